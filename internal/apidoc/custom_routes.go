@@ -36,15 +36,141 @@ func defineCustomRoutes() []CustomRoute {
 			Method:      "POST",
 			Path:        "/api/v1/users/export",
 			Summary:     "Export Users",
-			Description: "Export users data (requires export permission)",
+			Description: "Export the users the requester can list to a file (requires export permission; the users list rule applies). The optional body selects the format, fields, filter, sort, file name and expiry, and is validated against the users collection. Returns the existing job while the same export is queued or running, or when retried with the same Idempotency-Key",
 			Tags:        []string{"Users"},
 			Protected:   true,
+			Parameters: []Parameter{
+				{
+					Name:        "format",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "string", "enum": []string{"csv", "xlsx", "json", "ndjson", "pdf"}},
+					Description: "File format of the export when the body has no format (defaults to csv)",
+				},
+				{
+					Name:        "Idempotency-Key",
+					In:          "header",
+					Required:    false,
+					Schema:      map[string]any{"type": "string"},
+					Description: "Client generated key; retries with the same key within 24 hours return the original export job",
+				},
+			},
+			RequestBody: &RequestBody{
+				Description: "Export options; every property is optional",
+				Required:    false,
+				Content: map[string]MediaType{
+					"application/json": {
+						Schema: map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"format": map[string]any{
+									"type":        "string",
+									"enum":        []string{"csv", "xlsx", "json", "ndjson", "pdf"},
+									"description": "File format of the export (defaults to the format query parameter, else csv)",
+								},
+								"fields": map[string]any{
+									"type":        "array",
+									"items":       map[string]any{"type": "string"},
+									"description": "Exported fields, in order; relation.field shows a field of the related records (defaults to id, email, name, emailVisibility, verified, is_active, roles, permissions.slug, created, updated)",
+									"example":     []string{"email", "name", "roles.name", "created"},
+								},
+								"filter": map[string]any{
+									"type":        "string",
+									"description": "PocketBase filter of the exported users",
+									"example":     "verified = true && created >= '2025-01-01'",
+								},
+								"sort": map[string]any{
+									"type":        "string",
+									"description": "PocketBase sort of the exported users (defaults to -created)",
+									"example":     "name,-created",
+								},
+								"filename_prefix": map[string]any{
+									"type":        "string",
+									"pattern":     "^[A-Za-z0-9][A-Za-z0-9_-]{0,99}$",
+									"description": "Start of the file name, followed by the export time (defaults to users_export)",
+									"example":     "verified_users",
+								},
+								"result_expiry": map[string]any{
+									"type":        "string",
+									"description": "How long the export file is kept, as a duration between 1h and 8760h (defaults to EXPORT_FILE_EXPIRATION_DAYS)",
+									"example":     "72h",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/v1/imports",
+			Summary:     "Import Data",
+			Description: "Upload a CSV, XLSX, JSON or NDJSON file and queue its import into a collection (requires data.import permission; the create and update rules of the collection apply to each row). Invalid rows are left out and listed in an error report downloaded with the job file route. Returns the existing job when retried with the same Idempotency-Key",
+			Tags:        []string{"Imports"},
+			Protected:   true,
+			Parameters: []Parameter{
+				{
+					Name:        "Idempotency-Key",
+					In:          "header",
+					Required:    false,
+					Schema:      map[string]any{"type": "string"},
+					Description: "Client generated key; retries with the same key within 24 hours return the original import job",
+				},
+			},
+			RequestBody: &RequestBody{
+				Description: "The import file and its options",
+				Required:    true,
+				Content: map[string]MediaType{
+					"multipart/form-data": {
+						Schema: map[string]any{
+							"type":     "object",
+							"required": []string{"file", "collection"},
+							"properties": map[string]any{
+								"file": map[string]any{
+									"type":        "string",
+									"format":      "binary",
+									"description": "File to import, with a header row for CSV and XLSX (at most 32 MB)",
+								},
+								"collection": map[string]any{
+									"type":        "string",
+									"description": "Name of the collection the records are imported into",
+									"example":     "products",
+								},
+								"format": map[string]any{
+									"type":        "string",
+									"enum":        []string{"csv", "xlsx", "json", "ndjson"},
+									"description": "Format of the file (defaults to the format of the file extension)",
+								},
+								"mapping": map[string]any{
+									"type":        "string",
+									"description": "JSON object of file columns to collection fields; only the mapped columns are imported (defaults to the columns named after a field)",
+									"example":     `{"Product name":"name","Price":"price"}`,
+								},
+								"mode": map[string]any{
+									"type":        "string",
+									"enum":        []string{"insert", "upsert"},
+									"description": "insert creates a record per row; upsert updates the record with the same key field, or creates one (defaults to insert)",
+								},
+								"key_field": map[string]any{
+									"type":        "string",
+									"description": "Field matching the existing records of an upsert: id or a field with a unique index (defaults to id)",
+									"example":     "sku",
+								},
+								"dry_run": map[string]any{
+									"type":        "boolean",
+									"description": "Validate the rows and report their errors without saving them",
+								},
+							},
+						},
+					},
+				},
+			},
 		},
 		{
 			Method:      "GET",
 			Path:        "/api/v1/jobs/{id}/status",
 			Summary:     "Get Job Status",
-			Description: "Get the status of a specific job",
+			Description: "Get the state, progress, attempts, last error, timestamps and result of a specific job (owner or job.view.all permission)",
 			Tags:        []string{"Jobs"},
 			Protected:   true,
 			Parameters: []Parameter{
@@ -61,7 +187,165 @@ func defineCustomRoutes() []CustomRoute {
 			Method:      "POST",
 			Path:        "/api/v1/jobs/{id}/download",
 			Summary:     "Download Job File",
-			Description: "Download the file associated with a job",
+			Description: "Download the file associated with a job (owner or job.view.all permission)",
+			Tags:        []string{"Jobs"},
+			Protected:   true,
+			Parameters: []Parameter{
+				{
+					Name:        "id",
+					In:          "path",
+					Required:    true,
+					Schema:      map[string]any{"type": "string"},
+					Description: "The unique identifier of the job",
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/v1/admin/jobs",
+			Summary:     "List Jobs",
+			Description: "List jobs filtered by type, status, queue, owner and created date, newest first (requires job.view.all permission)",
+			Tags:        []string{"Jobs"},
+			Protected:   true,
+			Parameters: []Parameter{
+				{
+					Name:        "type",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "string"},
+					Description: "Filter by job type (e.g. email, data_processing)",
+				},
+				{
+					Name:        "status",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "string", "enum": []string{"queued", "processing", "completed", "failed", "dead", "canceled"}},
+					Description: "Filter by job status",
+				},
+				{
+					Name:        "queue",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "string"},
+					Description: "Filter by queue name",
+				},
+				{
+					Name:        "user_id",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "string"},
+					Description: "Filter by the ID of the job owner",
+				},
+				{
+					Name:        "from",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "string"},
+					Description: "Only jobs created at or after this date (YYYY-MM-DD or RFC 3339)",
+				},
+				{
+					Name:        "to",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "string"},
+					Description: "Only jobs created at or before this date (YYYY-MM-DD or RFC 3339)",
+				},
+				{
+					Name:        "page",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "integer", "minimum": 1},
+					Description: "Page number (defaults to 1)",
+				},
+				{
+					Name:        "per_page",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "integer", "minimum": 1, "maximum": 200},
+					Description: "Jobs per page (defaults to 30, at most 200)",
+				},
+			},
+		},
+		{
+			Method:      "DELETE",
+			Path:        "/api/v1/admin/jobs",
+			Summary:     "Purge Jobs",
+			Description: "Delete completed, dead or canceled jobs in bulk (requires job.purge permission)",
+			Tags:        []string{"Jobs"},
+			Protected:   true,
+			Parameters: []Parameter{
+				{
+					Name:        "status",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "string"},
+					Description: "Comma separated statuses to purge: completed, dead and/or canceled (defaults to completed,dead)",
+				},
+				{
+					Name:        "before",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "string"},
+					Description: "Only purge jobs last updated before this date (YYYY-MM-DD or RFC 3339)",
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/v1/admin/jobs/{id}",
+			Summary:     "Get Job Details",
+			Description: "Get a job with its payload and error history (requires job.view.all permission)",
+			Tags:        []string{"Jobs"},
+			Protected:   true,
+			Parameters: []Parameter{
+				{
+					Name:        "id",
+					In:          "path",
+					Required:    true,
+					Schema:      map[string]any{"type": "string"},
+					Description: "The unique identifier of the job",
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/v1/admin/jobs/batches/{id}",
+			Summary:     "Get Batch Status",
+			Description: "Get the aggregate status of a job batch: pending, completed and failed job counts and the follow-up job (requires job.view.all permission)",
+			Tags:        []string{"Jobs"},
+			Protected:   true,
+			Parameters: []Parameter{
+				{
+					Name:        "id",
+					In:          "path",
+					Required:    true,
+					Schema:      map[string]any{"type": "string"},
+					Description: "The unique identifier of the batch",
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/v1/admin/jobs/{id}/retry",
+			Summary:     "Retry Job",
+			Description: "Requeue a failed, dead or canceled job with a fresh attempt budget (requires job.retry permission)",
+			Tags:        []string{"Jobs"},
+			Protected:   true,
+			Parameters: []Parameter{
+				{
+					Name:        "id",
+					In:          "path",
+					Required:    true,
+					Schema:      map[string]any{"type": "string"},
+					Description: "The unique identifier of the job",
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/v1/admin/jobs/{id}/cancel",
+			Summary:     "Cancel Job",
+			Description: "Cancel a queued, failed or running job; running jobs are interrupted (requires job.cancel permission)",
 			Tags:        []string{"Jobs"},
 			Protected:   true,
 			Parameters: []Parameter{
@@ -74,5 +358,147 @@ func defineCustomRoutes() []CustomRoute {
 				},
 			},
 		},
+		{
+			Method:      "GET",
+			Path:        "/api/v1/admin/crons",
+			Summary:     "List Crons",
+			Description: "List the defined crons with their schedule, pause state, next fire time and last run (requires cron.view permission)",
+			Tags:        []string{"Crons"},
+			Protected:   true,
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/v1/admin/crons/preview",
+			Summary:     "Preview Cron Schedule",
+			Description: "List the next runs of a cron expression, e.g. to check a schedule before saving it (requires cron.view permission)",
+			Tags:        []string{"Crons"},
+			Protected:   true,
+			Parameters: []Parameter{
+				{
+					Name:        "expr",
+					In:          "query",
+					Required:    true,
+					Schema:      map[string]any{"type": "string"},
+					Description: "Cron expression, with names, L and # modifiers, @ macros and an optional TZ= prefix (e.g. 0 9 * * MON-FRI)",
+				},
+				{
+					Name:        "timezone",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "string"},
+					Description: "IANA timezone the expression is evaluated in (defaults to UTC)",
+				},
+				{
+					Name:        "count",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "integer", "minimum": 1, "maximum": 100},
+					Description: "Number of runs to list (defaults to 5, at most 100)",
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/v1/admin/crons/{id}/run",
+			Summary:     "Run Cron",
+			Description: "Run an enabled cron now in the background, even when paused; 409 while it is already running (requires cron.manage permission)",
+			Tags:        []string{"Crons"},
+			Protected:   true,
+			Parameters: []Parameter{
+				{
+					Name:        "id",
+					In:          "path",
+					Required:    true,
+					Schema:      map[string]any{"type": "string"},
+					Description: "The cron ID (e.g. clean_exported_files)",
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/v1/admin/crons/{id}/pause",
+			Summary:     "Pause Cron",
+			Description: "Skip the scheduled runs of a cron on every instance until it is resumed (requires cron.manage permission)",
+			Tags:        []string{"Crons"},
+			Protected:   true,
+			Parameters: []Parameter{
+				{
+					Name:        "id",
+					In:          "path",
+					Required:    true,
+					Schema:      map[string]any{"type": "string"},
+					Description: "The cron ID (e.g. clean_exported_files)",
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/v1/admin/crons/{id}/resume",
+			Summary:     "Resume Cron",
+			Description: "Resume the scheduled runs of a paused cron (requires cron.manage permission)",
+			Tags:        []string{"Crons"},
+			Protected:   true,
+			Parameters: []Parameter{
+				{
+					Name:        "id",
+					In:          "path",
+					Required:    true,
+					Schema:      map[string]any{"type": "string"},
+					Description: "The cron ID (e.g. clean_exported_files)",
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/v1/admin/crons/runs",
+			Summary:     "List Cron Runs",
+			Description: "List the run history of the crons, newest first, with their outcome, duration and error (requires cron.view permission)",
+			Tags:        []string{"Crons"},
+			Protected:   true,
+			Parameters: []Parameter{
+				{
+					Name:        "cron_id",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "string"},
+					Description: "Filter by cron ID (e.g. system_queue)",
+				},
+				{
+					Name:        "status",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "string", "enum": []string{"running", "success", "failed", "skipped"}},
+					Description: "Filter by run status",
+				},
+				{
+					Name:        "from",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "string"},
+					Description: "Only runs started at or after this date (YYYY-MM-DD or RFC 3339)",
+				},
+				{
+					Name:        "to",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "string"},
+					Description: "Only runs started at or before this date (YYYY-MM-DD or RFC 3339)",
+				},
+				{
+					Name:        "page",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "integer", "minimum": 1},
+					Description: "Page number (defaults to 1)",
+				},
+				{
+					Name:        "per_page",
+					In:          "query",
+					Required:    false,
+					Schema:      map[string]any{"type": "integer", "minimum": 1, "maximum": 200},
+					Description: "Runs per page (defaults to 30, at most 200)",
+				},
+			},
+		},
 	}
 }
@@ -390,6 +390,8 @@ func (g *Generator) buildTags(collections []CollectionInfo, routes []GeneratedRo
 	tagMap["System"] = "System health and monitoring endpoints"
 	tagMap["Custom"] = "Custom API endpoints"
 	tagMap["Jobs"] = "Job management endpoints"
+	tagMap["Crons"] = "Cron management endpoints"
+	tagMap["Imports"] = "Data import endpoints"
 	tagMap["Users"] = "User management endpoints"
 
 	if g.config.EnableAuth {
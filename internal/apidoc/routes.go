@@ -67,13 +67,14 @@ type SecurityRequirement map[string][]string
 
 // CustomRoute represents a manually defined route
 type CustomRoute struct {
-	Method      string      `json:"method"`
-	Path        string      `json:"path"`
-	Summary     string      `json:"summary"`
-	Description string      `json:"description"`
-	Tags        []string    `json:"tags"`
-	Protected   bool        `json:"protected"`
-	Parameters  []Parameter `json:"parameters,omitempty"`
+	Method      string       `json:"method"`
+	Path        string       `json:"path"`
+	Summary     string       `json:"summary"`
+	Description string       `json:"description"`
+	Tags        []string     `json:"tags"`
+	Protected   bool         `json:"protected"`
+	Parameters  []Parameter  `json:"parameters,omitempty"`
+	RequestBody *RequestBody `json:"requestBody,omitempty"`
 }
 
 // RouteGen interface for route generation
@@ -1286,6 +1287,7 @@ func (rg *RouteGenerator) convertCustomRoute(custom CustomRoute) GeneratedRoute
 		Description: custom.Description,
 		Tags:        custom.Tags,
 		Parameters:  custom.Parameters,
+		RequestBody: custom.RequestBody,
 		OperationID: rg.generateOperationID(custom.Method, custom.Path),
 		Responses: map[string]Response{
 			"200": {
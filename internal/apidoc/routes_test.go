@@ -260,3 +260,29 @@ func TestGetAllRoutes(t *testing.T) {
 		t.Error("Expected custom route to be included in all routes")
 	}
 }
+
+func TestConvertCustomRouteRequestBody(t *testing.T) {
+	app := pocketbase.New()
+	schemaGen := &mockSchemaGen{}
+	generator := NewRouteGeneratorWithFullConfig(app, schemaGen, true, false)
+
+	body := &RequestBody{
+		Content: map[string]MediaType{
+			"application/json": {Schema: map[string]any{"type": "object"}},
+		},
+	}
+
+	route := generator.convertCustomRoute(CustomRoute{
+		Method:      "POST",
+		Path:        "/api/v1/users/export",
+		Protected:   true,
+		RequestBody: body,
+	})
+
+	if route.RequestBody != body {
+		t.Error("Expected the request body of the custom route")
+	}
+	if len(route.Security) != 1 {
+		t.Error("Expected a protected route to require authentication")
+	}
+}
@@ -10,15 +10,19 @@ import (
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/plugins/migratecmd"
+	"github.com/spf13/cobra"
 
 	"ims-pocketbase-baas-starter/internal/apidoc"
 	"ims-pocketbase-baas-starter/internal/commands"
 	"ims-pocketbase-baas-starter/internal/crons"
 	_ "ims-pocketbase-baas-starter/internal/database/migrations" //side effect migration load(from pocketbase)
+	"ims-pocketbase-baas-starter/internal/handlers/command"
 	"ims-pocketbase-baas-starter/internal/hooks"
 	"ims-pocketbase-baas-starter/internal/jobs"
 	"ims-pocketbase-baas-starter/internal/middlewares"
 	"ims-pocketbase-baas-starter/internal/routes"
+	"ims-pocketbase-baas-starter/pkg/common"
+	"ims-pocketbase-baas-starter/pkg/jobutils"
 	"ims-pocketbase-baas-starter/pkg/logger"
 	"ims-pocketbase-baas-starter/pkg/metrics"
 )
@@ -36,6 +40,9 @@ func NewApp() *pocketbase.PocketBase {
 	logger := logger.GetLogger(app)
 	logger.SetStoreLogs(true) // Enable storing logs in DB
 
+	mode := common.GetAppMode()
+	logger.Info("Configuring app", "mode", mode)
+
 	metricsProvider := metrics.GetInstance()
 	logger.Info("Metrics provider initialized", "provider", metricsProvider != nil)
 
@@ -67,7 +74,32 @@ func NewApp() *pocketbase.PocketBase {
 		log.Fatalf("Failed to register hooks: %v", err)
 	}
 
+	if mode == common.AppModeWorker {
+		// the serve command runs the job workers instead of the HTTP server, so the same image
+		// and command can be deployed as API and as worker instances
+		app.OnBootstrap().BindFunc(func(be *core.BootstrapEvent) error {
+			if err := be.Next(); err != nil {
+				return err
+			}
+			if serveCmd, _, err := app.RootCmd.Find([]string{"serve"}); err == nil && serveCmd != app.RootCmd {
+				serveCmd.RunE = func(cmd *cobra.Command, args []string) error {
+					command.HandleJobsWorkCommand(app, cmd, args)
+					return nil
+				}
+			}
+			return nil
+		})
+	}
+
 	app.OnTerminate().BindFunc(func(te *core.TerminateEvent) error {
+		gracePeriod := jobutils.GetShutdownGracePeriod()
+		logger.Info("Draining job worker pools", "grace_period", gracePeriod.String())
+		ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+		if err := jobManager.Shutdown(ctx); err != nil {
+			logger.Warn("Job worker pools did not drain cleanly", "error", err)
+		}
+		cancel()
+
 		if metricsProvider != nil {
 			logger.Info("Shutting down metrics provider")
 			if err := metricsProvider.Shutdown(context.Background()); err != nil {
@@ -101,6 +133,12 @@ func NewApp() *pocketbase.PocketBase {
 
 		apidoc.RegisterEndpoints(se, generator)
 
+		// Dispatch queued jobs continuously instead of waiting for the system_queue cron;
+		// in http mode jobs are left to the worker instances
+		if dispatcher := jobManager.GetDispatcher(); dispatcher != nil && mode == common.AppModeAll && common.GetEnvBool("JOB_DISPATCHER_ENABLED", true) {
+			dispatcher.Start()
+		}
+
 		return se.Next()
 	})
 
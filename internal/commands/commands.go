@@ -15,6 +15,7 @@ type Command struct {
 	Short   string                                                 // Short description of the command
 	Long    string                                                 // Long description of the command
 	Handler func(*pocketbase.PocketBase, *cobra.Command, []string) // Handler function to execute
+	Flags   func(*cobra.Command)                                   // Registers the command flags (optional)
 	Enabled bool                                                   // Whether the command should be registered
 }
 
@@ -67,6 +68,83 @@ func RegisterCommands(app *pocketbase.PocketBase) error {
 			Handler: command.HandleSeedUsersWithRoleCommand,
 			Enabled: true,
 		},
+		{
+			ID:      "jobs:list",
+			Use:     "jobs:list",
+			Short:   "List jobs in the queue",
+			Long:    "Lists jobs newest first, optionally filtered by status, type and queue",
+			Handler: command.HandleJobsListCommand,
+			Flags:   command.JobsListFlags,
+			Enabled: true,
+		},
+		{
+			ID:      "jobs:retry",
+			Use:     "jobs:retry [job-id]",
+			Short:   "Retry a failed job or every failed job",
+			Long:    "Requeues a failed, dead or canceled job with a fresh attempt budget, or every failed and dead job with --all-failed",
+			Handler: command.HandleJobsRetryCommand,
+			Flags:   command.JobsRetryFlags,
+			Enabled: true,
+		},
+		{
+			ID:      "jobs:purge",
+			Use:     "jobs:purge",
+			Short:   "Delete finished jobs",
+			Long:    "Deletes completed and dead jobs (or the given statuses) last updated longer ago than --older-than",
+			Handler: command.HandleJobsPurgeCommand,
+			Flags:   command.JobsPurgeFlags,
+			Enabled: true,
+		},
+		{
+			ID:      "jobs:work",
+			Use:     "jobs:work",
+			Short:   "Process queued jobs without the HTTP server",
+			Long:    "Runs the job workers of every queue (or only --queue) until interrupted, or drains the ready jobs and exits with --once",
+			Handler: command.HandleJobsWorkCommand,
+			Flags:   command.JobsWorkFlags,
+			Enabled: true,
+		},
+		{
+			ID:      "jobs:enqueue",
+			Use:     "jobs:enqueue <type> <json-data>",
+			Short:   "Queue a job",
+			Long:    "Queues a job of a registered type with the given JSON object as its payload data",
+			Handler: command.HandleJobsEnqueueCommand,
+			Flags:   command.JobsEnqueueFlags,
+			Enabled: true,
+		},
+		{
+			ID:      "crons:list",
+			Use:     "crons:list",
+			Short:   "List the crons",
+			Long:    "Lists the defined crons with their schedule, state (enabled, disabled or paused), next fire time and last run",
+			Handler: command.HandleCronsListCommand,
+			Enabled: true,
+		},
+		{
+			ID:      "crons:run",
+			Use:     "crons:run <cron-id>",
+			Short:   "Run a cron now",
+			Long:    "Runs an enabled cron once in this process, even when it is paused, and waits for it to finish",
+			Handler: command.HandleCronsRunCommand,
+			Enabled: true,
+		},
+		{
+			ID:      "crons:pause",
+			Use:     "crons:pause <cron-id>",
+			Short:   "Pause a cron",
+			Long:    "Skips the scheduled runs of a cron on every instance until it is resumed",
+			Handler: command.HandleCronsPauseCommand,
+			Enabled: true,
+		},
+		{
+			ID:      "crons:resume",
+			Use:     "crons:resume <cron-id>",
+			Short:   "Resume a paused cron",
+			Long:    "Resumes the scheduled runs of a cron paused with crons:pause or the admin API",
+			Handler: command.HandleCronsResumeCommand,
+			Enabled: true,
+		},
 		// Add more commands here as needed:
 		// {
 		//     ID:      "example",
@@ -94,6 +172,10 @@ func RegisterCommands(app *pocketbase.PocketBase) error {
 			},
 		}
 
+		if cmd.Flags != nil {
+			cmd.Flags(cobraCmd)
+		}
+
 		// Register the command with PocketBase
 		app.RootCmd.AddCommand(cobraCmd)
 	}
@@ -36,6 +36,33 @@ func TestRegisterCommands(t *testing.T) {
 	}
 }
 
+func TestRegisterJobsCommands(t *testing.T) {
+	app := pocketbase.New()
+
+	if err := RegisterCommands(app); err != nil {
+		t.Fatalf("RegisterCommands failed: %v", err)
+	}
+
+	expectedFlags := map[string]string{
+		"jobs:list":    "status",
+		"jobs:retry":   "all-failed",
+		"jobs:purge":   "older-than",
+		"jobs:work":    "once",
+		"jobs:enqueue": "queue",
+	}
+
+	for name, flag := range expectedFlags {
+		cmd, _, err := app.RootCmd.Find([]string{name})
+		if err != nil || cmd == app.RootCmd {
+			t.Errorf("Expected command '%s' not found", name)
+			continue
+		}
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected command '%s' to have the --%s flag", name, flag)
+		}
+	}
+}
+
 func TestRegisterCommandsWithNilApp(t *testing.T) {
 	err := RegisterCommands(nil)
 	if err == nil {
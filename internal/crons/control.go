@@ -0,0 +1,164 @@
+package crons
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/cronutils"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+var (
+	// ErrCronNotFound is returned for a cron ID that is not defined
+	ErrCronNotFound = errors.New("cron not found")
+
+	// ErrCronDisabled is returned when triggering a cron that is disabled in this instance
+	ErrCronDisabled = errors.New("cron is disabled")
+)
+
+var (
+	cronsMu sync.RWMutex
+	defined []Cron
+)
+
+// CronInfo is the runtime state of a defined cron
+type CronInfo struct {
+	ID          string             `json:"id"`
+	CronExpr    string             `json:"cron_expr"`
+	Timezone    string             `json:"timezone"`
+	Description string             `json:"description"`
+	Enabled     bool               `json:"enabled"`
+	Paused      bool               `json:"paused"`
+	PausedAt    *time.Time         `json:"paused_at"`
+	PausedBy    string             `json:"paused_by"`
+	NextRunAt   *time.Time         `json:"next_run_at"`
+	LastRun     *cronutils.CronRun `json:"last_run"`
+}
+
+// ToMap returns the cron as response data
+func (c *CronInfo) ToMap() map[string]any {
+	var lastRun any
+	if c.LastRun != nil {
+		lastRun = c.LastRun.ToMap()
+	}
+
+	return map[string]any{
+		"id":          c.ID,
+		"cron_expr":   c.CronExpr,
+		"timezone":    c.Timezone,
+		"description": c.Description,
+		"enabled":     c.Enabled,
+		"paused":      c.Paused,
+		"paused_at":   c.PausedAt,
+		"paused_by":   c.PausedBy,
+		"next_run_at": c.NextRunAt,
+		"last_run":    lastRun,
+	}
+}
+
+// setCrons keeps the cron definitions of RegisterCrons
+func setCrons(crons []Cron) {
+	cronsMu.Lock()
+	defer cronsMu.Unlock()
+	defined = slices.Clone(crons)
+}
+
+// GetCron returns the definition of a cron
+func GetCron(id string) (Cron, error) {
+	cronsMu.RLock()
+	defer cronsMu.RUnlock()
+
+	for _, cron := range defined {
+		if cron.ID == id {
+			return cron, nil
+		}
+	}
+	return Cron{}, fmt.Errorf("%w: %s", ErrCronNotFound, id)
+}
+
+// ListCrons returns every defined cron with its schedule, pause state, next fire time and last run.
+// Disabled and paused crons have no next fire time.
+func ListCrons(app core.App) ([]*CronInfo, error) {
+	cronsMu.RLock()
+	crons := slices.Clone(defined)
+	cronsMu.RUnlock()
+
+	paused, err := cronutils.PausedCrons(app)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	infos := make([]*CronInfo, 0, len(crons))
+	for _, cron := range crons {
+		info := &CronInfo{
+			ID:          cron.ID,
+			CronExpr:    cron.CronExpr,
+			Description: cron.Description,
+			Enabled:     cron.Enabled,
+		}
+
+		if pause, ok := paused[cron.ID]; ok {
+			info.Paused = true
+			info.PausedAt = pause.PausedAt
+			info.PausedBy = pause.PausedBy
+		}
+
+		if schedule, err := cron.Schedule(); err == nil {
+			info.Timezone = schedule.Location.String()
+			if cron.Enabled && !info.Paused {
+				if next, err := schedule.Next(now); err == nil {
+					info.NextRunAt = &next
+				}
+			}
+		}
+
+		runs, err := cronutils.ListCronRuns(app, cronutils.CronRunFilter{CronID: cron.ID, PerPage: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(runs.Items) > 0 {
+			info.LastRun = runs.Items[0]
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// TriggerCron runs an enabled cron now, outside its schedule and even when it is paused, in the
+// background. It returns the started run and a channel that receives the run once it finished.
+func TriggerCron(app core.App, id string) (*cronutils.CronRun, <-chan *cronutils.CronRun, error) {
+	cron, err := GetCron(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !cron.Enabled {
+		return nil, nil, fmt.Errorf("%w: %s", ErrCronDisabled, id)
+	}
+
+	return cronutils.StartCronRun(app, cron.ID, cron.LockTimeout, cron.Handler)
+}
+
+// PauseCron pauses the scheduled runs of a cron on every instance until it is resumed
+func PauseCron(app core.App, id string, pausedBy string) (*cronutils.CronPause, error) {
+	if _, err := GetCron(id); err != nil {
+		return nil, err
+	}
+
+	return cronutils.PauseCron(app, id, pausedBy)
+}
+
+// ResumeCron resumes the scheduled runs of a paused cron
+func ResumeCron(app core.App, id string) error {
+	if _, err := GetCron(id); err != nil {
+		return err
+	}
+
+	return cronutils.ResumeCron(app, id)
+}
@@ -0,0 +1,82 @@
+package crons
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/cronutils"
+
+	"github.com/pocketbase/pocketbase"
+)
+
+func TestGetCron(t *testing.T) {
+	app := pocketbase.New()
+	if err := RegisterCrons(app); err != nil {
+		t.Fatalf("RegisterCrons failed: %v", err)
+	}
+
+	cron, err := GetCron("clean_exported_files")
+	if err != nil {
+		t.Fatalf("expected clean_exported_files to be defined: %v", err)
+	}
+	if cron.CronExpr != "0 2 * * *" || cron.Handler == nil {
+		t.Errorf("unexpected cron definition: %+v", cron)
+	}
+
+	if _, err := GetCron("unknown"); !errors.Is(err, ErrCronNotFound) {
+		t.Errorf("expected ErrCronNotFound, got %v", err)
+	}
+}
+
+func TestTriggerDisabledCron(t *testing.T) {
+	app := pocketbase.New()
+	t.Setenv("APP_MODE", "http")
+
+	if err := RegisterCrons(app); err != nil {
+		t.Fatalf("RegisterCrons failed: %v", err)
+	}
+
+	// system_queue is defined but disabled in http mode
+	if _, _, err := TriggerCron(app, "system_queue"); !errors.Is(err, ErrCronDisabled) {
+		t.Errorf("expected ErrCronDisabled, got %v", err)
+	}
+
+	if _, _, err := TriggerCron(app, "unknown"); !errors.Is(err, ErrCronNotFound) {
+		t.Errorf("expected ErrCronNotFound, got %v", err)
+	}
+	if _, err := PauseCron(app, "unknown", "cli"); !errors.Is(err, ErrCronNotFound) {
+		t.Errorf("expected ErrCronNotFound when pausing, got %v", err)
+	}
+	if err := ResumeCron(app, "unknown"); !errors.Is(err, ErrCronNotFound) {
+		t.Errorf("expected ErrCronNotFound when resuming, got %v", err)
+	}
+}
+
+func TestCronInfoToMap(t *testing.T) {
+	next := time.Date(2025, 3, 2, 2, 0, 0, 0, time.UTC)
+	info := &CronInfo{
+		ID:        "clean_exported_files",
+		CronExpr:  "0 2 * * *",
+		Enabled:   true,
+		NextRunAt: &next,
+		LastRun:   &cronutils.CronRun{ID: "run-1", Status: cronutils.CronRunStatusSuccess},
+	}
+
+	data := info.ToMap()
+
+	if data["id"] != "clean_exported_files" || data["paused"] != false {
+		t.Errorf("unexpected cron data: %v", data)
+	}
+	if data["next_run_at"] != &next {
+		t.Errorf("expected next_run_at %v, got %v", next, data["next_run_at"])
+	}
+	lastRun, ok := data["last_run"].(map[string]any)
+	if !ok || lastRun["status"] != cronutils.CronRunStatusSuccess {
+		t.Errorf("unexpected last run: %v", data["last_run"])
+	}
+
+	if (&CronInfo{}).ToMap()["last_run"] != nil {
+		t.Error("expected no last run for a cron that never ran")
+	}
+}
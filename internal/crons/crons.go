@@ -1,9 +1,12 @@
 package crons
 
 import (
+	"fmt"
 	"os"
+	"time"
 
 	"ims-pocketbase-baas-starter/internal/handlers/cron"
+	"ims-pocketbase-baas-starter/pkg/common"
 	"ims-pocketbase-baas-starter/pkg/cronutils"
 	log "ims-pocketbase-baas-starter/pkg/logger"
 
@@ -12,11 +15,26 @@ import (
 
 // Cron represents a scheduled cron job with its configuration
 type Cron struct {
-	ID          string // Unique identifier for the cron
-	CronExpr    string // Cron expression for scheduling (e.g., "0 2 * * *")
-	Handler     func() // Function to execute when cron job runs
-	Enabled     bool   // Whether the cron job should be registered and executed
-	Description string // Human-readable description of what the cron job does
+	ID          string        // Unique identifier for the cron
+	CronExpr    string        // Cron expression for scheduling (e.g., "0 2 * * *")
+	Handler     func()        // Function to execute when cron job runs
+	Enabled     bool          // Whether the cron job should be registered and executed
+	Description string        // Human-readable description of what the cron job does
+	LockTimeout time.Duration // How long a run may hold the overlap lock before it is considered abandoned (1 hour when 0)
+	Timezone    string        // IANA timezone the expression is evaluated in (UTC when empty, a TZ= prefix wins)
+}
+
+// Schedule parses the cron expression of the cron in its timezone
+func (c Cron) Schedule() (*cronutils.Schedule, error) {
+	location := time.UTC
+	if c.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(c.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q of cron %s: %w", c.Timezone, c.ID, err)
+		}
+	}
+
+	return cronutils.ParseSchedule(c.CronExpr, location)
 }
 
 // RegisterCrons registers all scheduled crons with the PocketBase application
@@ -34,15 +52,30 @@ func RegisterCrons(app *pocketbase.PocketBase) error {
 			ID:          "system_queue",
 			CronExpr:    "* * * * *", // every minutes
 			Handler:     cronutils.WithRecovery(app, "system_queue", func() { cron.HandleSystemQueue(app) }),
-			Enabled:     os.Getenv("ENABLE_SYSTEM_QUEUE_CRON") != "false", // Enabled by default
+			Enabled:     os.Getenv("ENABLE_SYSTEM_QUEUE_CRON") != "false" && common.GetAppMode() != common.AppModeHTTP, // Enabled by default, except in http mode
 			Description: "Process the system queue ",
+			LockTimeout: 15 * time.Minute,
 		},
 		{
 			ID:          "clean_exported_files",
 			CronExpr:    "0 2 * * *", // every day at 2:00 AM
 			Handler:     cronutils.WithRecovery(app, "clean_exported_files", func() { cron.HandleClearExportFiles(app) }),
 			Enabled:     os.Getenv("ENABLE_CLEAR_EXPORT_FILES_CRON") != "false", // Enabled by default
-			Description: "Delete the expired job generated export files",
+			Description: "Delete the expired job generated export files and import uploads",
+		},
+		{
+			ID:          "clear_completed_jobs",
+			CronExpr:    "0 * * * *", // every hour
+			Handler:     cronutils.WithRecovery(app, "clear_completed_jobs", func() { cron.HandleClearCompletedJobs(app) }),
+			Enabled:     os.Getenv("ENABLE_CLEAR_COMPLETED_JOBS_CRON") != "false", // Enabled by default
+			Description: "Delete completed queue jobs older than the retention window",
+		},
+		{
+			ID:          "scheduled_jobs",
+			CronExpr:    "* * * * *", // every minute
+			Handler:     cronutils.WithRecovery(app, "scheduled_jobs", func() { cron.HandleScheduledJobs(app) }),
+			Enabled:     os.Getenv("ENABLE_SCHEDULED_JOBS_CRON") != "false", // Enabled by default
+			Description: "Queue the recurring jobs of the scheduled_jobs collection that are due",
 		},
 		// Add more cron jobs here as needed:
 		// {
@@ -56,6 +89,9 @@ func RegisterCrons(app *pocketbase.PocketBase) error {
 
 	log.Info("Registering cron jobs", "total_cron_jobs", len(crons))
 
+	// Keep the definitions for listing, triggering and pausing the crons at runtime
+	setCrons(crons)
+
 	// Register enabled cron jobs with PocketBase cron scheduler
 	for _, cronJob := range crons {
 		if !cronJob.Enabled {
@@ -63,16 +99,22 @@ func RegisterCrons(app *pocketbase.PocketBase) error {
 			continue
 		}
 
-		if err := cronutils.ValidateCronExpression(cronJob.CronExpr); err != nil {
-			log.Error("Invalid cron expression for cron job", "cron_id", cronJob.ID, "cron", cronJob.CronExpr, "error", err)
+		schedule, err := cronJob.Schedule()
+		if err != nil {
+			log.Error("Invalid cron expression for cron job", "cron_id", cronJob.ID, "cron", cronJob.CronExpr, "timezone", cronJob.Timezone, "error", err)
 			return err
 		}
 
-		app.Cron().MustAdd(cronJob.ID, cronJob.CronExpr, cronJob.Handler)
+		// Record each run and skip it while the previous run is still going
+		if err := cronutils.AddToCron(app.Cron(), cronJob.ID, schedule, cronutils.WithRunHistory(app, cronJob.ID, cronJob.LockTimeout, cronJob.Handler)); err != nil {
+			log.Error("Failed to register cron job", "cron_id", cronJob.ID, "cron", cronJob.CronExpr, "error", err)
+			return err
+		}
 
 		log.Info("Registered cron job",
 			"cron_id", cronJob.ID,
 			"cron_expr", cronJob.CronExpr,
+			"timezone", schedule.Location.String(),
 			"description", cronJob.Description,
 		)
 	}
@@ -44,6 +44,21 @@ func TestRegisterCronsWithDisabledCrons(t *testing.T) {
 	}
 }
 
+func TestRegisterCronsInHTTPMode(t *testing.T) {
+	app := pocketbase.New()
+	t.Setenv("APP_MODE", "http")
+
+	if err := RegisterCrons(app); err != nil {
+		t.Fatalf("RegisterCrons in http mode failed: %v", err)
+	}
+
+	for _, job := range app.Cron().Jobs() {
+		if job.Id() == "system_queue" {
+			t.Error("system_queue cron should not be registered in http mode")
+		}
+	}
+}
+
 func TestCronStructure(t *testing.T) {
 	cron := Cron{
 		ID:          "test",
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Forward migration
+		schemaPath := filepath.Join("internal", "database", "schema", "0007_pb_schema.json")
+		schemaData, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return fmt.Errorf("failed to read schema file: %w", err)
+		}
+
+		var collections []any
+		if err := json.Unmarshal(schemaData, &collections); err != nil {
+			return fmt.Errorf("failed to parse schema JSON: %w", err)
+		}
+
+		collectionsData, err := json.Marshal(collections)
+		if err != nil {
+			return fmt.Errorf("failed to marshal collections: %w", err)
+		}
+
+		if err := app.ImportCollectionsByMarshaledJSON(collectionsData, false); err != nil {
+			return fmt.Errorf("failed to import collections: %w", err)
+		}
+
+		// Jobs queued before named queues existed belong to the default queue
+		if _, err := app.DB().NewQuery("UPDATE queues SET queue = 'default' WHERE queue = ''").Execute(); err != nil {
+			return fmt.Errorf("failed to backfill queue name: %w", err)
+		}
+
+		return nil
+	}, func(app core.App) error {
+		// Rollback migration
+		collection, err := app.FindCollectionByNameOrId("queues")
+		if err != nil {
+			return nil // Collection might not exist
+		}
+
+		fieldsToRemove := []string{"queue", "priority", "idempotency_key"}
+		for _, fieldName := range fieldsToRemove {
+			collection.Fields.RemoveByName(fieldName)
+		}
+		collection.RemoveIndex("idx_Kq4mWz7TnB")
+		collection.RemoveIndex("idx_Hd2sLx9PeG")
+
+		if err := app.Save(collection); err != nil {
+			return fmt.Errorf("failed to remove queue enqueue fields: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Forward migration
+		schemaPath := filepath.Join("internal", "database", "schema", "0013_pb_schema.json")
+		schemaData, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return fmt.Errorf("failed to read schema file: %w", err)
+		}
+
+		var collections []any
+		if err := json.Unmarshal(schemaData, &collections); err != nil {
+			return fmt.Errorf("failed to parse schema JSON: %w", err)
+		}
+
+		collectionsData, err := json.Marshal(collections)
+		if err != nil {
+			return fmt.Errorf("failed to marshal collections: %w", err)
+		}
+
+		if err := app.ImportCollectionsByMarshaledJSON(collectionsData, false); err != nil {
+			return fmt.Errorf("failed to import collections: %w", err)
+		}
+
+		return nil
+	}, func(app core.App) error {
+		// Rollback migration
+		collection, err := app.FindCollectionByNameOrId("queues")
+		if err != nil {
+			return nil // Collection might not exist
+		}
+
+		// canceled jobs have no equivalent before this migration, keep them out of the queue as dead jobs
+		if _, err := app.DB().Update("queues", dbx.Params{"status": "dead"}, dbx.HashExp{"status": "canceled"}).Execute(); err != nil {
+			return fmt.Errorf("failed to move canceled jobs to dead: %w", err)
+		}
+
+		if status, ok := collection.Fields.GetByName("status").(*core.SelectField); ok {
+			status.Values = slices.DeleteFunc(status.Values, func(value string) bool { return value == "canceled" })
+		}
+		collection.Fields.RemoveByName("error_history")
+
+		if err := app.Save(collection); err != nil {
+			return fmt.Errorf("failed to remove queue cancel and error history fields: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Forward migration
+		schemaPath := filepath.Join("internal", "database", "schema", "0014_pb_schema.json")
+		schemaData, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return fmt.Errorf("failed to read schema file: %w", err)
+		}
+
+		var collections []any
+		if err := json.Unmarshal(schemaData, &collections); err != nil {
+			return fmt.Errorf("failed to parse schema JSON: %w", err)
+		}
+
+		collectionsData, err := json.Marshal(collections)
+		if err != nil {
+			return fmt.Errorf("failed to marshal collections: %w", err)
+		}
+
+		if err := app.ImportCollectionsByMarshaledJSON(collectionsData, false); err != nil {
+			return fmt.Errorf("failed to import collections: %w", err)
+		}
+
+		return nil
+	}, func(app core.App) error {
+		// Rollback migration
+		if collection, err := app.FindCollectionByNameOrId("queues"); err == nil {
+			collection.Fields.RemoveByName("batch_id")
+			collection.Fields.RemoveByName("parent_id")
+			collection.Fields.RemoveByName("chain")
+			collection.RemoveIndex("idx_Mw5sGv1RbT")
+
+			if err := app.Save(collection); err != nil {
+				return fmt.Errorf("failed to remove queue batch and chain fields: %w", err)
+			}
+		}
+
+		if collection, err := app.FindCollectionByNameOrId("job_batches"); err == nil {
+			if err := app.Delete(collection); err != nil {
+				return fmt.Errorf("failed to delete collection job_batches: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
@@ -88,6 +88,9 @@ func seedRoles(app core.App) error {
 				permission.CacheClear, permission.UserCreate, permission.UserView, permission.UserViewAll, permission.UserUpdate, permission.UserDelete,
 				permission.UserRoleAssign, permission.UserPermissionAssign, permission.UserExport,
 				permission.RoleCreate, permission.RoleView, permission.RoleViewAll, permission.RoleUpdate, permission.RoleDelete,
+				permission.JobViewAll, permission.JobRetry, permission.JobCancel, permission.JobPurge,
+				permission.CronView, permission.CronManage,
+				permission.DataImport,
 			},
 		},
 		{
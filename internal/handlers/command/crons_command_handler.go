@@ -0,0 +1,116 @@
+package command
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"ims-pocketbase-baas-starter/internal/crons"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/spf13/cobra"
+)
+
+// cronPausedByCLI is recorded as who paused a cron from the CLI
+const cronPausedByCLI = "cli"
+
+// HandleCronsListCommand handles the 'crons:list' CLI command
+func HandleCronsListCommand(app *pocketbase.PocketBase, cmd *cobra.Command, args []string) {
+	list, err := crons.ListCrons(app)
+	if err != nil {
+		log.Error("Failed to list crons", "error", err)
+		fmt.Printf("❌ Error listing crons: %v\n", err)
+		return
+	}
+
+	if len(list) == 0 {
+		fmt.Println("No crons defined")
+		return
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSCHEDULE\tSTATE\tNEXT RUN\tLAST RUN\tLAST STATUS")
+	for _, cron := range list {
+		state := "enabled"
+		if !cron.Enabled {
+			state = "disabled"
+		} else if cron.Paused {
+			state = "paused"
+		}
+
+		nextRun := ""
+		if cron.NextRunAt != nil {
+			nextRun = cron.NextRunAt.Format(time.DateTime)
+		}
+
+		lastRun, lastStatus := "", ""
+		if cron.LastRun != nil {
+			lastStatus = cron.LastRun.Status
+			if cron.LastRun.StartedAt != nil {
+				lastRun = cron.LastRun.StartedAt.Format(time.DateTime)
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", cron.ID, cron.CronExpr, state, nextRun, lastRun, lastStatus)
+	}
+	w.Flush()
+}
+
+// HandleCronsRunCommand handles the 'crons:run' CLI command. It runs the cron in this process
+// and waits for it to finish.
+func HandleCronsRunCommand(app *pocketbase.PocketBase, cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		fmt.Println("❌ Usage: crons:run <cron-id>")
+		return
+	}
+
+	run, done, err := crons.TriggerCron(app, args[0])
+	if err != nil {
+		log.Error("Failed to run cron", "cron_id", args[0], "error", err)
+		fmt.Printf("❌ Error running cron: %v\n", err)
+		return
+	}
+
+	fmt.Printf("🔄 Running cron %s (run %s)...\n", args[0], run.ID)
+
+	finished := <-done
+	if finished.Error != "" {
+		fmt.Printf("❌ Cron %s %s after %dms: %s\n", args[0], finished.Status, finished.DurationMs, finished.Error)
+		return
+	}
+
+	fmt.Printf("✅ Cron %s finished in %dms\n", args[0], finished.DurationMs)
+}
+
+// HandleCronsPauseCommand handles the 'crons:pause' CLI command
+func HandleCronsPauseCommand(app *pocketbase.PocketBase, cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		fmt.Println("❌ Usage: crons:pause <cron-id>")
+		return
+	}
+
+	if _, err := crons.PauseCron(app, args[0], cronPausedByCLI); err != nil {
+		log.Error("Failed to pause cron", "cron_id", args[0], "error", err)
+		fmt.Printf("❌ Error pausing cron: %v\n", err)
+		return
+	}
+
+	fmt.Printf("✅ Cron %s paused\n", args[0])
+}
+
+// HandleCronsResumeCommand handles the 'crons:resume' CLI command
+func HandleCronsResumeCommand(app *pocketbase.PocketBase, cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		fmt.Println("❌ Usage: crons:resume <cron-id>")
+		return
+	}
+
+	if err := crons.ResumeCron(app, args[0]); err != nil {
+		log.Error("Failed to resume cron", "cron_id", args[0], "error", err)
+		fmt.Printf("❌ Error resuming cron: %v\n", err)
+		return
+	}
+
+	fmt.Printf("✅ Cron %s resumed\n", args[0])
+}
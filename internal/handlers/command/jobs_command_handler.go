@@ -0,0 +1,387 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"ims-pocketbase-baas-starter/internal/jobs"
+	"ims-pocketbase-baas-starter/pkg/jobutils"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/spf13/cobra"
+)
+
+// maxListedErrorLength keeps the last error column of jobs:list readable
+const maxListedErrorLength = 60
+
+// minDrainRetryPause is the least time jobs:work --once waits before fetching again after a
+// batch with failed jobs, so a zero retry backoff does not retry them in a busy loop
+const minDrainRetryPause = 250 * time.Millisecond
+
+// queueProcessor is the part of the job processor drainQueues needs
+type queueProcessor interface {
+	ProcessQueue(queue string) (int, []error, error)
+}
+
+// drainSleep pauses drainQueues, replaced in tests
+var drainSleep = time.Sleep
+
+// JobsListFlags registers the flags of the 'jobs:list' CLI command
+func JobsListFlags(cmd *cobra.Command) {
+	cmd.Flags().String("status", "", "Only list jobs in this status (queued, processing, completed, failed, dead, canceled)")
+	cmd.Flags().String("type", "", "Only list jobs of this type")
+	cmd.Flags().String("queue", "", "Only list jobs of this queue")
+	cmd.Flags().Int("page", 1, "Page number")
+	cmd.Flags().Int("limit", jobutils.DefaultJobListPerPage, "Jobs per page")
+}
+
+// HandleJobsListCommand handles the 'jobs:list' CLI command
+func HandleJobsListCommand(app *pocketbase.PocketBase, cmd *cobra.Command, args []string) {
+	status, _ := cmd.Flags().GetString("status")
+	jobType, _ := cmd.Flags().GetString("type")
+	queue, _ := cmd.Flags().GetString("queue")
+	page, _ := cmd.Flags().GetInt("page")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	list, err := jobutils.ListJobs(app, jobutils.JobListFilter{
+		Type:    jobType,
+		Status:  status,
+		Queue:   queue,
+		Page:    page,
+		PerPage: limit,
+	})
+	if err != nil {
+		log.Error("Failed to list jobs", "error", err)
+		fmt.Printf("❌ Error listing jobs: %v\n", err)
+		return
+	}
+
+	if len(list.Items) == 0 {
+		fmt.Println("No jobs found")
+		return
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTYPE\tQUEUE\tSTATUS\tATTEMPTS\tPROGRESS\tCREATED\tLAST ERROR")
+	for _, job := range list.Items {
+		created := ""
+		if job.CreatedAt != nil {
+			created = job.CreatedAt.Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d/%d\t%d%%\t%s\t%s\n",
+			job.JobID, job.Type, job.Queue, job.Status,
+			job.Attempts, job.MaxAttempts, job.Progress.Percent,
+			created, truncateListedError(job.LastError))
+	}
+	w.Flush()
+
+	fmt.Printf("Page %d of %d (%d jobs)\n", list.Page, max(list.TotalPages, 1), list.TotalItems)
+}
+
+// JobsRetryFlags registers the flags of the 'jobs:retry' CLI command
+func JobsRetryFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("all-failed", false, "Retry every failed and dead job")
+}
+
+// HandleJobsRetryCommand handles the 'jobs:retry' CLI command
+func HandleJobsRetryCommand(app *pocketbase.PocketBase, cmd *cobra.Command, args []string) {
+	allFailed, _ := cmd.Flags().GetBool("all-failed")
+
+	if !allFailed {
+		if len(args) < 1 {
+			fmt.Println("❌ Usage: jobs:retry <job-id> or jobs:retry --all-failed")
+			return
+		}
+
+		if _, err := jobutils.RetryJob(app, args[0]); err != nil {
+			log.Error("Failed to retry job", "job_id", args[0], "error", err)
+			fmt.Printf("❌ Error retrying job: %v\n", err)
+			return
+		}
+
+		fmt.Printf("✅ Job %s queued for retry\n", args[0])
+		return
+	}
+
+	retried, err := retryFailedJobs(app)
+	if err != nil {
+		log.Error("Failed to retry failed jobs", "retried", retried, "error", err)
+		fmt.Printf("❌ Error retrying failed jobs after %d retried: %v\n", retried, err)
+		return
+	}
+
+	fmt.Printf("✅ %d failed jobs queued for retry\n", retried)
+}
+
+// retryFailedJobs requeues every failed and dead job and returns how many were requeued
+func retryFailedJobs(app *pocketbase.PocketBase) (int, error) {
+	retried := 0
+	for _, status := range []string{jobutils.JobStatusFailed, jobutils.JobStatusDead} {
+		records, err := jobutils.FindJobsByStatus(app, status, 0, 0)
+		if err != nil {
+			return retried, err
+		}
+
+		for _, record := range records {
+			_, err := jobutils.RetryJob(app, record.Id)
+			if errors.Is(err, jobutils.ErrDuplicateJob) {
+				// another job with the same idempotency key runs in its place
+				fmt.Printf("⚠️  Skipped job %s: %v\n", record.Id, err)
+				continue
+			}
+			if err != nil {
+				return retried, err
+			}
+			retried++
+		}
+	}
+	return retried, nil
+}
+
+// JobsPurgeFlags registers the flags of the 'jobs:purge' CLI command
+func JobsPurgeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("older-than", "", "Only purge jobs last updated longer ago than this (e.g. 72h, 7d; 0 purges regardless of age)")
+	cmd.Flags().String("status", jobutils.JobStatusCompleted+","+jobutils.JobStatusDead, "Comma separated statuses to purge (completed, dead, canceled)")
+}
+
+// HandleJobsPurgeCommand handles the 'jobs:purge' CLI command
+func HandleJobsPurgeCommand(app *pocketbase.PocketBase, cmd *cobra.Command, args []string) {
+	olderThan, _ := cmd.Flags().GetString("older-than")
+	statusList, _ := cmd.Flags().GetString("status")
+
+	if olderThan == "" {
+		fmt.Println("❌ Usage: jobs:purge --older-than <age> [--status completed,dead]")
+		return
+	}
+
+	age, err := parseJobAge(olderThan)
+	if err != nil {
+		fmt.Printf("❌ Invalid --older-than value: %v\n", err)
+		return
+	}
+
+	var before time.Time
+	if age > 0 {
+		before = time.Now().Add(-age)
+	}
+
+	statuses := []string{}
+	for _, status := range strings.Split(statusList, ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			statuses = append(statuses, status)
+		}
+	}
+
+	deleted, err := jobutils.PurgeJobs(app, statuses, before)
+	if err != nil {
+		log.Error("Failed to purge jobs", "deleted", deleted, "error", err)
+		fmt.Printf("❌ Error purging jobs after %d deleted: %v\n", deleted, err)
+		return
+	}
+
+	fmt.Printf("✅ Purged %d jobs\n", deleted)
+}
+
+// parseJobAge parses a Go duration, also accepting a number of days (e.g. 7d)
+func parseJobAge(value string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("expected a number of days, got %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if age < 0 {
+		return 0, fmt.Errorf("age cannot be negative, got %q", value)
+	}
+	return age, nil
+}
+
+// JobsWorkFlags registers the flags of the 'jobs:work' CLI command
+func JobsWorkFlags(cmd *cobra.Command) {
+	cmd.Flags().String("queue", "", "Only process jobs of this configured queue")
+	cmd.Flags().Bool("once", false, "Process the jobs that are ready now, then exit")
+}
+
+// HandleJobsWorkCommand handles the 'jobs:work' CLI command, which is also what the serve
+// command runs in the worker app mode. Without --once the workers run until the process is
+// interrupted; running jobs are then drained by the app's terminate hook.
+func HandleJobsWorkCommand(app *pocketbase.PocketBase, cmd *cobra.Command, args []string) {
+	queue, _ := cmd.Flags().GetString("queue")
+	once, _ := cmd.Flags().GetBool("once")
+
+	// like serve, apply pending migrations so workers can be deployed on their own
+	if err := app.RunAllMigrations(); err != nil {
+		log.Error("Failed to apply migrations", "error", err)
+		fmt.Printf("❌ Error applying migrations: %v\n", err)
+		return
+	}
+
+	jobManager := jobs.GetJobManager()
+	processor := jobManager.GetProcessor()
+	if processor == nil {
+		log.Error("Job processor is not initialized")
+		fmt.Println("❌ Job processor is not initialized")
+		return
+	}
+
+	queues := processor.GetQueueConfigs().Names()
+	if queue != "" {
+		if _, exists := processor.GetQueueConfigs()[queue]; !exists {
+			fmt.Printf("❌ Queue '%s' is not configured (configured: %s)\n", queue, strings.Join(queues, ", "))
+			return
+		}
+		queues = []string{queue}
+	}
+
+	if once {
+		processed, failed := drainQueues(processor, queues)
+		fmt.Printf("✅ Processed %d jobs (%d failed) from %s\n", processed, failed, strings.Join(queues, ", "))
+		return
+	}
+
+	if err := jobManager.GetDispatcher().StartQueues(queues...); err != nil {
+		log.Error("Failed to start job workers", "error", err)
+		fmt.Printf("❌ Error starting job workers: %v\n", err)
+		return
+	}
+
+	fmt.Printf("👷 Processing jobs from %s, press Ctrl+C to stop\n", strings.Join(queues, ", "))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	fmt.Println("Stopping job workers...")
+}
+
+// drainQueues processes the ready jobs of the queues batch by batch until none is left and
+// returns how many jobs succeeded and failed. Failed jobs are not picked up again while they
+// wait for their retry backoff, and at least minDrainRetryPause passes before the next batch.
+func drainQueues(processor queueProcessor, queues []string) (int, int) {
+	processed, failed := 0, 0
+
+	for _, queue := range queues {
+		for {
+			fetched, results, err := processor.ProcessQueue(queue)
+			if err != nil {
+				log.Error("Failed to process queue", "queue", queue, "error", err)
+				break
+			}
+			if fetched == 0 {
+				break
+			}
+
+			batchFailed := 0
+			for _, result := range results {
+				if result != nil {
+					batchFailed++
+				} else {
+					processed++
+				}
+			}
+			failed += batchFailed
+
+			if batchFailed > 0 {
+				drainSleep(minDrainRetryPause)
+			}
+		}
+	}
+
+	return processed, failed
+}
+
+// JobsEnqueueFlags registers the flags of the 'jobs:enqueue' CLI command
+func JobsEnqueueFlags(cmd *cobra.Command) {
+	cmd.Flags().String("name", "", "Job name (defaults to the job type)")
+	cmd.Flags().String("queue", "", "Queue to place the job on (defaults to the default queue)")
+	cmd.Flags().Int("priority", 0, "Higher priority jobs are picked up first")
+	cmd.Flags().Duration("delay", 0, "Delay before the job becomes available (e.g. 10m)")
+	cmd.Flags().Int("max-attempts", 0, "Attempts before the job is moved to the dead-letter set")
+	cmd.Flags().String("options", "", "JSON object with the payload options (e.g. {\"timeout\": 60})")
+}
+
+// HandleJobsEnqueueCommand handles the 'jobs:enqueue' CLI command
+func HandleJobsEnqueueCommand(app *pocketbase.PocketBase, cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		fmt.Println("❌ Usage: jobs:enqueue <type> <json-data>")
+		return
+	}
+
+	options, _ := cmd.Flags().GetString("options")
+	payload, err := buildEnqueuePayload(args[0], args[1], options)
+	if err != nil {
+		fmt.Printf("❌ Invalid job payload: %v\n", err)
+		return
+	}
+
+	name, _ := cmd.Flags().GetString("name")
+	queue, _ := cmd.Flags().GetString("queue")
+	priority, _ := cmd.Flags().GetInt("priority")
+	delay, _ := cmd.Flags().GetDuration("delay")
+	maxAttempts, _ := cmd.Flags().GetInt("max-attempts")
+
+	handle, err := jobutils.Enqueue(app, payload,
+		jobutils.WithName(name),
+		jobutils.WithQueue(queue),
+		jobutils.WithPriority(priority),
+		jobutils.WithDelay(delay),
+		jobutils.WithMaxAttempts(maxAttempts),
+	)
+	if err != nil {
+		log.Error("Failed to queue job", "job_type", args[0], "error", err)
+		fmt.Printf("❌ Error queuing job: %v\n", err)
+		return
+	}
+
+	fmt.Printf("✅ Job %s queued\n", handle.ID)
+}
+
+// buildEnqueuePayload builds a job payload from the job type and the JSON data and options objects
+func buildEnqueuePayload(jobType, data, options string) (map[string]any, error) {
+	if strings.TrimSpace(jobType) == "" {
+		return nil, fmt.Errorf("job type is required")
+	}
+
+	payload := map[string]any{"type": jobType}
+
+	dataMap := map[string]any{}
+	if err := json.Unmarshal([]byte(data), &dataMap); err != nil {
+		return nil, fmt.Errorf("data must be a JSON object: %w", err)
+	}
+	payload["data"] = dataMap
+
+	if options != "" {
+		optionsMap := map[string]any{}
+		if err := json.Unmarshal([]byte(options), &optionsMap); err != nil {
+			return nil, fmt.Errorf("options must be a JSON object: %w", err)
+		}
+		payload["options"] = optionsMap
+	}
+
+	return payload, nil
+}
+
+// truncateListedError shortens an error message to fit the jobs:list table
+func truncateListedError(message string) string {
+	message = strings.ReplaceAll(message, "\n", " ")
+	if len(message) > maxListedErrorLength {
+		return message[:maxListedErrorLength-3] + "..."
+	}
+	return message
+}
@@ -0,0 +1,173 @@
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestParseJobAge(t *testing.T) {
+	tests := []struct {
+		value     string
+		expected  time.Duration
+		expectErr bool
+	}{
+		{value: "0", expected: 0},
+		{value: "90m", expected: 90 * time.Minute},
+		{value: "72h", expected: 72 * time.Hour},
+		{value: "7d", expected: 7 * 24 * time.Hour},
+		{value: "-1h", expectErr: true},
+		{value: "-2d", expectErr: true},
+		{value: "xd", expectErr: true},
+		{value: "soon", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			age, err := parseJobAge(tt.value)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected an error for %q", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if age != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, age)
+			}
+		})
+	}
+}
+
+func TestBuildEnqueuePayload(t *testing.T) {
+	payload, err := buildEnqueuePayload("email", `{"to": "user@example.com"}`, `{"timeout": 60}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if payload["type"] != "email" {
+		t.Errorf("expected type email, got %v", payload["type"])
+	}
+	if data, ok := payload["data"].(map[string]any); !ok || data["to"] != "user@example.com" {
+		t.Errorf("unexpected data: %v", payload["data"])
+	}
+	if options, ok := payload["options"].(map[string]any); !ok || options["timeout"] != float64(60) {
+		t.Errorf("unexpected options: %v", payload["options"])
+	}
+
+	if payload, err := buildEnqueuePayload("email", `{}`, ""); err != nil || payload["options"] != nil {
+		t.Errorf("expected a payload without options, got %v (err %v)", payload, err)
+	}
+
+	invalid := []struct {
+		name    string
+		jobType string
+		data    string
+		options string
+	}{
+		{name: "missing type", jobType: " ", data: `{}`},
+		{name: "data is not an object", jobType: "email", data: `["a"]`},
+		{name: "data is not JSON", jobType: "email", data: `to=user`},
+		{name: "options are not an object", jobType: "email", data: `{}`, options: `60`},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := buildEnqueuePayload(tt.jobType, tt.data, tt.options); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestTruncateListedError(t *testing.T) {
+	if got := truncateListedError("short\nerror"); got != "short error" {
+		t.Errorf("expected newlines to be flattened, got %q", got)
+	}
+
+	got := truncateListedError(strings.Repeat("x", 200))
+	if len(got) != maxListedErrorLength || !strings.HasSuffix(got, "...") {
+		t.Errorf("expected a truncated error of %d characters, got %d", maxListedErrorLength, len(got))
+	}
+}
+
+func TestJobsCommandFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(*cobra.Command)
+		flags    []string
+	}{
+		{name: "jobs:list", register: JobsListFlags, flags: []string{"status", "type", "queue", "page", "limit"}},
+		{name: "jobs:retry", register: JobsRetryFlags, flags: []string{"all-failed"}},
+		{name: "jobs:purge", register: JobsPurgeFlags, flags: []string{"older-than", "status"}},
+		{name: "jobs:work", register: JobsWorkFlags, flags: []string{"queue", "once"}},
+		{name: "jobs:enqueue", register: JobsEnqueueFlags, flags: []string{"name", "queue", "priority", "delay", "max-attempts", "options"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			tt.register(cmd)
+
+			for _, flag := range tt.flags {
+				if cmd.Flags().Lookup(flag) == nil {
+					t.Errorf("expected flag --%s to be registered", flag)
+				}
+			}
+		})
+	}
+}
+
+// mockQueueProcessor returns its batches in order, then reports the queue as empty
+type mockQueueProcessor struct {
+	batches [][]error
+}
+
+func (m *mockQueueProcessor) ProcessQueue(queue string) (int, []error, error) {
+	if len(m.batches) == 0 {
+		return 0, nil, nil
+	}
+	batch := m.batches[0]
+	m.batches = m.batches[1:]
+	return len(batch), batch, nil
+}
+
+func TestDrainQueues(t *testing.T) {
+	var pauses []time.Duration
+	drainSleep = func(d time.Duration) { pauses = append(pauses, d) }
+	defer func() { drainSleep = time.Sleep }()
+
+	// with a zero retry backoff the failed job is ready again right away
+	failure := errors.New("handler failed")
+	processor := &mockQueueProcessor{batches: [][]error{
+		{nil, failure},
+		{failure},
+		{nil},
+	}}
+
+	processed, failed := drainQueues(processor, []string{"default"})
+
+	if processed != 2 || failed != 2 {
+		t.Errorf("expected 2 processed and 2 failed jobs, got %d and %d", processed, failed)
+	}
+	if len(pauses) != 2 {
+		t.Fatalf("expected a pause after each batch with failures, got %d pauses", len(pauses))
+	}
+	for _, pause := range pauses {
+		if pause < minDrainRetryPause {
+			t.Errorf("expected a pause of at least %s, got %s", minDrainRetryPause, pause)
+		}
+	}
+}
+
+func TestHandleJobsRetryCommandWithoutJobId(t *testing.T) {
+	cmd := &cobra.Command{}
+	JobsRetryFlags(cmd)
+
+	// prints the usage and returns before touching the app
+	HandleJobsRetryCommand(nil, cmd, []string{})
+}
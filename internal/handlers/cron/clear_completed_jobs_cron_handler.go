@@ -0,0 +1,32 @@
+package cron
+
+import (
+	"ims-pocketbase-baas-starter/pkg/common"
+	"ims-pocketbase-baas-starter/pkg/cronutils"
+	"ims-pocketbase-baas-starter/pkg/jobutils"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	"github.com/pocketbase/pocketbase"
+)
+
+// HandleClearCompletedJobs deletes completed queue jobs older than the retention window
+func HandleClearCompletedJobs(app *pocketbase.PocketBase) {
+	ctx := cronutils.NewCronExecutionContext(app, "clear_completed_jobs")
+	ctx.LogStart("Starting completed jobs cleanup operations")
+
+	batchSize := common.GetEnvInt("JOB_CLEANUP_BATCH_SIZE", 500) // Delete up to 500 completed jobs per run
+	retention := jobutils.GetCompletedJobRetention()
+
+	deletedCount, err := jobutils.PruneCompletedJobs(app, retention, batchSize)
+	if err != nil {
+		ctx.LogError(err, "Failed to prune completed jobs")
+		return
+	}
+
+	log.Info("Completed jobs cleanup batch completed",
+		"deleted", deletedCount,
+		"retention", retention.String(),
+		"batch_size", batchSize)
+
+	ctx.LogEnd("Completed jobs cleanup operations completed successfully")
+}
@@ -6,27 +6,37 @@ import (
 
 	"ims-pocketbase-baas-starter/pkg/common"
 	"ims-pocketbase-baas-starter/pkg/cronutils"
+	"ims-pocketbase-baas-starter/pkg/jobutils"
 	log "ims-pocketbase-baas-starter/pkg/logger"
 
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
 )
 
-// HandleClearExportFiles processes cleanup of expired export files
+// HandleClearExportFiles processes cleanup of expired export files and of expired import uploads
 func HandleClearExportFiles(app *pocketbase.PocketBase) {
-	ctx := cronutils.NewCronExecutionContext(app, "clear_export_files")
+	ctx := cronutils.NewCronExecutionContext(app, "clean_exported_files")
 	ctx.LogStart("Starting export files cleanup operations")
 
-	batchSize := common.GetEnvInt("EXPORT_CLEANUP_BATCH_SIZE", 100) // Process up to 100 expired files per run
+	batchSize := common.GetEnvInt("EXPORT_CLEANUP_BATCH_SIZE", 100) // Process up to 100 expired files per run and collection
 
-	expiredRecords, err := findExpiredExportFiles(app, batchSize)
+	for _, collectionName := range []string{jobutils.ExportFilesCollectionName, jobutils.ImportFilesCollectionName} {
+		clearExpiredFiles(ctx, app, collectionName, batchSize)
+	}
+
+	ctx.LogEnd("Export files cleanup operations completed successfully")
+}
+
+// clearExpiredFiles deletes a batch of the expired file records of a collection
+func clearExpiredFiles(ctx *cronutils.CronExecutionContext, app *pocketbase.PocketBase, collectionName string, batchSize int) {
+	expiredRecords, err := findExpiredFiles(app, collectionName, batchSize)
 	if err != nil {
-		ctx.LogError(err, "Failed to find expired export files")
+		ctx.LogError(err, "Failed to find expired files of "+collectionName)
 		return
 	}
 
 	if len(expiredRecords) == 0 {
-		ctx.LogEnd("Export files cleanup completed - no files to clean")
+		log.Debug("No expired files to clean", "collection", collectionName)
 		return
 	}
 
@@ -34,8 +44,8 @@ func HandleClearExportFiles(app *pocketbase.PocketBase) {
 	errorCount := 0
 
 	for _, record := range expiredRecords {
-		if err := deleteExportFileRecord(ctx, app, record); err != nil {
-			ctx.LogError(err, fmt.Sprintf("Failed to delete export file record: %s", record.Id))
+		if err := deleteExpiredFileRecord(ctx, app, record); err != nil {
+			ctx.LogError(err, fmt.Sprintf("Failed to delete %s record: %s", collectionName, record.Id))
 			errorCount++
 			continue
 		}
@@ -43,24 +53,23 @@ func HandleClearExportFiles(app *pocketbase.PocketBase) {
 	}
 
 	// Log final results
-	log.Info("Export files cleanup batch completed",
+	log.Info("Expired files cleanup batch completed",
+		"collection", collectionName,
 		"total_expired", len(expiredRecords),
 		"deleted", deletedCount,
 		"errors", errorCount,
 		"batch_size", batchSize)
 
 	if errorCount > 0 {
-		ctx.LogError(fmt.Errorf("cleanup completed with %d errors out of %d records", errorCount, len(expiredRecords)), "Cleanup had errors")
+		ctx.LogError(fmt.Errorf("cleanup of %s completed with %d errors out of %d records", collectionName, errorCount, len(expiredRecords)), "Cleanup had errors")
 	}
-
-	ctx.LogEnd("Export files cleanup operations completed successfully")
 }
 
-// findExpiredExportFiles finds all export file records that have expired
-func findExpiredExportFiles(app *pocketbase.PocketBase, batchSize int) ([]*core.Record, error) {
-	collection, err := app.FindCollectionByNameOrId("export_files")
+// findExpiredFiles finds the file records of a collection (export_files or import_files) that have expired
+func findExpiredFiles(app *pocketbase.PocketBase, collectionName string, batchSize int) ([]*core.Record, error) {
+	collection, err := app.FindCollectionByNameOrId(collectionName)
 	if err != nil {
-		return nil, fmt.Errorf("export_files collection not found: %w", err)
+		return nil, fmt.Errorf("%s collection not found: %w", collectionName, err)
 	}
 
 	now := time.Now()
@@ -74,27 +83,29 @@ func findExpiredExportFiles(app *pocketbase.PocketBase, batchSize int) ([]*core.
 		0,         // no offset
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query expired export files: %w", err)
+		return nil, fmt.Errorf("failed to query expired %s: %w", collectionName, err)
 	}
 
 	return records, nil
 }
 
-// deleteExportFileRecord deletes an export file record and its associated file
-func deleteExportFileRecord(ctx *cronutils.CronExecutionContext, app *pocketbase.PocketBase, record *core.Record) error {
+// deleteExpiredFileRecord deletes an expired file record and its associated file
+func deleteExpiredFileRecord(ctx *cronutils.CronExecutionContext, app *pocketbase.PocketBase, record *core.Record) error {
 	recordId := record.Id
+	collectionName := record.Collection().Name
 	jobId := record.GetString("job_id")
 	filename := record.GetString("file")
 	expiresAt := record.GetDateTime("expires_at").Time()
 
-	ctx.LogDebug(fmt.Sprintf("Deleting expired export file: record_id=%s, job_id=%s, filename=%s, expired_at=%s",
-		recordId, jobId, filename, expiresAt.Format(time.RFC3339)), "Processing expired file")
+	ctx.LogDebug(fmt.Sprintf("Deleting expired file: collection=%s, record_id=%s, job_id=%s, filename=%s, expired_at=%s",
+		collectionName, recordId, jobId, filename, expiresAt.Format(time.RFC3339)), "Processing expired file")
 
 	if err := app.Delete(record); err != nil {
-		return fmt.Errorf("failed to delete export file record %s: %w", recordId, err)
+		return fmt.Errorf("failed to delete %s record %s: %w", collectionName, recordId, err)
 	}
 
-	log.Info("Deleted expired export file",
+	log.Info("Deleted expired file",
+		"collection", collectionName,
 		"record_id", recordId,
 		"job_id", jobId,
 		"filename", filename,
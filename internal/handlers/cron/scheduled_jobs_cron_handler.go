@@ -0,0 +1,31 @@
+package cron
+
+import (
+	"time"
+
+	"ims-pocketbase-baas-starter/internal/jobs"
+	"ims-pocketbase-baas-starter/pkg/cronutils"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	"github.com/pocketbase/pocketbase"
+)
+
+// HandleScheduledJobs queues the jobs of the scheduled_jobs collection that are due this minute
+func HandleScheduledJobs(app *pocketbase.PocketBase) {
+	ctx := cronutils.NewCronExecutionContext(app, "scheduled_jobs")
+
+	scheduler := jobs.GetJobManager().GetScheduler()
+	if scheduler == nil {
+		ctx.LogError(nil, "Job scheduler not initialized")
+		return
+	}
+
+	queued, err := scheduler.RunDue(time.Now())
+	if err != nil {
+		ctx.LogError(err, "Failed to queue some scheduled jobs")
+	}
+
+	if queued > 0 {
+		log.Info("Scheduled jobs queued", "queued", queued)
+	}
+}
@@ -1,24 +1,38 @@
 package cron
 
 import (
+	"errors"
+	"sync"
 	"time"
 
 	"ims-pocketbase-baas-starter/internal/jobs"
-	"ims-pocketbase-baas-starter/pkg/common"
 	"ims-pocketbase-baas-starter/pkg/cronutils"
+	"ims-pocketbase-baas-starter/pkg/jobutils"
 	log "ims-pocketbase-baas-starter/pkg/logger"
 	"ims-pocketbase-baas-starter/pkg/metrics"
 
-	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
 )
 
-// HandleSystemQueue processes jobs from the queue table using the job processor
+// HandleSystemQueue processes jobs from the queue table using the job processor.
+// Every named queue whose poll interval has elapsed is processed on its own worker pool,
+// in parallel, so a backlog on one queue does not delay the others.
+// It is a fallback for when the continuous job dispatcher is disabled (JOB_DISPATCHER_ENABLED=false);
+// while the dispatcher runs, the cron wakes it instead. Pausing the cron pauses the dispatcher too,
+// and triggering the paused cron processes one batch of every due queue.
 func HandleSystemQueue(app *pocketbase.PocketBase) {
+	jobManager := jobs.GetJobManager()
+
+	// scheduled runs skip the paused cron, so a paused run was triggered by hand
+	if dispatcher := jobManager.GetDispatcher(); dispatcher != nil && dispatcher.IsRunning() && !cronutils.IsCronPaused(app, jobutils.QueuePauseCronID) {
+		log.Debug("Job dispatcher is running, waking it instead of processing the queues")
+		dispatcher.NotifyAll()
+		return
+	}
+
 	ctx := cronutils.NewCronExecutionContext(app, "system_queue")
 	ctx.LogStart("Starting system queue process operations")
 
-	jobManager := jobs.GetJobManager()
 	processor := jobManager.GetProcessor()
 
 	if processor == nil {
@@ -26,55 +40,59 @@ func HandleSystemQueue(app *pocketbase.PocketBase) {
 		return
 	}
 
-	maxWorkers := common.GetEnvInt("JOB_MAX_WORKERS", 5)                 // Default 5 concurrent workers
-	batchSize := common.GetEnvInt("JOB_BATCH_SIZE", 50)                  // Process up to 50 jobs per run
-	reservationTimeout := common.GetEnvInt("JOB_RESERVATION_TIMEOUT", 5) // Default 5 minutes reservation timeout
-
-	// Fetch pending jobs: either not reserved or reservation has expired
-	expiredTime := time.Now().Add(-time.Duration(reservationTimeout) * time.Minute)
-
-	// Format for PocketBase: RFC3339 with 'T' replaced by space (e.g., "2025-11-04 19:39:00Z")
-	pbExpiredTime := expiredTime.Format("2006-01-02 15:04:05Z")
+	var wg sync.WaitGroup
+	for _, queue := range processor.DueQueues(time.Now()) {
+		wg.Add(1)
+		go func(queue string) {
+			defer wg.Done()
+			processQueue(ctx, processor, queue)
+		}(queue)
+	}
+	wg.Wait()
 
-	queues, err := app.FindRecordsByFilter(
-		"queues",
-		"reserved_at = '' || reserved_at < {:expired}",
-		"-created", // FIFO: oldest first
-		batchSize,
-		0,
-		dbx.Params{"expired": pbExpiredTime},
-	)
+	ctx.LogEnd("System queue process operations completed successfully")
+}
 
+// processQueue processes one batch of ready jobs of a named queue
+func processQueue(ctx *cronutils.CronExecutionContext, processor *jobutils.JobProcessor, queue string) {
+	// Fetch pending jobs: queued, failed and awaiting retry, or with an expired reservation
+	total, results, err := processor.ProcessQueue(queue)
 	if err != nil {
-		ctx.LogError(err, "Error fetching queues data")
+		ctx.LogError(err, "Error processing queue "+queue)
 		return
 	}
 
 	// Record queue size metrics
 	metricsProvider := metrics.GetInstance()
 	if metricsProvider != nil {
-		metrics.RecordQueueSize(metricsProvider, "system", len(queues))
+		metrics.RecordQueueSize(metricsProvider, queue, total)
 	}
 
-	if len(queues) > 0 {
-		errors := processor.ProcessJobsConcurrently(queues, maxWorkers)
-		successCount := 0
-		failureCount := 0
-		for _, err := range errors {
-			if err == nil {
-				successCount++
-			} else {
-				failureCount++
-				ctx.LogError(err, "Job processing error")
-			}
-		}
+	if total == 0 {
+		return
+	}
 
-		log.Info("Job processing batch completed",
-			"total_jobs", len(queues),
-			"successful", successCount,
-			"failed", failureCount,
-			"workers", maxWorkers)
+	successCount := 0
+	failureCount := 0
+	skippedCount := 0
+	for _, err := range results {
+		if err == nil {
+			successCount++
+		} else if errors.Is(err, jobutils.ErrJobNotClaimed) {
+			// Another worker or server instance claimed the job first
+			skippedCount++
+		} else {
+			// the job records its own failure, it does not fail the cron run
+			failureCount++
+			log.Error("Job processing error", "queue", queue, "error", err)
+		}
 	}
 
-	ctx.LogEnd("System queue process operations completed successfully")
+	log.Info("Job processing batch completed",
+		"queue", queue,
+		"total_jobs", total,
+		"successful", successCount,
+		"failed", failureCount,
+		"skipped", skippedCount,
+		"workers", processor.GetQueueConfigs()[queue].Workers)
 }
@@ -0,0 +1,132 @@
+package dataimport
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ims-pocketbase-baas-starter/pkg/jobutils"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/inflector"
+	"github.com/pocketbase/pocketbase/tools/search"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// ErrImportForbidden is returned when the requester of an import is not allowed to create or update
+// the records of the collection
+var ErrImportForbidden = errors.New("the import requester is not allowed to import into this collection")
+
+// findRequester returns the request info the import runs with: the auth record of the job owner,
+// or nil for jobs without an owner, which import without rules
+func findRequester(app core.App, ownerId string) (*core.RequestInfo, error) {
+	if ownerId == "" {
+		return nil, nil
+	}
+
+	for _, collection := range []string{"users", core.CollectionNameSuperusers} {
+		if auth, err := app.FindRecordById(collection, ownerId); err == nil {
+			return &core.RequestInfo{Auth: auth, Method: "POST", Context: core.RequestInfoContextDefault}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: owner %s not found", ErrImportForbidden, ownerId)
+}
+
+// bypassesRules reports whether an import runs without the API rules (superusers and jobs without an owner)
+func bypassesRules(requestInfo *core.RequestInfo) bool {
+	return requestInfo == nil || requestInfo.HasSuperuserAuth()
+}
+
+// canUseImportFile reports whether the requester of an import can import an uploaded file: its owner,
+// or for the uploads of superusers (which have no owner) requesters that bypass the rules
+func canUseImportFile(upload *core.Record, requestInfo *core.RequestInfo) bool {
+	if requestInfo == nil {
+		return true
+	}
+	ownerId := upload.GetString("user_id")
+	if ownerId == "" {
+		return bypassesRules(requestInfo)
+	}
+	return requestInfo.Auth != nil && requestInfo.Auth.Id == ownerId
+}
+
+// CheckImportAccess fails with ErrImportForbidden when the rules of the collection keep the requester
+// from every row of an import: auth collections and collections whose create rule (and update rule
+// for upserts) only superusers pass. The rules are then checked for each row.
+func CheckImportAccess(collection *core.Collection, requestInfo *core.RequestInfo, mode string) error {
+	if bypassesRules(requestInfo) {
+		return nil
+	}
+
+	if collection.IsAuth() {
+		return fmt.Errorf("%w: only superusers can import into auth collections", ErrImportForbidden)
+	}
+	if collection.CreateRule == nil {
+		return ErrImportForbidden
+	}
+	if mode == jobutils.DataImportModeUpsert && collection.UpdateRule == nil {
+		return ErrImportForbidden
+	}
+
+	return nil
+}
+
+// canCreateRecord checks a new record and the request body against the create rule of its collection,
+// the way the records API does: the record is selected from a temporary table holding its values.
+func canCreateRecord(app core.App, record *core.Record, requestInfo *core.RequestInfo) (bool, error) {
+	collection := record.Collection()
+	if bypassesRules(requestInfo) || (collection.CreateRule != nil && *collection.CreateRule == "") {
+		return true, nil
+	}
+	if collection.CreateRule == nil {
+		return false, nil
+	}
+
+	dummyRecord := record.Clone()
+	dummyRandomPart := "__pb_create__" + security.PseudorandomString(6)
+	if dummyRecord.Id == "" {
+		dummyRecord.Id = "__temp_id__" + dummyRandomPart
+	}
+
+	dummyExport, err := dummyRecord.DBExport(app)
+	if err != nil {
+		return false, fmt.Errorf("failed to export the record values: %w", err)
+	}
+
+	dummyParams := make(dbx.Params, len(dummyExport))
+	selects := make([]string, 0, len(dummyExport))
+	for k, v := range dummyExport {
+		k = inflector.Columnify(k)
+		param := "__pb_create__" + k
+		dummyParams[param] = v
+		selects = append(selects, "{:"+param+"} AS [["+k+"]]")
+	}
+
+	// shallow clone, so the rule resolves the fields of the collection against the temporary table
+	dummyCollection := *collection
+	dummyCollection.Id += dummyRandomPart
+	dummyCollection.Name += inflector.Columnify(dummyRandomPart)
+
+	withFrom := fmt.Sprintf("WITH {{%s}} as (SELECT %s)", dummyCollection.Name, strings.Join(selects, ","))
+	query := app.DB().Select("(1)").PreFragment(withFrom).From(dummyCollection.Name).AndBind(dummyParams)
+
+	resolver := core.NewRecordFieldResolver(app, &dummyCollection, requestInfo, true)
+	expr, err := search.FilterData(*dummyCollection.CreateRule).BuildExpr(resolver)
+	if err != nil {
+		return false, fmt.Errorf("invalid create rule of collection %s: %w", collection.Name, err)
+	}
+	query.AndWhere(expr)
+
+	if err := resolver.UpdateQuery(query); err != nil {
+		return false, err
+	}
+
+	var exists int
+	if err := query.Limit(1).Row(&exists); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+	return exists > 0, nil
+}
@@ -0,0 +1,917 @@
+package dataimport
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/common"
+	"ims-pocketbase-baas-starter/pkg/jobutils"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/dbutils"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// DefaultImportBatchSize is the number of rows an import saves per transaction when
+// IMPORT_BATCH_SIZE is not set
+const DefaultImportBatchSize = 500
+
+// errDryRun rolls back the transaction of a batch of a dry run
+var errDryRun = errors.New("dry run")
+
+// importColumn is a column of an import file and the collection field its values are saved to
+type importColumn struct {
+	column string
+	field  core.Field
+}
+
+// importRow is a row of an import file
+type importRow struct {
+	number int // from 1 for the first row after the header
+	values map[string]any
+}
+
+// importStats counts the rows of an import
+type importStats struct {
+	rows    int
+	created int
+	updated int
+	failed  int
+}
+
+func (s *importStats) add(other importStats) {
+	s.rows += other.rows
+	s.created += other.created
+	s.updated += other.updated
+	s.failed += other.failed
+}
+
+// collectionImporter saves the rows of an import file to a collection
+type collectionImporter struct {
+	app         core.App
+	collection  *core.Collection
+	columns     []importColumn
+	mode        string
+	keyField    string
+	dryRun      bool
+	requestInfo *core.RequestInfo
+	report      *errorReport
+}
+
+// HandleCollectionImport imports the file of the import_files record in payload.Data.Source into the
+// collection in payload.Data.Target. The columns of the file are saved to the fields named in
+// payload.Data.Mapping (column -> field), or without a mapping to the fields they are named after
+// (case insensitive). In upsert mode (payload.Data.Mode) rows update the record whose
+// payload.Data.KeyField (id by default) matches, and create a record otherwise.
+//
+// Each row is validated the way the records API validates a record, and the rows are saved in
+// transactions of IMPORT_BATCH_SIZE rows. Rows that fail are left out and listed in a CSV error report,
+// which is downloaded like an export file. With payload.Options.DryRun every batch is rolled back, so
+// the report lists the errors of the file without saving anything.
+//
+// The import runs with the access of the job owner: the create rule (and the update rule of upserts) of
+// the collection is checked for each row, and only superusers import into auth collections. Jobs without
+// an owner import without rules. The uploaded file is deleted once the import completes.
+func HandleCollectionImport(ctx context.Context, app *pocketbase.PocketBase, job *jobutils.JobData, payload *jobutils.DataProcessingJobPayload) error {
+	jobId := job.ID
+
+	collection, upload, err := resolveImport(app, payload)
+	if err != nil {
+		return jobutils.NewPermanentError(err)
+	}
+
+	requestInfo, err := findRequester(app, job.OwnerID)
+	if err != nil {
+		return jobutils.NewPermanentError(err)
+	}
+
+	if !canUseImportFile(upload, requestInfo) {
+		log.Warn("Import file does not belong to the job owner", "job_id", jobId, "import_file", upload.Id, "owner_id", job.OwnerID)
+		return jobutils.NewPermanentError(fmt.Errorf("import file %s not found", upload.Id))
+	}
+
+	format, err := GetFormat(upload.GetString("format"))
+	if err != nil {
+		return jobutils.NewPermanentError(err)
+	}
+
+	mode := importMode(payload.Data.Mode)
+	if err := CheckImportAccess(collection, requestInfo, mode); err != nil {
+		log.Warn("Import requester cannot import into the collection", "job_id", jobId, "collection", collection.Name, "owner_id", job.OwnerID)
+		return jobutils.NewPermanentError(err)
+	}
+
+	reportProgress(jobId, 0, "Reading import file")
+
+	tempDir, err := os.MkdirTemp("", "import-"+jobId+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create import directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	filePath := filepath.Join(tempDir, "import."+format.Extension)
+	if err := downloadImportFile(app, upload, filePath); err != nil {
+		log.Error("Failed to read import file", "job_id", jobId, "import_file", upload.Id, "error", err)
+		return err
+	}
+
+	total, fileColumns, err := scanImportFile(format, filePath)
+	if err != nil {
+		log.Warn("Invalid import file", "job_id", jobId, "import_file", upload.Id, "error", err)
+		return jobutils.NewPermanentError(err)
+	}
+	if total == 0 {
+		return jobutils.NewPermanentError(errors.New("the import file has no rows"))
+	}
+
+	columns, err := resolveImportColumns(collection, fileColumns, payload.Data.Mapping, bypassesRules(requestInfo))
+	if err != nil {
+		return jobutils.NewPermanentError(err)
+	}
+
+	keyField := importKeyField(payload.Data.KeyField)
+	if mode == jobutils.DataImportModeUpsert && !slices.ContainsFunc(columns, func(c importColumn) bool { return c.field.GetName() == keyField }) {
+		return jobutils.NewPermanentError(fmt.Errorf("no column of the file is mapped to the key field %q", keyField))
+	}
+
+	log.Info("Importing file", "job_id", jobId, "collection", collection.Name, "format", format.Name, "rows", total, "columns", len(columns), "mode", mode, "dry_run", payload.Options.DryRun)
+
+	reportPath := filepath.Join(tempDir, reportFilename(collection.Name, time.Now()))
+	importer := &collectionImporter{
+		app:         app,
+		collection:  collection,
+		columns:     columns,
+		mode:        mode,
+		keyField:    keyField,
+		dryRun:      payload.Options.DryRun,
+		requestInfo: requestInfo,
+		report:      newErrorReport(reportPath),
+	}
+	defer importer.report.close()
+
+	stats, err := importer.importFile(ctx, jobId, format, filePath, total)
+	if err != nil {
+		log.Error("Import failed", "job_id", jobId, "collection", collection.Name, "saved_rows", stats.rows, "error", err)
+		return importer.failure(err, stats)
+	}
+
+	result := &jobutils.DataImportResult{
+		BaseJobResultData: jobutils.BaseJobResultData{
+			Message:   importMessage(collection.Name, importer.dryRun, stats),
+			Timestamp: time.Now(),
+		},
+		Collection: collection.Name,
+		DryRun:     importer.dryRun,
+		TotalRows:  stats.rows,
+		Created:    stats.created,
+		Updated:    stats.updated,
+		Failed:     stats.failed,
+	}
+
+	if !importer.report.empty() {
+		if err := importer.report.close(); err != nil {
+			return importer.failure(err, stats)
+		}
+
+		reportProgress(jobId, 95, "Saving error report")
+
+		reportRecord, err := jobutils.SaveExportFileFromPath(app, jobId, job.OwnerID, reportPath, "text/csv", stats.failed, time.Time{})
+		if err != nil {
+			log.Error("Failed to save import error report", "job_id", jobId, "error", err)
+			return importer.failure(fmt.Errorf("failed to save import error report: %w", err), stats)
+		}
+
+		result.ReportRecordId = reportRecord.Id
+		result.ReportFileName = reportRecord.GetString("file")
+	}
+
+	if err := jobutils.SetJobResult(jobId, result); err != nil {
+		log.Debug("Failed to store import job result", "job_id", jobId, "error", err)
+	}
+
+	if err := app.Delete(upload); err != nil {
+		log.Warn("Failed to delete import file", "job_id", jobId, "import_file", upload.Id, "error", err)
+	}
+
+	log.Info("Import completed", "job_id", jobId, "collection", collection.Name, "dry_run", importer.dryRun,
+		"created", stats.created, "updated", stats.updated, "failed", stats.failed)
+
+	return nil
+}
+
+// importFile reads the rows of the import file and saves them one batch at a time.
+// It stops between batches once ctx is done; the returned stats count the rows of the saved batches.
+func (i *collectionImporter) importFile(ctx context.Context, jobId string, format Format, filePath string, total int) (importStats, error) {
+	var stats importStats
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return stats, fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return stats, fmt.Errorf("failed to open import file: %w", err)
+	}
+
+	reader, err := format.NewReader(file, info.Size())
+	if err != nil {
+		return stats, jobutils.NewPermanentError(err)
+	}
+
+	batchSize := importBatchSize()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			log.Warn("Import interrupted", "job_id", jobId, "imported", stats.rows, "error", err)
+			return stats, fmt.Errorf("import operation interrupted: %w", err)
+		}
+
+		rows, readErr := readBatch(reader, batchSize, stats.rows)
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return stats, jobutils.NewPermanentError(readErr)
+		}
+
+		if len(rows) > 0 {
+			batchStats, err := i.importBatch(rows)
+			if err != nil {
+				return stats, err
+			}
+			stats.add(batchStats)
+
+			// rows of a file changed since the first read can make the count grow past it
+			total = max(total, stats.rows)
+			reportProgress(jobId, 5+90*stats.rows/total, fmt.Sprintf("Imported %d of %d rows", stats.rows, total))
+		}
+
+		if readErr != nil {
+			return stats, nil
+		}
+	}
+}
+
+// failure returns the error of an import that stopped after saving the rows counted by stats.
+// Inserts that saved rows cannot be retried, as a retry would import the saved rows again.
+func (i *collectionImporter) failure(err error, stats importStats) error {
+	if i.dryRun || i.mode != jobutils.DataImportModeInsert || stats.rows == 0 {
+		return err
+	}
+	return jobutils.NewPermanentError(fmt.Errorf("%w (the rows up to row %d were saved, import the rows after it only)", err, stats.rows))
+}
+
+// readBatch reads up to size rows, numbered after the offset rows read before.
+// It returns io.EOF with the last rows of the file.
+func readBatch(reader Reader, size, offset int) ([]importRow, error) {
+	rows := make([]importRow, 0, size)
+	for len(rows) < size {
+		values, err := reader.Next()
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, importRow{number: offset + len(rows) + 1, values: values})
+	}
+	return rows, nil
+}
+
+// importBatch saves a batch of rows in a transaction and adds the errors of its rows to the report.
+// Rows that fail do not stop the batch; other errors roll it back.
+func (i *collectionImporter) importBatch(rows []importRow) (importStats, error) {
+	var stats importStats
+	var rowErrors []rowError
+
+	err := i.app.RunInTransaction(func(txApp core.App) error {
+		stats = importStats{rows: len(rows)}
+		rowErrors = rowErrors[:0]
+
+		for _, row := range rows {
+			created, errs, err := i.importRow(txApp, row)
+			if err != nil {
+				return fmt.Errorf("failed to import row %d: %w", row.number, err)
+			}
+
+			switch {
+			case len(errs) > 0:
+				stats.failed++
+				rowErrors = append(rowErrors, errs...)
+			case created:
+				stats.created++
+			default:
+				stats.updated++
+			}
+		}
+
+		if i.dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return importStats{}, err
+	}
+
+	if err := i.report.add(rowErrors); err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// importRow creates or updates the record of a row. It returns the errors of the row when the row is
+// invalid or the rules of the collection do not allow it, and an error only when the batch must stop.
+func (i *collectionImporter) importRow(txApp core.App, row importRow) (bool, []rowError, error) {
+	data, errs := i.rowData(row)
+	if len(errs) > 0 {
+		return false, errs, nil
+	}
+
+	record, err := i.findExisting(txApp, data)
+	if err != nil {
+		return false, nil, err
+	}
+
+	created := record == nil
+	if created {
+		record = core.NewRecord(i.collection)
+	}
+
+	var requestInfo *core.RequestInfo
+	if !bypassesRules(i.requestInfo) {
+		info := *i.requestInfo
+		info.Body = data
+		requestInfo = &info
+	}
+
+	if !created && requestInfo != nil {
+		allowed, err := txApp.CanAccessRecord(record, requestInfo, i.collection.UpdateRule)
+		if err != nil {
+			return false, nil, err
+		}
+		if !allowed {
+			return false, []rowError{{row: row.number, message: "the update rule of the collection does not allow this row"}}, nil
+		}
+	}
+
+	for name, value := range data {
+		// the ID of a record is the key of an upsert and is generated for rows without one
+		if name == core.FieldNameId && (!created || value == "" || value == nil) {
+			continue
+		}
+		record.Set(name, value)
+	}
+
+	if created && requestInfo != nil {
+		allowed, err := canCreateRecord(txApp, record, requestInfo)
+		if err != nil {
+			return false, nil, err
+		}
+		if !allowed {
+			return false, []rowError{{row: row.number, message: "the create rule of the collection does not allow this row"}}, nil
+		}
+	}
+
+	if err := txApp.Save(record); err != nil {
+		return false, i.saveErrors(row.number, err), nil
+	}
+
+	return created, nil, nil
+}
+
+// rowData converts the values of the mapped columns of a row to field values (field name -> value)
+func (i *collectionImporter) rowData(row importRow) (map[string]any, []rowError) {
+	data := make(map[string]any, len(i.columns))
+	var errs []rowError
+
+	for _, column := range i.columns {
+		raw, exists := row.values[column.column]
+		if !exists {
+			// JSON rows can leave out keys; the field is then left unchanged
+			continue
+		}
+
+		value, err := importValue(column.field, raw)
+		if err != nil {
+			errs = append(errs, rowError{row: row.number, column: column.column, field: column.field.GetName(), message: err.Error()})
+			continue
+		}
+		data[column.field.GetName()] = value
+	}
+
+	return data, errs
+}
+
+// findExisting returns the record an upsert row updates, or nil when the row creates a record
+func (i *collectionImporter) findExisting(txApp core.App, data map[string]any) (*core.Record, error) {
+	if i.mode != jobutils.DataImportModeUpsert {
+		return nil, nil
+	}
+
+	key, _ := data[i.keyField].(string)
+	if key == "" {
+		if number, ok := data[i.keyField].(float64); ok {
+			key = strconv.FormatFloat(number, 'f', -1, 64)
+		}
+	}
+	if key == "" {
+		return nil, nil
+	}
+
+	var record *core.Record
+	var err error
+	if i.keyField == core.FieldNameId {
+		record, err = txApp.FindRecordById(i.collection, key)
+	} else {
+		record, err = txApp.FindFirstRecordByData(i.collection, i.keyField, data[i.keyField])
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the %s record with %s %q: %w", i.collection.Name, i.keyField, key, err)
+	}
+
+	return record, nil
+}
+
+// saveErrors returns the row errors of a failed save: one per invalid field, or one for the row
+func (i *collectionImporter) saveErrors(rowNumber int, err error) []rowError {
+	var fieldErrors validation.Errors
+	if !errors.As(err, &fieldErrors) {
+		return []rowError{{row: rowNumber, message: err.Error()}}
+	}
+
+	names := make([]string, 0, len(fieldErrors))
+	for name := range fieldErrors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	errs := make([]rowError, 0, len(names))
+	for _, name := range names {
+		errs = append(errs, rowError{
+			row:     rowNumber,
+			column:  i.columnOf(name),
+			field:   name,
+			message: fieldErrors[name].Error(),
+		})
+	}
+	return errs
+}
+
+// columnOf returns the column of the file mapped to a field (empty when the field is not mapped)
+func (i *collectionImporter) columnOf(fieldName string) string {
+	for _, column := range i.columns {
+		if column.field.GetName() == fieldName {
+			return column.column
+		}
+	}
+	return ""
+}
+
+// downloadImportFile copies the file of an import_files record from the storage (local or S3) to filePath
+func downloadImportFile(app core.App, upload *core.Record, filePath string) error {
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return fmt.Errorf("failed to open the file storage: %w", err)
+	}
+	defer fsys.Close()
+
+	reader, err := fsys.GetReader(upload.BaseFilesPath() + "/" + upload.GetString("file"))
+	if err != nil {
+		return fmt.Errorf("failed to read import file %s: %w", upload.Id, err)
+	}
+	defer reader.Close()
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create import file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, reader); err != nil {
+		return fmt.Errorf("failed to read import file %s: %w", upload.Id, err)
+	}
+
+	return file.Close()
+}
+
+// scanImportFile reads a whole import file once, so invalid files fail before any row is saved.
+// It returns the number of rows and the columns of the file, sorted.
+func scanImportFile(format Format, filePath string) (int, []string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to open import file: %w", err)
+	}
+
+	reader, err := format.NewReader(file, info.Size())
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rows := 0
+	columnSet := map[string]struct{}{}
+	for {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+
+		rows++
+		for column := range row {
+			columnSet[column] = struct{}{}
+		}
+	}
+
+	columns := make([]string, 0, len(columnSet))
+	for column := range columnSet {
+		columns = append(columns, column)
+	}
+	slices.Sort(columns)
+
+	return rows, columns, nil
+}
+
+// importBatchSize returns how many rows an import saves per transaction
+func importBatchSize() int {
+	size := common.GetEnvInt("IMPORT_BATCH_SIZE", DefaultImportBatchSize)
+	if size <= 0 {
+		return DefaultImportBatchSize
+	}
+	return size
+}
+
+// importMode returns the mode of an import (insert when empty)
+func importMode(mode string) string {
+	if mode == "" {
+		return jobutils.DataImportModeInsert
+	}
+	return mode
+}
+
+// importKeyField returns the field matching the existing records of an upsert (id when empty)
+func importKeyField(keyField string) string {
+	if keyField == "" {
+		return core.FieldNameId
+	}
+	return keyField
+}
+
+// importMessage summarizes the rows of an import
+func importMessage(collection string, dryRun bool, stats importStats) string {
+	if dryRun {
+		return fmt.Sprintf("Dry run of the import into %s completed: %d rows would be created, %d updated and %d failed",
+			collection, stats.created, stats.updated, stats.failed)
+	}
+	return fmt.Sprintf("Import into %s completed: %d rows created, %d updated and %d failed",
+		collection, stats.created, stats.updated, stats.failed)
+}
+
+// reportProgress stores the progress of the import job; failures are logged and never fail the import
+func reportProgress(jobId string, percent int, message string) {
+	if err := jobutils.ReportProgress(jobId, percent, message); err != nil {
+		log.Debug("Failed to report import progress", "job_id", jobId, "progress", percent, "error", err)
+	}
+}
+
+// ValidateImportPayload checks that an import payload can run before it is queued: the collection
+// accepts records, the import file was uploaded for it, and the mode, key field and mapping are valid.
+// The columns of the mapping are checked against the file when the import runs.
+// It returns validation.Errors keyed by the JSON name of the invalid payload values.
+func ValidateImportPayload(app core.App, payload *jobutils.DataProcessingJobPayload) error {
+	collection, err := findImportCollection(app, payload.Data.Target)
+	if err != nil {
+		return validation.Errors{"target": err}
+	}
+
+	errs := validation.Errors{}
+
+	if _, err := findImportFile(app, payload.Data.Source, collection); err != nil {
+		errs["source"] = err
+	}
+
+	mode := importMode(payload.Data.Mode)
+	if mode != jobutils.DataImportModeInsert && mode != jobutils.DataImportModeUpsert {
+		errs["mode"] = fmt.Errorf("invalid import mode %q, expected %s or %s", payload.Data.Mode, jobutils.DataImportModeInsert, jobutils.DataImportModeUpsert)
+	} else if mode == jobutils.DataImportModeUpsert {
+		if err := validateKeyField(collection, importKeyField(payload.Data.KeyField)); err != nil {
+			errs["key_field"] = err
+		}
+	}
+
+	for column, fieldName := range payload.Data.Mapping {
+		if strings.TrimSpace(column) == "" {
+			errs["mapping"] = errors.New("the mapping has an empty column name")
+			break
+		}
+		if _, err := findImportField(collection, fieldName, true); err != nil {
+			errs["mapping"] = err
+			break
+		}
+	}
+
+	return errs.Filter()
+}
+
+// resolveImport finds the collection and the import_files record of an import payload
+func resolveImport(app core.App, payload *jobutils.DataProcessingJobPayload) (*core.Collection, *core.Record, error) {
+	if err := ValidateImportPayload(app, payload); err != nil {
+		return nil, nil, err
+	}
+
+	collection, err := findImportCollection(app, payload.Data.Target)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	upload, err := findImportFile(app, payload.Data.Source, collection)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return collection, upload, nil
+}
+
+// findImportCollection returns the collection an import saves records to
+func findImportCollection(app core.App, name string) (*core.Collection, error) {
+	collection, err := app.FindCachedCollectionByNameOrId(name)
+	if err != nil {
+		return nil, fmt.Errorf("collection %q not found", name)
+	}
+	if collection.IsView() {
+		return nil, fmt.Errorf("collection %s is a view, records cannot be imported into it", collection.Name)
+	}
+	return collection, nil
+}
+
+// findImportFile returns the import_files record of an import into collection
+func findImportFile(app core.App, id string, collection *core.Collection) (*core.Record, error) {
+	upload, err := app.FindRecordById(jobutils.ImportFilesCollectionName, id)
+	if err != nil {
+		return nil, fmt.Errorf("import file %q not found", id)
+	}
+	if upload.GetString("collection") != collection.Name {
+		return nil, fmt.Errorf("import file %q was uploaded for collection %s", id, upload.GetString("collection"))
+	}
+	return upload, nil
+}
+
+// validateKeyField checks that a field can match the existing records of an upsert: the record ID or
+// a field with a unique index
+func validateKeyField(collection *core.Collection, keyField string) error {
+	if collection.Fields.GetByName(keyField) == nil {
+		return fmt.Errorf("unknown field %q of collection %s", keyField, collection.Name)
+	}
+	if keyField != core.FieldNameId && !dbutils.HasSingleColumnUniqueIndex(keyField, collection.Indexes) {
+		return fmt.Errorf("field %q of collection %s has no unique index, so it cannot match the existing records", keyField, collection.Name)
+	}
+	return nil
+}
+
+// resolveImportColumns resolves the columns of an import file to the fields of a collection, in the
+// order of the collection. With a mapping (column -> field) only the mapped columns are imported;
+// without one every column named after an importable field (case insensitive) is imported.
+func resolveImportColumns(collection *core.Collection, fileColumns []string, mapping map[string]string, allowHidden bool) ([]importColumn, error) {
+	fieldColumns := make(map[string]string) // field name -> column
+
+	if len(mapping) > 0 {
+		for column, fieldName := range mapping {
+			if !slices.Contains(fileColumns, column) {
+				return nil, fmt.Errorf("column %q not found in the import file", column)
+			}
+
+			field, err := findImportField(collection, fieldName, allowHidden)
+			if err != nil {
+				return nil, err
+			}
+			if other, exists := fieldColumns[field.GetName()]; exists {
+				return nil, fmt.Errorf("columns %q and %q are both mapped to field %q", min(other, column), max(other, column), field.GetName())
+			}
+			fieldColumns[field.GetName()] = column
+		}
+	} else {
+		for _, field := range collection.Fields {
+			if !isImportable(collection, field, allowHidden) {
+				continue
+			}
+			for _, column := range fileColumns {
+				if column == field.GetName() {
+					fieldColumns[field.GetName()] = column
+					break
+				}
+				if _, exists := fieldColumns[field.GetName()]; !exists && strings.EqualFold(column, field.GetName()) {
+					fieldColumns[field.GetName()] = column
+				}
+			}
+		}
+	}
+
+	columns := make([]importColumn, 0, len(fieldColumns))
+	for _, field := range collection.Fields {
+		if column, exists := fieldColumns[field.GetName()]; exists {
+			columns = append(columns, importColumn{column: column, field: field})
+		}
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no column of the import file matches a field of collection %s", collection.Name)
+	}
+
+	return columns, nil
+}
+
+// findImportField returns a field of the collection rows can be imported into
+func findImportField(collection *core.Collection, fieldName string, allowHidden bool) (core.Field, error) {
+	field := collection.Fields.GetByName(fieldName)
+	if field == nil {
+		return nil, fmt.Errorf("unknown field %q of collection %s", fieldName, collection.Name)
+	}
+	if !isImportable(collection, field, true) {
+		return nil, fmt.Errorf("field %q of collection %s cannot be imported", fieldName, collection.Name)
+	}
+	if !isImportable(collection, field, allowHidden) {
+		return nil, fmt.Errorf("hidden field %q of collection %s can be imported only by superusers", fieldName, collection.Name)
+	}
+	return field, nil
+}
+
+// isImportable reports whether values can be imported into a field: files and the dates set on save
+// cannot, nor can the token key of auth records. Hidden fields require allowHidden.
+func isImportable(collection *core.Collection, field core.Field, allowHidden bool) bool {
+	switch field.Type() {
+	case core.FieldTypeAutodate, core.FieldTypeFile:
+		return false
+	}
+	if collection.IsAuth() && field.GetName() == core.FieldNameTokenKey {
+		return false
+	}
+	return allowHidden || !field.GetHidden()
+}
+
+// importValue converts a value of an import file to the value of a field, failing when the value
+// cannot be one (e.g. a word for a number). Empty values clear the field. The values of multiple
+// select and relation fields are separated with ";" or written as a JSON array.
+func importValue(field core.Field, value any) (any, error) {
+	if text, ok := value.(string); ok && !isTextField(field) {
+		value = strings.TrimSpace(text)
+	}
+	if value == nil || value == "" {
+		return nil, nil
+	}
+
+	switch field.(type) {
+	case *core.NumberField:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			if number, err := strconv.ParseFloat(v, 64); err == nil {
+				return number, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid number %v", printable(value))
+	case *core.BoolField:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case float64:
+			if v == 0 || v == 1 {
+				return v == 1, nil
+			}
+		case string:
+			switch strings.ToLower(v) {
+			case "true", "1", "yes", "y":
+				return true, nil
+			case "false", "0", "no", "n":
+				return false, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid boolean %v, expected true or false", printable(value))
+	case *core.DateField:
+		if v, ok := value.(string); ok {
+			if date, _ := types.ParseDateTime(v); !date.IsZero() {
+				return date, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid date %v, expected a date such as 2025-03-01 10:00:00Z", printable(value))
+	case *core.SelectField, *core.RelationField:
+		values, err := importList(value)
+		if err != nil {
+			return nil, err
+		}
+		if isMultiple(field) {
+			return values, nil
+		}
+		if len(values) > 1 {
+			return nil, fmt.Errorf("expected a single value, got %d", len(values))
+		}
+		if len(values) == 0 {
+			return nil, nil
+		}
+		return values[0], nil
+	}
+
+	if !isTextField(field) {
+		// JSON and geo point values, decoded or as JSON text
+		return value, nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return nil, fmt.Errorf("invalid text value %v", printable(value))
+	}
+}
+
+// importList returns the values of a multiple value cell: a JSON array, or values separated with ";"
+func importList(value any) ([]string, error) {
+	var items []any
+
+	switch v := value.(type) {
+	case []any:
+		items = v
+	case string:
+		if strings.HasPrefix(v, "[") {
+			if err := json.Unmarshal([]byte(v), &items); err != nil {
+				return nil, fmt.Errorf("invalid JSON array %q", v)
+			}
+			break
+		}
+		for _, item := range strings.Split(v, ";") {
+			items = append(items, strings.TrimSpace(item))
+		}
+	default:
+		items = []any{v}
+	}
+
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		var text string
+		switch v := item.(type) {
+		case string:
+			text = v
+		case float64:
+			text = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("invalid value %v, expected text", printable(item))
+		}
+		if text != "" {
+			values = append(values, text)
+		}
+	}
+	return values, nil
+}
+
+// isTextField reports whether a field holds text, which is imported as it is written
+func isTextField(field core.Field) bool {
+	switch field.(type) {
+	case *core.TextField, *core.EmailField, *core.URLField, *core.EditorField, *core.PasswordField:
+		return true
+	default:
+		return false
+	}
+}
+
+// isMultiple reports whether a field holds a list of values (multiple select, file or relation)
+func isMultiple(field core.Field) bool {
+	multiple, ok := field.(interface{ IsMultiple() bool })
+	return ok && multiple.IsMultiple()
+}
+
+// printable returns a value of an import file as it is shown in errors
+func printable(value any) string {
+	if text, ok := value.(string); ok {
+		return strconv.Quote(text)
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(raw)
+}
@@ -0,0 +1,397 @@
+package dataimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/jobutils"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func newTestCollection() *core.Collection {
+	collection := core.NewBaseCollection("products")
+	collection.Fields.Add(
+		&core.TextField{Name: "name"},
+		&core.TextField{Name: "sku"},
+		&core.NumberField{Name: "price"},
+		&core.BoolField{Name: "active"},
+		&core.SelectField{Name: "tags", MaxSelect: 3, Values: []string{"new", "sale", "eco"}},
+		&core.SelectField{Name: "size", MaxSelect: 1, Values: []string{"S", "M", "L"}},
+		&core.JSONField{Name: "meta"},
+		&core.FileField{Name: "image"},
+		&core.TextField{Name: "secret", Hidden: true},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	collection.AddIndex("idx_products_sku", true, "sku", "")
+	return collection
+}
+
+func columnNames(columns []importColumn) string {
+	names := []string{}
+	for _, column := range columns {
+		names = append(names, column.column+"->"+column.field.GetName())
+	}
+	return strings.Join(names, ",")
+}
+
+func TestResolveImportColumns(t *testing.T) {
+	collection := newTestCollection()
+	fileColumns := []string{"Name", "created", "image", "notes", "price", "secret"}
+
+	columns, err := resolveImportColumns(collection, fileColumns, nil, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := columnNames(columns); got != "Name->name,price->price" {
+		t.Errorf("expected the columns named after importable fields, got %s", got)
+	}
+
+	columns, err = resolveImportColumns(collection, fileColumns, nil, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := columnNames(columns); got != "Name->name,price->price,secret->secret" {
+		t.Errorf("expected hidden fields for superusers, got %s", got)
+	}
+
+	columns, err = resolveImportColumns(collection, []string{"name", "NAME"}, nil, false)
+	if err != nil || columnNames(columns) != "name->name" {
+		t.Errorf("expected the exact name to win, got %s (%v)", columnNames(columns), err)
+	}
+
+	columns, err = resolveImportColumns(collection, fileColumns, map[string]string{"notes": "name", "Name": "sku"}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := columnNames(columns); got != "notes->name,Name->sku" {
+		t.Errorf("expected only the mapped columns, in field order, got %s", got)
+	}
+
+	tests := []struct {
+		name    string
+		mapping map[string]string
+		columns []string
+	}{
+		{"no matching column", nil, []string{"unknown"}},
+		{"missing column", map[string]string{"Title": "name"}, fileColumns},
+		{"unknown field", map[string]string{"Name": "title"}, fileColumns},
+		{"file field", map[string]string{"image": "image"}, fileColumns},
+		{"autodate field", map[string]string{"created": "created"}, fileColumns},
+		{"hidden field", map[string]string{"secret": "secret"}, fileColumns},
+		{"field mapped twice", map[string]string{"Name": "name", "notes": "name"}, fileColumns},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := resolveImportColumns(collection, tt.columns, tt.mapping, false); err == nil {
+				t.Errorf("expected an error for %v", tt.mapping)
+			}
+		})
+	}
+}
+
+func TestIsImportable(t *testing.T) {
+	users := core.NewAuthCollection("users")
+	if isImportable(users, users.Fields.GetByName(core.FieldNameTokenKey), true) {
+		t.Error("expected the token key not to be importable")
+	}
+	if !isImportable(users, users.Fields.GetByName(core.FieldNamePassword), true) {
+		t.Error("expected the password to be importable by superusers")
+	}
+	if isImportable(users, users.Fields.GetByName(core.FieldNamePassword), false) {
+		t.Error("expected the hidden password not to be importable without superuser access")
+	}
+}
+
+func TestValidateKeyField(t *testing.T) {
+	collection := newTestCollection()
+
+	for _, keyField := range []string{"id", "sku"} {
+		if err := validateKeyField(collection, keyField); err != nil {
+			t.Errorf("unexpected error for %s: %v", keyField, err)
+		}
+	}
+	for _, keyField := range []string{"name", "missing"} {
+		if err := validateKeyField(collection, keyField); err == nil {
+			t.Errorf("expected an error for %s", keyField)
+		}
+	}
+}
+
+func TestImportValue(t *testing.T) {
+	collection := newTestCollection()
+	field := func(name string) core.Field { return collection.Fields.GetByName(name) }
+
+	tests := []struct {
+		field    string
+		value    any
+		expected any
+	}{
+		{"name", "  Desk, oak ", "  Desk, oak "},
+		{"name", 12.0, "12"},
+		{"name", true, "true"},
+		{"name", nil, nil},
+		{"price", " 129.5 ", 129.5},
+		{"price", 45.0, 45.0},
+		{"price", "", nil},
+		{"active", "Yes", true},
+		{"active", "0", false},
+		{"active", 1.0, true},
+		{"active", false, false},
+		{"size", "M", "M"},
+		{"size", []any{"L"}, "L"},
+		{"meta", `{"size":"L"}`, `{"size":"L"}`},
+	}
+	for _, tt := range tests {
+		value, err := importValue(field(tt.field), tt.value)
+		if err != nil {
+			t.Errorf("%s %v: unexpected error: %v", tt.field, tt.value, err)
+			continue
+		}
+		if value != tt.expected {
+			t.Errorf("%s %v: expected %#v, got %#v", tt.field, tt.value, tt.expected, value)
+		}
+	}
+
+	dateField := &core.DateField{Name: "released"}
+	value, err := importValue(dateField, " 2025-03-01 10:00:00Z ")
+	if date, ok := value.(types.DateTime); err != nil || !ok || date.String() != "2025-03-01 10:00:00.000Z" {
+		t.Errorf("expected a date, got %v (%v)", value, err)
+	}
+
+	tags, err := importValue(field("tags"), "new; eco;")
+	if got, ok := tags.([]string); err != nil || !ok || strings.Join(got, ",") != "new,eco" {
+		t.Errorf("expected values separated with ;, got %v (%v)", tags, err)
+	}
+	tags, err = importValue(field("tags"), `["sale","new"]`)
+	if got, ok := tags.([]string); err != nil || !ok || strings.Join(got, ",") != "sale,new" {
+		t.Errorf("expected a JSON array, got %v (%v)", tags, err)
+	}
+
+	invalid := []struct {
+		field core.Field
+		value any
+	}{
+		{field("price"), "12 EUR"},
+		{field("price"), true},
+		{field("active"), "maybe"},
+		{field("active"), 2.0},
+		{dateField, "yesterday"},
+		{dateField, 45000.0},
+		{field("size"), "S; M"},
+		{field("tags"), `["new",`},
+		{field("name"), map[string]any{"a": 1.0}},
+	}
+	for _, tt := range invalid {
+		if _, err := importValue(tt.field, tt.value); err == nil {
+			t.Errorf("%s: expected an error for %v", tt.field.GetName(), tt.value)
+		}
+	}
+}
+
+func TestRowData(t *testing.T) {
+	collection := newTestCollection()
+	columns, _ := resolveImportColumns(collection, []string{"name", "price", "active"}, nil, false)
+	importer := &collectionImporter{collection: collection, columns: columns}
+
+	data, errs := importer.rowData(importRow{number: 3, values: map[string]any{"name": "Desk", "price": "12"}})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if data["name"] != "Desk" || data["price"] != 12.0 {
+		t.Errorf("unexpected data: %v", data)
+	}
+	if _, exists := data["active"]; exists {
+		t.Error("expected a missing key to leave the field unchanged")
+	}
+
+	_, errs = importer.rowData(importRow{number: 3, values: map[string]any{"price": "cheap", "active": "maybe"}})
+	if len(errs) != 2 || errs[0].row != 3 || errs[0].column != "price" || errs[1].field != "active" {
+		t.Errorf("expected an error per invalid value, got %+v", errs)
+	}
+}
+
+func TestSaveErrors(t *testing.T) {
+	collection := newTestCollection()
+	columns, _ := resolveImportColumns(collection, []string{"Price", "name"}, nil, false)
+	importer := &collectionImporter{collection: collection, columns: columns}
+
+	errs := importer.saveErrors(7, validation.Errors{
+		"price": validation.NewError("validation_min_less_equal_than_required", "Must be larger or equal than 0."),
+		"name":  validation.NewError("validation_required", "Cannot be blank."),
+	})
+	if len(errs) != 2 {
+		t.Fatalf("expected an error per field, got %+v", errs)
+	}
+	if errs[0].field != "name" || errs[1].field != "price" || errs[1].column != "Price" || errs[1].row != 7 {
+		t.Errorf("unexpected errors: %+v", errs)
+	}
+
+	errs = importer.saveErrors(8, errors.New("blocked by a hook"))
+	if len(errs) != 1 || errs[0].field != "" || errs[0].message != "blocked by a hook" {
+		t.Errorf("expected a row error, got %+v", errs)
+	}
+}
+
+type testReader struct {
+	rows int
+	read int
+}
+
+func (r *testReader) Next() (map[string]any, error) {
+	if r.read == r.rows {
+		return nil, io.EOF
+	}
+	r.read++
+	return map[string]any{"n": r.read}, nil
+}
+
+func TestReadBatch(t *testing.T) {
+	reader := &testReader{rows: 5}
+
+	rows, err := readBatch(reader, 3, 0)
+	if err != nil || len(rows) != 3 || rows[2].number != 3 {
+		t.Fatalf("expected a full batch, got %d rows (%v)", len(rows), err)
+	}
+
+	rows, err = readBatch(reader, 3, 3)
+	if !errors.Is(err, io.EOF) || len(rows) != 2 || rows[0].number != 4 || rows[1].number != 5 {
+		t.Errorf("expected the last rows with io.EOF, got %d rows (%v)", len(rows), err)
+	}
+}
+
+func TestErrorReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), reportFilename("products", time.Date(2025, 3, 1, 10, 15, 0, 0, time.UTC)))
+	if filepath.Base(path) != "products_import_errors_20250301_101500.csv" {
+		t.Errorf("unexpected report name %s", filepath.Base(path))
+	}
+
+	report := newErrorReport(path)
+	if err := report.add(nil); err != nil || !report.empty() {
+		t.Fatalf("expected no file without errors (%v)", err)
+	}
+
+	report.add([]rowError{{row: 2, column: "Price", field: "price", message: "invalid number \"12 EUR\""}})
+	report.add([]rowError{{row: 5, message: "the create rule of the collection does not allow this row"}})
+	if err := report.close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if err := report.close(); err != nil {
+		t.Errorf("expected a second close to do nothing, got %v", err)
+	}
+
+	file, _ := os.Open(path)
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid report: %v", err)
+	}
+
+	expected := [][]string{
+		{"row", "column", "field", "error"},
+		{"2", "Price", "price", `invalid number "12 EUR"`},
+		{"5", "", "", "the create rule of the collection does not allow this row"},
+	}
+	if len(rows) != len(expected) {
+		t.Fatalf("expected %d rows, got %v", len(expected), rows)
+	}
+	for i := range expected {
+		if strings.Join(rows[i], "|") != strings.Join(expected[i], "|") {
+			t.Errorf("row %d: expected %v, got %v", i, expected[i], rows[i])
+		}
+	}
+}
+
+func TestCheckImportAccess(t *testing.T) {
+	rule := ""
+	collection := newTestCollection()
+	collection.CreateRule = &rule
+
+	user := &core.RequestInfo{Auth: core.NewRecord(core.NewAuthCollection("users"))}
+	superuser := &core.RequestInfo{Auth: core.NewRecord(core.NewAuthCollection(core.CollectionNameSuperusers))}
+
+	if err := CheckImportAccess(collection, user, jobutils.DataImportModeInsert); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := CheckImportAccess(collection, user, jobutils.DataImportModeUpsert); !errors.Is(err, ErrImportForbidden) {
+		t.Errorf("expected upserts to need an update rule, got %v", err)
+	}
+	if err := CheckImportAccess(core.NewAuthCollection("members"), user, ""); !errors.Is(err, ErrImportForbidden) {
+		t.Errorf("expected auth collections to be forbidden, got %v", err)
+	}
+
+	for _, requestInfo := range []*core.RequestInfo{nil, superuser} {
+		if err := CheckImportAccess(core.NewAuthCollection("members"), requestInfo, jobutils.DataImportModeUpsert); err != nil {
+			t.Errorf("expected the rules to be bypassed, got %v", err)
+		}
+	}
+}
+
+func TestCanUseImportFile(t *testing.T) {
+	uploads := core.NewBaseCollection(jobutils.ImportFilesCollectionName)
+	uploads.Fields.Add(&core.TextField{Name: "user_id"})
+
+	owned := core.NewRecord(uploads)
+	owned.Set("user_id", "user1")
+	unowned := core.NewRecord(uploads)
+
+	owner := core.NewRecord(core.NewAuthCollection("users"))
+	owner.Id = "user1"
+	other := core.NewRecord(core.NewAuthCollection("users"))
+	other.Id = "user2"
+	superuser := core.NewRecord(core.NewAuthCollection(core.CollectionNameSuperusers))
+
+	tests := []struct {
+		name        string
+		upload      *core.Record
+		requestInfo *core.RequestInfo
+		expected    bool
+	}{
+		{"owner", owned, &core.RequestInfo{Auth: owner}, true},
+		{"other user", owned, &core.RequestInfo{Auth: other}, false},
+		{"job without owner", owned, nil, true},
+		{"superuser upload", unowned, &core.RequestInfo{Auth: superuser}, true},
+		{"superuser upload and a user", unowned, &core.RequestInfo{Auth: owner}, false},
+	}
+	for _, tt := range tests {
+		if got := canUseImportFile(tt.upload, tt.requestInfo); got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestImportBatchSize(t *testing.T) {
+	t.Setenv("IMPORT_BATCH_SIZE", "")
+	if got := importBatchSize(); got != DefaultImportBatchSize {
+		t.Errorf("expected the default batch size, got %d", got)
+	}
+
+	t.Setenv("IMPORT_BATCH_SIZE", "50")
+	if got := importBatchSize(); got != 50 {
+		t.Errorf("expected 50, got %d", got)
+	}
+
+	t.Setenv("IMPORT_BATCH_SIZE", "-1")
+	if got := importBatchSize(); got != DefaultImportBatchSize {
+		t.Errorf("expected the default for an invalid size, got %d", got)
+	}
+}
+
+func TestImportMessage(t *testing.T) {
+	stats := importStats{rows: 160, created: 118, updated: 40, failed: 2}
+
+	if got := importMessage("products", false, stats); got != "Import into products completed: 118 rows created, 40 updated and 2 failed" {
+		t.Errorf("unexpected message %q", got)
+	}
+	if got := importMessage("products", true, stats); !strings.HasPrefix(got, "Dry run of the import into products completed: 118 rows would be created") {
+		t.Errorf("unexpected dry run message %q", got)
+	}
+}
@@ -0,0 +1,62 @@
+package dataimport
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"ims-pocketbase-baas-starter/pkg/jobutils"
+)
+
+// Reader reads the rows of an import file, one at a time. Row values are strings for CSV files,
+// strings, float64 or bool for XLSX files and decoded JSON values for JSON files.
+type Reader interface {
+	// Next returns the next row keyed by column, or io.EOF after the last row
+	Next() (map[string]any, error)
+}
+
+// Format is an import file format and the Reader that parses it
+type Format struct {
+	Name      string                                          // Format of the import (e.g. csv)
+	Extension string                                          // File name extension, without the dot
+	NewReader func(r io.ReaderAt, size int64) (Reader, error) // Creates a reader of a whole file
+}
+
+var formats = map[string]Format{
+	jobutils.DataProcessingFileCSV:    {Name: jobutils.DataProcessingFileCSV, Extension: "csv", NewReader: newCSVReader},
+	jobutils.DataProcessingFileXLSX:   {Name: jobutils.DataProcessingFileXLSX, Extension: "xlsx", NewReader: newXLSXReader},
+	jobutils.DataProcessingFileJSON:   {Name: jobutils.DataProcessingFileJSON, Extension: "json", NewReader: newJSONReader},
+	jobutils.DataProcessingFileNDJSON: {Name: jobutils.DataProcessingFileNDJSON, Extension: "ndjson", NewReader: newNDJSONReader},
+}
+
+// GetFormat returns the import format with the given name
+func GetFormat(name string) (Format, error) {
+	format, ok := formats[strings.ToLower(name)]
+	if !ok {
+		return Format{}, fmt.Errorf("unsupported import format: %q (supported: %s)", name, strings.Join(FormatNames(), ", "))
+	}
+	return format, nil
+}
+
+// FormatFromFilename returns the import format of a file name extension (empty when not supported)
+func FormatFromFilename(filename string) string {
+	extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	for _, format := range formats {
+		if format.Extension == extension {
+			return format.Name
+		}
+	}
+	return ""
+}
+
+// FormatNames returns the names of the import formats, sorted
+func FormatNames() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,69 @@
+package dataimport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// utf8BOM starts the CSV files saved by some spreadsheet applications
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// csvReader reads CSV files whose first line holds the column names. Short lines leave the
+// missing columns empty and extra values are ignored.
+type csvReader struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+func newCSVReader(r io.ReaderAt, size int64) (Reader, error) {
+	buffered := bufio.NewReader(io.NewSectionReader(r, 0, size))
+	if start, _ := buffered.Peek(len(utf8BOM)); bytes.Equal(start, utf8BOM) {
+		buffered.Discard(len(utf8BOM))
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = strings.TrimSpace(column)
+	}
+
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (c *csvReader) Next() (map[string]any, error) {
+	values, err := c.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	row := make(map[string]any, len(c.columns))
+	for i, column := range c.columns {
+		if column == "" {
+			continue
+		}
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		row[column] = value
+	}
+	return row, nil
+}
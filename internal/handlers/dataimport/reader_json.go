@@ -0,0 +1,64 @@
+package dataimport
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// jsonReader reads JSON files holding an array of objects, or with lines set newline delimited JSON
+// (one object per line). Objects are decoded one at a time, so the file is never loaded whole.
+type jsonReader struct {
+	decoder *json.Decoder
+	lines   bool
+	row     int
+}
+
+func newJSONReader(r io.ReaderAt, size int64) (Reader, error) {
+	decoder := json.NewDecoder(bufio.NewReader(io.NewSectionReader(r, 0, size)))
+
+	token, err := decoder.Token()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the JSON file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("invalid JSON: expected an array of objects")
+	}
+
+	return &jsonReader{decoder: decoder}, nil
+}
+
+func newNDJSONReader(r io.ReaderAt, size int64) (Reader, error) {
+	decoder := json.NewDecoder(bufio.NewReader(io.NewSectionReader(r, 0, size)))
+	return &jsonReader{decoder: decoder, lines: true}, nil
+}
+
+func (j *jsonReader) Next() (map[string]any, error) {
+	if !j.lines && !j.decoder.More() {
+		// closing bracket of the array
+		if _, err := j.decoder.Token(); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return nil, io.EOF
+	}
+
+	j.row++
+
+	var value any
+	if err := j.decoder.Decode(&value); errors.Is(err, io.EOF) && j.lines {
+		return nil, io.EOF
+	} else if err != nil {
+		return nil, fmt.Errorf("invalid JSON in row %d: %w", j.row, err)
+	}
+
+	row, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid JSON: row %d is not an object", j.row)
+	}
+	return row, nil
+}
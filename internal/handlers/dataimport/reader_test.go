@@ -0,0 +1,203 @@
+package dataimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ims-pocketbase-baas-starter/internal/handlers/export"
+)
+
+func readTestRows(t *testing.T, name string, data []byte) []map[string]any {
+	t.Helper()
+
+	format, err := GetFormat(name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reader, err := format.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	rows := []map[string]any{}
+	for {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestGetFormat(t *testing.T) {
+	for _, name := range []string{"csv", "XLSX", "json", "ndjson"} {
+		format, err := GetFormat(name)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", name, err)
+			continue
+		}
+		if format.Extension == "" || format.NewReader == nil {
+			t.Errorf("incomplete format %q: %+v", name, format)
+		}
+	}
+
+	if _, err := GetFormat("pdf"); err == nil || !strings.Contains(err.Error(), "csv, json, ndjson, xlsx") {
+		t.Errorf("expected an error listing the formats, got %v", err)
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	tests := map[string]string{
+		"products.csv":        "csv",
+		"Products.XLSX":       "xlsx",
+		"export.2025.ndjson":  "ndjson",
+		"data.json":           "json",
+		"report.pdf":          "",
+		"no_extension":        "",
+		"archive.csv.zip":     "",
+		"/tmp/upload/one.csv": "csv",
+	}
+	for filename, expected := range tests {
+		if got := FormatFromFilename(filename); got != expected {
+			t.Errorf("%s: expected %q, got %q", filename, expected, got)
+		}
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	data := "\xef\xbb\xbfname, price ,tags\n\"Desk, oak\",129.5,new; eco\nChair,45\n"
+
+	rows := readTestRows(t, "csv", []byte(data))
+
+	expected := []map[string]any{
+		{"name": "Desk, oak", "price": "129.5", "tags": "new; eco"},
+		{"name": "Chair", "price": "45", "tags": ""},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %v, got %v", expected, rows)
+	}
+
+	if _, err := newCSVReader(strings.NewReader(""), 0); err == nil {
+		t.Error("expected an error for an empty file")
+	}
+}
+
+func TestJSONReader(t *testing.T) {
+	data := `[{"name":"Desk","price":129.5,"tags":["new","eco"]},{"name":"Chair","active":true}]`
+
+	rows := readTestRows(t, "json", []byte(data))
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if rows[0]["price"] != 129.5 || rows[1]["active"] != true {
+		t.Errorf("expected decoded values, got %v", rows)
+	}
+	if tags, ok := rows[0]["tags"].([]any); !ok || len(tags) != 2 {
+		t.Errorf("expected a tags array, got %v", rows[0]["tags"])
+	}
+
+	if rows := readTestRows(t, "json", []byte(" [ ] ")); len(rows) != 0 {
+		t.Errorf("expected no rows, got %v", rows)
+	}
+
+	for _, invalid := range []string{"", `{"name":"Desk"}`, `[1, 2]`} {
+		reader, err := newJSONReader(strings.NewReader(invalid), int64(len(invalid)))
+		if err == nil {
+			_, err = reader.Next()
+		}
+		if err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestNDJSONReader(t *testing.T) {
+	data := "{\"name\":\"Desk\"}\n\n{\"name\":\"Chair\",\"price\":45}\n"
+
+	rows := readTestRows(t, "ndjson", []byte(data))
+	if len(rows) != 2 || rows[1]["name"] != "Chair" || rows[1]["price"] != 45.0 {
+		t.Errorf("expected one row per line, got %v", rows)
+	}
+
+	invalid := "{\"name\":\"Desk\"}\n[1]\n"
+	reader, _ := newNDJSONReader(strings.NewReader(invalid), int64(len(invalid)))
+	reader.Next()
+	if _, err := reader.Next(); err == nil || !strings.Contains(err.Error(), "row 2") {
+		t.Errorf("expected an error naming the row, got %v", err)
+	}
+}
+
+func TestXLSXReader(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+			`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Products" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId3" Type="worksheet" Target="worksheets/products.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>name</t></si><si><t>price</t></si><si><r><t>Desk, </t></r><r><t>oak</t></r></si></sst>`,
+		"xl/worksheets/products.xml": `<worksheet><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="inlineStr"><is><t>active</t></is></c></row>` +
+			`<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>129.5</v></c><c r="D2" t="b"><v>1</v></c></row>` +
+			`<row r="3"><c r="A3" s="1"/></row>` +
+			`<row r="4"><c r="B4"><v>45</v></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+	for name, content := range parts {
+		w, _ := archive.Create(name)
+		w.Write([]byte(content))
+	}
+	archive.Close()
+
+	rows := readTestRows(t, "xlsx", buf.Bytes())
+
+	expected := []map[string]any{
+		{"name": "Desk, oak", "price": 129.5, "active": true},
+		{"name": "", "price": 45.0, "active": ""},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %v, got %v", expected, rows)
+	}
+
+	if _, err := newXLSXReader(strings.NewReader("not a zip"), 9); err == nil {
+		t.Error("expected an error for an invalid archive")
+	}
+}
+
+func TestXLSXReaderReadsExports(t *testing.T) {
+	format, _ := export.GetFormat("xlsx")
+
+	var buf bytes.Buffer
+	writer := format.NewWriter(&buf, "products")
+	writer.WriteHeader([]string{"name", "price", "active", "tags"})
+	writer.WriteRow([]any{"Chair <B&W>", 45.0, false, []string{"new", "eco"}})
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	rows := readTestRows(t, "xlsx", buf.Bytes())
+
+	expected := []map[string]any{{"name": "Chair <B&W>", "price": 45.0, "active": false, "tags": "new; eco"}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %v, got %v", expected, rows)
+	}
+}
+
+func TestXLSXColumnIndex(t *testing.T) {
+	columns := map[string]int{"A1": 0, "Z9": 25, "AA7": 26, "AZ1": 51, "BA1": 52, "XFD1": 16383, "XFE1": -1, "1": -1}
+	for ref, index := range columns {
+		if got := xlsxColumnIndex(ref); got != index {
+			t.Errorf("%s: expected %d, got %d", ref, index, got)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package dataimport
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// xlsxMaxColumns is the number of columns of a worksheet (A to XFD)
+const xlsxMaxColumns = 16384
+
+// xlsxCell is a cell of a worksheet row
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"is"`
+}
+
+// xlsxReader reads the first worksheet of an Office Open XML workbook, whose first row holds the
+// column names. The worksheet is decoded one row at a time; only the shared strings are kept in
+// memory. Dates are read as the numbers spreadsheets store them as, so they should be text cells.
+type xlsxReader struct {
+	decoder *xml.Decoder
+	sheet   io.ReadCloser
+	strings []string // shared strings
+	columns []string
+	row     int
+}
+
+func newXLSXReader(r io.ReaderAt, size int64) (Reader, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX file: %w", err)
+	}
+
+	x := &xlsxReader{}
+
+	if file := findZipFile(archive, "xl/sharedStrings.xml"); file != nil {
+		if x.strings, err = readSharedStrings(file); err != nil {
+			return nil, err
+		}
+	}
+
+	sheetFile := findZipFile(archive, firstSheetPath(archive))
+	if sheetFile == nil {
+		return nil, errors.New("invalid XLSX file: no worksheet found")
+	}
+	if x.sheet, err = sheetFile.Open(); err != nil {
+		return nil, fmt.Errorf("invalid XLSX file: %w", err)
+	}
+	x.decoder = xml.NewDecoder(x.sheet)
+
+	header, err := x.nextRow()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the XLSX worksheet is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	x.columns = make([]string, len(header))
+	for i, value := range header {
+		if value != nil {
+			x.columns[i] = strings.TrimSpace(fmt.Sprint(value))
+		}
+	}
+
+	return x, nil
+}
+
+func (x *xlsxReader) Next() (map[string]any, error) {
+	for {
+		values, err := x.nextRow()
+		if err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]any, len(x.columns))
+		empty := true
+		for i, column := range x.columns {
+			if column == "" {
+				continue
+			}
+			var value any = ""
+			if i < len(values) && values[i] != nil {
+				value = values[i]
+				empty = false
+			}
+			row[column] = value
+		}
+
+		// spreadsheets often keep formatted but empty rows below the data
+		if !empty {
+			return row, nil
+		}
+	}
+}
+
+// nextRow decodes the next row of the worksheet, with nil for the empty cells
+func (x *xlsxReader) nextRow() ([]any, error) {
+	for {
+		token, err := x.decoder.Token()
+		if errors.Is(err, io.EOF) {
+			x.sheet.Close()
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XLSX worksheet: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row struct {
+			Cells []xlsxCell `xml:"c"`
+		}
+		if err := x.decoder.DecodeElement(&row, &start); err != nil {
+			return nil, fmt.Errorf("invalid XLSX worksheet: %w", err)
+		}
+		x.row++
+
+		var values []any
+		for i, cell := range row.Cells {
+			index := i
+			if cell.Ref != "" {
+				index = xlsxColumnIndex(cell.Ref)
+			}
+			if index < 0 || index >= xlsxMaxColumns {
+				return nil, fmt.Errorf("invalid XLSX cell reference %q", cell.Ref)
+			}
+			for len(values) <= index {
+				values = append(values, nil)
+			}
+
+			value, err := x.cellValue(cell)
+			if err != nil {
+				return nil, fmt.Errorf("invalid XLSX cell %s in row %d: %w", cell.Ref, x.row, err)
+			}
+			values[index] = value
+		}
+		return values, nil
+	}
+}
+
+// cellValue returns the value of a cell: a string, a float64, a bool, or nil when empty
+func (x *xlsxReader) cellValue(cell xlsxCell) (any, error) {
+	switch cell.Type {
+	case "s":
+		index, err := strconv.Atoi(cell.Value)
+		if err != nil || index < 0 || index >= len(x.strings) {
+			return nil, fmt.Errorf("unknown shared string %q", cell.Value)
+		}
+		return x.strings[index], nil
+	case "inlineStr":
+		text := cell.Inline.Text
+		for _, run := range cell.Inline.Runs {
+			text += run.Text
+		}
+		return text, nil
+	case "b":
+		return cell.Value == "1", nil
+	case "str", "e":
+		return cell.Value, nil
+	default:
+		if cell.Value == "" {
+			return nil, nil
+		}
+		number, err := strconv.ParseFloat(cell.Value, 64)
+		if err != nil {
+			return cell.Value, nil
+		}
+		return number, nil
+	}
+}
+
+// xlsxColumnIndex returns the zero based column index of a cell reference (A1 -> 0, AA7 -> 26)
+func xlsxColumnIndex(ref string) int {
+	index := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A'+1)
+		if index > xlsxMaxColumns {
+			return -1
+		}
+	}
+	return index - 1
+}
+
+// readSharedStrings reads the strings the cells of the workbook refer to by index
+func readSharedStrings(file *zip.File) ([]string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX shared strings: %w", err)
+	}
+	defer reader.Close()
+
+	var sst struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := xml.NewDecoder(reader).Decode(&sst); err != nil {
+		return nil, fmt.Errorf("invalid XLSX shared strings: %w", err)
+	}
+
+	values := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		text := item.Text
+		for _, run := range item.Runs {
+			text += run.Text
+		}
+		values[i] = text
+	}
+	return values, nil
+}
+
+// firstSheetPath returns the archive path of the first worksheet of the workbook
+func firstSheetPath(archive *zip.Reader) string {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	var workbook struct {
+		Sheets []struct {
+			RelationID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	if decodeZipXML(archive, "xl/workbook.xml", &workbook) != nil || len(workbook.Sheets) == 0 {
+		return fallback
+	}
+	if decodeZipXML(archive, "xl/_rels/workbook.xml.rels", &rels) != nil {
+		return fallback
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RelationID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/")
+		}
+		return path.Join("xl", rel.Target)
+	}
+	return fallback
+}
+
+// decodeZipXML decodes an XML part of the archive
+func decodeZipXML(archive *zip.Reader, name string, v any) error {
+	file := findZipFile(archive, name)
+	if file == nil {
+		return fmt.Errorf("missing XLSX part %s", name)
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return xml.NewDecoder(reader).Decode(v)
+}
+
+// findZipFile returns the file of the archive with the given name, or nil
+func findZipFile(archive *zip.Reader, name string) *zip.File {
+	for _, file := range archive.File {
+		if file.Name == name {
+			return file
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package dataimport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// rowError is an error of a row of an import file: a value of a column, or the whole row when
+// column and field are empty
+type rowError struct {
+	row     int // number of the row, from 1 for the first row after the header
+	column  string
+	field   string
+	message string
+}
+
+// errorReport writes the row errors of an import to a CSV file, created with the first error
+type errorReport struct {
+	path   string
+	file   *os.File
+	writer *csv.Writer
+}
+
+func newErrorReport(path string) *errorReport {
+	return &errorReport{path: path}
+}
+
+// reportFilename returns the name of the error report of an import into a collection
+func reportFilename(collection string, now time.Time) string {
+	return fmt.Sprintf("%s_import_errors_%s.csv", collection, now.Format("20060102_150405"))
+}
+
+// add writes row errors to the report
+func (r *errorReport) add(errs []rowError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	if r.file == nil {
+		file, err := os.Create(r.path)
+		if err != nil {
+			return fmt.Errorf("failed to create error report: %w", err)
+		}
+		r.file = file
+		r.writer = csv.NewWriter(file)
+
+		if err := r.writer.Write([]string{"row", "column", "field", "error"}); err != nil {
+			return fmt.Errorf("failed to write error report: %w", err)
+		}
+	}
+
+	for _, e := range errs {
+		if err := r.writer.Write([]string{strconv.Itoa(e.row), e.column, e.field, e.message}); err != nil {
+			return fmt.Errorf("failed to write error report: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// empty reports whether no error was added to the report
+func (r *errorReport) empty() bool {
+	return r.file == nil
+}
+
+// close ends the report file; it can be called more than once
+func (r *errorReport) close() error {
+	if r.file == nil || r.writer == nil {
+		return nil
+	}
+
+	r.writer.Flush()
+	err := r.writer.Error()
+	r.writer = nil
+
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write error report: %w", err)
+	}
+	return nil
+}
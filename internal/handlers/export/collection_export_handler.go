@@ -0,0 +1,739 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/common"
+	"ims-pocketbase-baas-starter/pkg/jobutils"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/search"
+)
+
+// DefaultExportBatchSize is the number of records an export fetches and writes at a time
+// when EXPORT_BATCH_SIZE is not set
+const DefaultExportBatchSize = 1000
+
+// Bounds of the result_expiry option of an export
+const (
+	MinExportResultExpiry = time.Hour
+	MaxExportResultExpiry = 365 * 24 * time.Hour
+)
+
+// filenamePrefixPattern is the format of the filename_prefix option of an export
+var filenamePrefixPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,99}$`)
+
+// ErrExportForbidden is returned when the requester of an export is not allowed to list the collection
+var ErrExportForbidden = errors.New("the export requester is not allowed to list this collection")
+
+// relationDisplayFields are the fields shown for a related record whose collection has no
+// presentable field, in order of preference (the record ID is shown when none exists)
+var relationDisplayFields = []string{"name", "title", "slug", "email", "username"}
+
+// exportColumn is a column of an export: a field of the collection, or for relation fields the
+// display field of the related records (e.g. "permissions.slug")
+type exportColumn struct {
+	header   string
+	field    core.Field
+	relation *relationColumn
+	email    bool // the email of auth records, blank when the requester cannot see it
+}
+
+// relationColumn resolves the IDs of a relation field to the display values of the related records
+type relationColumn struct {
+	collection   *core.Collection
+	displayField core.Field        // nil shows the record IDs
+	values       map[string]string // related record ID -> display value
+}
+
+// HandleCollectionExport exports the records of the collection in payload.Data.Source to a file in
+// the format of payload.Data.Target (csv, xlsx, json, ndjson or pdf; CSV when empty).
+// The records are filtered with payload.Data.Filter and sorted with payload.Data.Sort (PocketBase
+// filter and sort syntax), and payload.Data.Fields selects the columns (every visible field when empty).
+// payload.Options.FilenamePrefix starts the file name and payload.Options.ResultExpiry sets how long
+// the file is kept.
+//
+// The export runs with the access of the job owner: the list rule of the collection is applied as it is
+// for the records API, related records are shown only when their view rule allows it and the emails of
+// auth records only when they are visible to the owner. Jobs without
+// an owner (e.g. scheduled jobs) export without rules. The export file belongs to the job owner.
+//
+// The IDs of the matching records are listed first, then the records are fetched in batches of
+// EXPORT_BATCH_SIZE from that list and written to a temporary file as they are fetched, so memory use
+// only grows with the IDs; the file is then streamed to the storage.
+// It stops between batches once ctx is done (job deadline reached or app shutting down).
+func HandleCollectionExport(ctx context.Context, app *pocketbase.PocketBase, job *jobutils.JobData, payload *jobutils.DataProcessingJobPayload) error {
+	jobId := job.ID
+
+	format, collection, columns, err := resolveExport(app, payload)
+	if err != nil {
+		return jobutils.NewPermanentError(err)
+	}
+
+	if err := validateFilenamePrefix(payload.Options.FilenamePrefix); err != nil {
+		return jobutils.NewPermanentError(err)
+	}
+	resultExpiry, err := parseResultExpiry(payload.Options.ResultExpiry)
+	if err != nil {
+		return jobutils.NewPermanentError(err)
+	}
+
+	requestInfo, err := FindRequester(app, job.OwnerID)
+	if err != nil {
+		return jobutils.NewPermanentError(err)
+	}
+
+	reportProgress(jobId, 0, "Counting "+collection.Name)
+
+	query, err := newExportQuery(app, collection, requestInfo, payload.Data.Filter, payload.Data.Sort)
+	if errors.Is(err, ErrExportForbidden) {
+		log.Warn("Export requester cannot list the collection", "job_id", jobId, "collection", collection.Name, "owner_id", job.OwnerID)
+		return jobutils.NewPermanentError(err)
+	}
+	if err != nil {
+		log.Error("Failed to prepare export query", "job_id", jobId, "collection", collection.Name, "error", err)
+		return jobutils.NewPermanentError(fmt.Errorf("failed to query %s records: %w", collection.Name, err))
+	}
+
+	ids, err := findExportIds(collection, query)
+	if err != nil {
+		log.Error("Failed to list records", "job_id", jobId, "collection", collection.Name, "error", err)
+		return fmt.Errorf("failed to list %s records: %w", collection.Name, err)
+	}
+
+	log.Info("Counted records for export", "job_id", jobId, "collection", collection.Name, "record_count", len(ids))
+
+	if len(ids) == 0 {
+		// a filter matching nothing is a normal outcome: the file only has the header row
+		log.Info("No records found to export, writing an empty file", "job_id", jobId, "collection", collection.Name)
+	}
+
+	tempDir, err := os.MkdirTemp("", "export-"+jobId+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	filename := exportFilename(payload.Options.FilenamePrefix, collection.Name, format, time.Now())
+	filePath := filepath.Join(tempDir, filename)
+
+	recordCount, err := writeExportFile(ctx, app, jobId, filePath, format, collection, columns, query, requestInfo, ids)
+	if err != nil {
+		return err
+	}
+
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read export file: %w", err)
+	}
+
+	log.Info("Generated export file", "job_id", jobId, "filename", filename, "format", format.Name, "file_size", fileInfo.Size())
+
+	if err := ctx.Err(); err != nil {
+		log.Warn("Export interrupted before saving the file", "job_id", jobId, "error", err)
+		return fmt.Errorf("export operation interrupted: %w", err)
+	}
+
+	reportProgress(jobId, 90, "Saving export file")
+
+	var expiresAt time.Time
+	if resultExpiry > 0 {
+		expiresAt = time.Now().Add(resultExpiry)
+	}
+
+	exportRecord, err := jobutils.SaveExportFileFromPath(app, jobId, job.OwnerID, filePath, format.ContentType, recordCount, expiresAt)
+	if err != nil {
+		log.Error("Failed to save export file", "job_id", jobId, "error", err)
+		return fmt.Errorf("failed to save export file: %w", err)
+	}
+
+	message := fmt.Sprintf("Export of %s completed successfully", collection.Name)
+	if recordCount == 0 {
+		message = fmt.Sprintf("Export of %s completed, no records matched", collection.Name)
+	}
+
+	if err := jobutils.SetJobResult(jobId, &jobutils.FileExportResult{
+		BaseJobResultData: jobutils.BaseJobResultData{
+			Message:   message,
+			Timestamp: time.Now(),
+		},
+		ExportRecordId: exportRecord.Id,
+		FileName:       exportRecord.GetString("file"),
+		FileSize:       fileInfo.Size(),
+		RecordCount:    recordCount,
+		ContentType:    format.ContentType,
+	}); err != nil {
+		log.Debug("Failed to store export job result", "job_id", jobId, "error", err)
+	}
+
+	log.Info("Export completed successfully", "job_id", jobId, "collection", collection.Name, "filename", filename, "record_count", recordCount)
+
+	return nil
+}
+
+// writeExportFile writes the records of the export query with the listed IDs to filePath, one batch at
+// a time: each batch is fetched, its relations resolved and its rows written before the next one is
+// fetched. Paging through the IDs listed when the export started keeps records created or deleted
+// meanwhile from shifting the batches (records deleted meanwhile are left out).
+// It returns the number of exported records.
+func writeExportFile(ctx context.Context, app core.App, jobId, filePath string, format Format, collection *core.Collection, columns []*exportColumn, query *dbx.SelectQuery, requestInfo *core.RequestInfo, ids []string) (int, error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer file.Close()
+
+	buffered := bufio.NewWriter(file)
+
+	stream, err := newExportStream(format, buffered, collection.Name, columns, requestInfo)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write %s header: %w", format.Name, err)
+	}
+
+	batchSize := exportBatchSize()
+	exported := 0
+
+	for offset := 0; offset < len(ids); offset += batchSize {
+		if err := ctx.Err(); err != nil {
+			log.Warn("Export interrupted", "job_id", jobId, "exported", exported, "error", err)
+			return 0, fmt.Errorf("export operation interrupted: %w", err)
+		}
+
+		batchIds := make([]any, 0, batchSize)
+		for _, id := range ids[offset:min(offset+batchSize, len(ids))] {
+			batchIds = append(batchIds, id)
+		}
+
+		// shallow clone, as the search provider does for its pages; the query keeps the sort of the export
+		batchQuery := *query
+		batchQuery.AndWhere(dbx.In(collection.Name+".id", batchIds...))
+
+		records := []*core.Record{}
+		if err := batchQuery.All(&records); err != nil {
+			log.Error("Failed to fetch records", "job_id", jobId, "collection", collection.Name, "offset", offset, "error", err)
+			return 0, fmt.Errorf("failed to fetch %s records: %w", collection.Name, err)
+		}
+
+		for _, column := range columns {
+			if column.relation == nil {
+				continue
+			}
+			if err := column.relation.load(app, records, column.field.GetName(), requestInfo); err != nil {
+				log.Error("Failed to resolve relation", "job_id", jobId, "field", column.field.GetName(), "error", err)
+				return 0, fmt.Errorf("failed to resolve relation %s: %w", column.field.GetName(), err)
+			}
+		}
+
+		if err := stream.write(records); err != nil {
+			log.Error("Failed to write records", "job_id", jobId, "format", format.Name, "error", err)
+			return 0, fmt.Errorf("failed to convert records to %s: %w", format.Name, err)
+		}
+		exported += len(records)
+
+		done := min(offset+batchSize, len(ids))
+		reportProgress(jobId, 5+85*done/len(ids), fmt.Sprintf("Exported %d of %d records", done, len(ids)))
+	}
+
+	if err := stream.close(); err != nil {
+		return 0, fmt.Errorf("failed to convert records to %s: %w", format.Name, err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to write export file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	return exported, nil
+}
+
+// exportBatchSize returns how many records an export fetches and writes at a time
+func exportBatchSize() int {
+	size := common.GetEnvInt("EXPORT_BATCH_SIZE", DefaultExportBatchSize)
+	if size <= 0 {
+		return DefaultExportBatchSize
+	}
+	return size
+}
+
+// reportProgress stores the progress of the export job; failures are logged and never fail the export
+func reportProgress(jobId string, percent int, message string) {
+	if err := jobutils.ReportProgress(jobId, percent, message); err != nil {
+		log.Debug("Failed to report export progress", "job_id", jobId, "progress", percent, "error", err)
+	}
+}
+
+// ValidateExportPayload checks that an export payload can run before it is queued: the format and the
+// collection exist, the fields are known, the filter and sort are valid and so are the file options.
+// The filter and sort are resolved for the requester the export runs with (nil for jobs without an
+// owner), as the export resolves them. It returns validation.Errors keyed by the JSON name of the
+// invalid payload values.
+func ValidateExportPayload(app core.App, payload *jobutils.DataProcessingJobPayload, requestInfo *core.RequestInfo) error {
+	if _, err := GetFormat(payload.Data.Target); err != nil {
+		return validation.Errors{"target": err}
+	}
+
+	collection, err := app.FindCachedCollectionByNameOrId(payload.Data.Source)
+	if err != nil {
+		return validation.Errors{"source": fmt.Errorf("collection %q not found", payload.Data.Source)}
+	}
+
+	errs := validation.Errors{}
+
+	if _, err := resolveExportColumns(app, collection, payload.Data.Fields); err != nil {
+		errs["fields"] = err
+	}
+
+	resolver := core.NewRecordFieldResolver(app, collection, requestInfo, true)
+	// hidden fields can be filtered and sorted on only by superusers
+	resolver.SetAllowHiddenFields(bypassesRules(requestInfo))
+	if payload.Data.Filter != "" {
+		if _, err := search.FilterData(payload.Data.Filter).BuildExpr(resolver); err != nil {
+			errs["filter"] = fmt.Errorf("invalid export filter: %w", err)
+		}
+	}
+	for _, sortField := range search.ParseSortFromString(payload.Data.Sort) {
+		if _, err := sortField.BuildExpr(resolver); err != nil {
+			errs["sort"] = fmt.Errorf("invalid export sort: %w", err)
+			break
+		}
+	}
+
+	if err := validateFilenamePrefix(payload.Options.FilenamePrefix); err != nil {
+		errs["filename_prefix"] = err
+	}
+	if _, err := parseResultExpiry(payload.Options.ResultExpiry); err != nil {
+		errs["result_expiry"] = err
+	}
+
+	return errs.Filter()
+}
+
+// validateFilenamePrefix checks the filename_prefix option of an export (empty uses the default name)
+func validateFilenamePrefix(prefix string) error {
+	if prefix != "" && !filenamePrefixPattern.MatchString(prefix) {
+		return errors.New("the filename prefix must be at most 100 letters, digits, _ or -, starting with a letter or digit")
+	}
+	return nil
+}
+
+// parseResultExpiry parses the result_expiry option of an export: how long the export file is kept
+// (zero when empty, for the EXPORT_FILE_EXPIRATION_DAYS default)
+func parseResultExpiry(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	expiry, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid result expiry %q, expected a duration such as 72h", value)
+	}
+	if expiry < MinExportResultExpiry || expiry > MaxExportResultExpiry {
+		return 0, errors.New("the result expiry must be between 1h and 8760h (365 days)")
+	}
+
+	return expiry, nil
+}
+
+// exportFilename returns the name of an export file: the filename prefix (<collection>_export by
+// default), the time of the export and the extension of the format
+func exportFilename(prefix, collection string, format Format, now time.Time) string {
+	if prefix == "" {
+		prefix = collection + "_export"
+	}
+	return fmt.Sprintf("%s_%s.%s", prefix, now.Format("20060102_150405"), format.Extension)
+}
+
+// resolveExport finds the format and the collection of an export payload and resolves its columns
+func resolveExport(app core.App, payload *jobutils.DataProcessingJobPayload) (Format, *core.Collection, []*exportColumn, error) {
+	format, err := GetFormat(payload.Data.Target)
+	if err != nil {
+		return Format{}, nil, nil, err
+	}
+
+	collection, err := app.FindCachedCollectionByNameOrId(payload.Data.Source)
+	if err != nil {
+		return Format{}, nil, nil, fmt.Errorf("collection %q not found: %w", payload.Data.Source, err)
+	}
+
+	columns, err := resolveExportColumns(app, collection, payload.Data.Fields)
+	if err != nil {
+		return Format{}, nil, nil, err
+	}
+
+	return format, collection, columns, nil
+}
+
+// resolveExportColumns resolves the requested fields of a collection to export columns.
+// Without fields every field that is not hidden is exported, in the order of the collection.
+func resolveExportColumns(app core.App, collection *core.Collection, fields []string) ([]*exportColumn, error) {
+	if len(fields) == 0 {
+		for _, field := range collection.Fields {
+			if !field.GetHidden() && field.Type() != core.FieldTypePassword {
+				fields = append(fields, field.GetName())
+			}
+		}
+	}
+
+	columns := make([]*exportColumn, 0, len(fields))
+	for _, name := range fields {
+		fieldName, displayName, hasDisplay := strings.Cut(strings.TrimSpace(name), ".")
+
+		field := collection.Fields.GetByName(fieldName)
+		if field == nil {
+			return nil, fmt.Errorf("unknown field %q of collection %s", fieldName, collection.Name)
+		}
+		if field.GetHidden() || field.Type() == core.FieldTypePassword {
+			return nil, fmt.Errorf("hidden field %q of collection %s cannot be exported", fieldName, collection.Name)
+		}
+
+		column := &exportColumn{header: strings.TrimSpace(name), field: field, email: isAuthEmail(collection, field)}
+
+		relationField, isRelation := field.(*core.RelationField)
+		if hasDisplay && !isRelation {
+			return nil, fmt.Errorf("field %q of collection %s is not a relation", fieldName, collection.Name)
+		}
+
+		if isRelation {
+			related, err := app.FindCachedCollectionByNameOrId(relationField.CollectionId)
+			if err != nil {
+				return nil, fmt.Errorf("related collection of field %q not found: %w", fieldName, err)
+			}
+
+			displayField := relationDisplayField(related)
+			if hasDisplay {
+				displayField = related.Fields.GetByName(displayName)
+				if displayField == nil || displayField.GetHidden() || displayField.Type() == core.FieldTypePassword {
+					return nil, fmt.Errorf("unknown field %q of collection %s", displayName, related.Name)
+				}
+			}
+
+			column.relation = &relationColumn{collection: related, displayField: displayField}
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+// relationDisplayField returns the field shown for the records of a related collection: its first
+// presentable field, otherwise the first of relationDisplayFields it has (nil shows the record IDs)
+func relationDisplayField(collection *core.Collection) core.Field {
+	for _, field := range collection.Fields {
+		if isPresentable(field) && !field.GetHidden() {
+			return field
+		}
+	}
+
+	for _, name := range relationDisplayFields {
+		if field := collection.Fields.GetByName(name); field != nil && !field.GetHidden() {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// isPresentable reports whether the Dashboard UI shows the field for the records of its collection
+func isPresentable(field core.Field) bool {
+	switch f := field.(type) {
+	case *core.TextField:
+		return f.Presentable
+	case *core.EmailField:
+		return f.Presentable
+	case *core.SelectField:
+		return f.Presentable
+	case *core.NumberField:
+		return f.Presentable
+	case *core.URLField:
+		return f.Presentable
+	default:
+		return false
+	}
+}
+
+// isAuthEmail reports whether a field is the email of the records of an auth collection
+func isAuthEmail(collection *core.Collection, field core.Field) bool {
+	return collection.IsAuth() && field.GetName() == core.FieldNameEmail
+}
+
+// canViewEmail reports whether the requester can see the email of an auth record, as the records API
+// decides it: the record shows its email (emailVisibility), or the requester owns it or bypasses the rules
+func canViewEmail(record *core.Record, requestInfo *core.RequestInfo) bool {
+	if bypassesRules(requestInfo) || record.EmailVisibility() {
+		return true
+	}
+	return requestInfo.Auth != nil && requestInfo.Auth.Id == record.Id &&
+		requestInfo.Auth.Collection().Id == record.Collection().Id
+}
+
+// FindRequester returns the request info the export runs with: the auth record of the job owner,
+// or nil for jobs without an owner, which export without rules
+func FindRequester(app core.App, ownerId string) (*core.RequestInfo, error) {
+	if ownerId == "" {
+		return nil, nil
+	}
+
+	for _, collection := range []string{"users", core.CollectionNameSuperusers} {
+		if auth, err := app.FindRecordById(collection, ownerId); err == nil {
+			return &core.RequestInfo{Auth: auth, Method: "GET", Context: core.RequestInfoContextDefault}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: owner %s not found", ErrExportForbidden, ownerId)
+}
+
+// bypassesRules reports whether an export runs without the API rules (superusers and jobs without an owner)
+func bypassesRules(requestInfo *core.RequestInfo) bool {
+	return requestInfo == nil || requestInfo.HasSuperuserAuth()
+}
+
+// newRuleQuery starts a query on the collection restricted by an API rule of the requester,
+// the way the records API applies it. It fails with ErrExportForbidden when only superusers are allowed.
+func newRuleQuery(app core.App, collection *core.Collection, requestInfo *core.RequestInfo, rule *string) (*dbx.SelectQuery, *core.RecordFieldResolver, error) {
+	if !bypassesRules(requestInfo) && rule == nil {
+		return nil, nil, ErrExportForbidden
+	}
+
+	query := app.RecordQuery(collection)
+	resolver := core.NewRecordFieldResolver(app, collection, requestInfo, true)
+
+	if !bypassesRules(requestInfo) && *rule != "" {
+		expr, err := search.FilterData(*rule).BuildExpr(resolver)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid rule of collection %s: %w", collection.Name, err)
+		}
+		query.AndWhere(expr)
+	}
+
+	// hidden fields can be filtered and sorted on only by superusers
+	resolver.SetAllowHiddenFields(bypassesRules(requestInfo))
+
+	return query, resolver, nil
+}
+
+// newExportQuery returns the query of the records of the collection the requester can list, filtered
+// and sorted (newest first by default). The record ID ends the sort, so records sharing the sorted
+// values are always exported in the same order.
+func newExportQuery(app core.App, collection *core.Collection, requestInfo *core.RequestInfo, filter, sort string) (*dbx.SelectQuery, error) {
+	query, resolver, err := newRuleQuery(app, collection, requestInfo, collection.ListRule)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter != "" {
+		expr, err := search.FilterData(filter).BuildExpr(resolver)
+		if err != nil {
+			return nil, fmt.Errorf("invalid export filter: %w", err)
+		}
+		query.AndWhere(expr)
+	}
+
+	if sort == "" && collection.Fields.GetByName("created") != nil {
+		sort = "-created"
+	}
+	for _, sortField := range search.ParseSortFromString(sort) {
+		expr, err := sortField.BuildExpr(resolver)
+		if err != nil {
+			return nil, fmt.Errorf("invalid export sort: %w", err)
+		}
+		query.AndOrderBy(expr)
+	}
+	query.AndOrderBy("[[" + collection.Name + ".id]] ASC")
+
+	if err := resolver.UpdateQuery(query); err != nil {
+		return nil, err
+	}
+
+	return query, nil
+}
+
+// findExportIds returns the IDs of the records of an export query, in the order of the export
+func findExportIds(collection *core.Collection, query *dbx.SelectQuery) ([]string, error) {
+	// shallow clone; the DISTINCT of queries joining relations keeps each ID once
+	idQuery := *query
+
+	ids := []string{}
+	err := idQuery.Select("[[" + collection.Name + ".id]]").Column(&ids)
+
+	return ids, err
+}
+
+// load fetches the display values of the records related to a batch of exported records (single query).
+// Related records the requester cannot view are left out.
+func (r *relationColumn) load(app core.App, records []*core.Record, fieldName string, requestInfo *core.RequestInfo) error {
+	idSet := make(map[string]struct{})
+	for _, record := range records {
+		for _, id := range record.GetStringSlice(fieldName) {
+			if id != "" {
+				idSet[id] = struct{}{}
+			}
+		}
+	}
+
+	r.values = make(map[string]string, len(idSet))
+	if len(idSet) == 0 {
+		return nil
+	}
+
+	ids := make([]any, 0, len(idSet))
+	for id := range idSet {
+		ids = append(ids, id)
+	}
+
+	query, resolver, err := newRuleQuery(app, r.collection, requestInfo, r.collection.ViewRule)
+	if errors.Is(err, ErrExportForbidden) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	query.AndWhere(dbx.In(r.collection.Name+".id", ids...))
+	if err := resolver.UpdateQuery(query); err != nil {
+		return err
+	}
+
+	related := []*core.Record{}
+	if err := query.All(&related); err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", r.collection.Name, err)
+	}
+
+	for _, record := range related {
+		value := record.Id
+		if r.displayField != nil && (!isAuthEmail(r.collection, r.displayField) || canViewEmail(record, requestInfo)) {
+			value = cellText(fieldValue(record, r.displayField))
+		}
+		r.values[record.Id] = value
+	}
+
+	return nil
+}
+
+// exportStream writes the rows of an export in a format, one batch of records at a time
+type exportStream struct {
+	writer      Writer
+	columns     []*exportColumn
+	requestInfo *core.RequestInfo
+	row         []any
+}
+
+// newExportStream starts an export in a format, with a header row of the requested fields.
+// The emails of auth records are written only when the requester can see them.
+func newExportStream(format Format, w io.Writer, title string, columns []*exportColumn, requestInfo *core.RequestInfo) (*exportStream, error) {
+	writer := format.NewWriter(w, title)
+
+	headers := make([]string, 0, len(columns))
+	for _, column := range columns {
+		headers = append(headers, column.header)
+	}
+	if err := writer.WriteHeader(headers); err != nil {
+		return nil, err
+	}
+
+	return &exportStream{writer: writer, columns: columns, requestInfo: requestInfo, row: make([]any, len(columns))}, nil
+}
+
+// write writes a row per record; the relations of the columns must be loaded for the records
+func (s *exportStream) write(records []*core.Record) error {
+	for _, record := range records {
+		for i, column := range s.columns {
+			s.row[i] = column.value(record)
+			if column.email && !canViewEmail(record, s.requestInfo) {
+				s.row[i] = ""
+			}
+		}
+
+		if err := s.writer.WriteRow(s.row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// close ends the export file
+func (s *exportStream) close() error {
+	return s.writer.Close()
+}
+
+// value returns the exported value of the column for a record
+func (c *exportColumn) value(record *core.Record) any {
+	if c.relation == nil {
+		return fieldValue(record, c.field)
+	}
+
+	ids := record.GetStringSlice(c.field.GetName())
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if value, exists := c.relation.values[id]; exists {
+			values = append(values, value)
+		}
+	}
+
+	if !isMultiple(c.field) {
+		if len(values) == 0 {
+			return nil
+		}
+		return values[0]
+	}
+	return values
+}
+
+// fieldValue returns the value of a record field as a row value of a Writer: a bool, a float64,
+// a time.Time (nil when not set), a []string for multiple values, a json.RawMessage or a string
+func fieldValue(record *core.Record, field core.Field) any {
+	name := field.GetName()
+
+	switch field.(type) {
+	case *core.BoolField:
+		return record.GetBool(name)
+	case *core.NumberField:
+		return record.GetFloat(name)
+	case *core.DateField, *core.AutodateField:
+		date := record.GetDateTime(name)
+		if date.IsZero() {
+			return nil
+		}
+		return date.Time()
+	case *core.SelectField, *core.FileField, *core.RelationField:
+		values := slices.DeleteFunc(record.GetStringSlice(name), func(v string) bool { return v == "" })
+		if !isMultiple(field) {
+			if len(values) == 0 {
+				return nil
+			}
+			return values[0]
+		}
+		return values
+	case *core.JSONField:
+		raw, err := json.Marshal(record.Get(name))
+		if err != nil || string(raw) == "null" {
+			return nil
+		}
+		return json.RawMessage(raw)
+	default:
+		return record.GetString(name)
+	}
+}
+
+// isMultiple reports whether a field holds a list of values (multiple select, file or relation)
+func isMultiple(field core.Field) bool {
+	multiple, ok := field.(interface{ IsMultiple() bool })
+	return ok && multiple.IsMultiple()
+}
@@ -0,0 +1,316 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func newTestCollection() *core.Collection {
+	collection := core.NewBaseCollection("products")
+	collection.Fields.Add(
+		&core.TextField{Name: "name"},
+		&core.NumberField{Name: "price"},
+		&core.BoolField{Name: "active"},
+		&core.SelectField{Name: "tags", MaxSelect: 3, Values: []string{"new", "sale", "eco"}},
+		&core.JSONField{Name: "meta"},
+		&core.TextField{Name: "secret", Hidden: true},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	return collection
+}
+
+func TestResolveExportColumns(t *testing.T) {
+	collection := newTestCollection()
+
+	columns, err := resolveExportColumns(nil, collection, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	headers := []string{}
+	for _, column := range columns {
+		headers = append(headers, column.header)
+	}
+	if got := strings.Join(headers, ","); got != "id,name,price,active,tags,meta,created" {
+		t.Errorf("expected every visible field, got %s", got)
+	}
+
+	columns, err = resolveExportColumns(nil, collection, []string{"name", " price "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(columns) != 2 || columns[1].header != "price" {
+		t.Errorf("expected the requested fields, got %d columns", len(columns))
+	}
+
+	tests := []struct {
+		name   string
+		fields []string
+	}{
+		{"unknown field", []string{"missing"}},
+		{"hidden field", []string{"secret"}},
+		{"display field of a non relation", []string{"name.slug"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := resolveExportColumns(nil, collection, tt.fields); err == nil {
+				t.Errorf("expected an error for %v", tt.fields)
+			}
+		})
+	}
+}
+
+func TestRelationDisplayField(t *testing.T) {
+	presentable := core.NewBaseCollection("roles")
+	presentable.Fields.Add(
+		&core.TextField{Name: "name"},
+		&core.TextField{Name: "code", Presentable: true},
+	)
+	if field := relationDisplayField(presentable); field == nil || field.GetName() != "code" {
+		t.Errorf("expected the presentable field, got %v", field)
+	}
+
+	named := core.NewBaseCollection("permissions")
+	named.Fields.Add(
+		&core.TextField{Name: "description"},
+		&core.TextField{Name: "slug"},
+		&core.TextField{Name: "name"},
+	)
+	if field := relationDisplayField(named); field == nil || field.GetName() != "name" {
+		t.Errorf("expected the name field, got %v", field)
+	}
+
+	anonymous := core.NewBaseCollection("links")
+	anonymous.Fields.Add(&core.NumberField{Name: "weight"})
+	if field := relationDisplayField(anonymous); field != nil {
+		t.Errorf("expected no display field, got %s", field.GetName())
+	}
+}
+
+func TestExportStreamCSV(t *testing.T) {
+	collection := newTestCollection()
+	collection.Fields.Add(&core.RelationField{Name: "categories", MaxSelect: 5, CollectionId: "categories"})
+
+	created := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	record := core.NewRecord(collection)
+	record.Id = "p1"
+	record.Set("name", "Desk, oak")
+	record.Set("price", 129.5)
+	record.Set("active", true)
+	record.Set("tags", []string{"new", "eco"})
+	record.Set("meta", map[string]any{"size": "L"})
+	// autodate fields ignore Set, as their value is set on save
+	createdDate, _ := types.ParseDateTime(created)
+	record.SetRaw("created", createdDate)
+	record.Set("categories", []string{"c1", "c2", "c3"})
+
+	columns, err := resolveExportColumns(nil, collection, []string{"id", "name", "price", "active", "tags", "meta", "created"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	columns = append(columns, &exportColumn{
+		header: "categories",
+		field:  collection.Fields.GetByName("categories"),
+		relation: &relationColumn{
+			// c3 is left out, e.g. when the requester cannot view it
+			values: map[string]string{"c1": "Office", "c2": "Furniture"},
+		},
+	})
+
+	second := core.NewRecord(collection)
+	second.Id = "p2"
+	second.Set("name", "Lamp")
+
+	format, _ := GetFormat("")
+	var buf bytes.Buffer
+	stream, err := newExportStream(format, &buf, "products", columns, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// one batch at a time, as the export fetches them
+	for _, batch := range [][]*core.Record{{record}, {second}} {
+		if err := stream.write(batch); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := stream.close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected a header and two rows, got %d rows", len(rows))
+	}
+
+	expected := []string{"p1", "Desk, oak", "129.5", "true", "new; eco", `{"size":"L"}`, created.Format(time.RFC3339), "Office; Furniture"}
+	for i, value := range expected {
+		if rows[1][i] != value {
+			t.Errorf("column %s: expected %q, got %q", rows[0][i], value, rows[1][i])
+		}
+	}
+	if rows[2][0] != "p2" || rows[2][1] != "Lamp" {
+		t.Errorf("expected the second batch after the first, got %v", rows[2])
+	}
+}
+
+func TestExportStreamEmailVisibility(t *testing.T) {
+	users := core.NewAuthCollection("users")
+	users.Id = "_pb_users_auth_"
+
+	newUser := func(id string, visible bool) *core.Record {
+		record := core.NewRecord(users)
+		record.Id = id
+		record.SetEmail(id + "@example.com")
+		record.SetEmailVisibility(visible)
+		return record
+	}
+	records := []*core.Record{newUser("u1", false), newUser("u2", true), newUser("u3", false)}
+
+	columns, err := resolveExportColumns(nil, users, []string{"id", "email"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	superuser := core.NewRecord(core.NewAuthCollection(core.CollectionNameSuperusers))
+	superuser.Id = "s1"
+
+	tests := []struct {
+		name        string
+		requestInfo *core.RequestInfo
+		expected    []string
+	}{
+		{"owner", &core.RequestInfo{Auth: newUser("u1", false)}, []string{"u1@example.com", "u2@example.com", ""}},
+		{"other user", &core.RequestInfo{Auth: newUser("u4", false)}, []string{"", "u2@example.com", ""}},
+		{"superuser", &core.RequestInfo{Auth: superuser}, []string{"u1@example.com", "u2@example.com", "u3@example.com"}},
+		{"job without owner", nil, []string{"u1@example.com", "u2@example.com", "u3@example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, _ := GetFormat("csv")
+			var buf bytes.Buffer
+			stream, err := newExportStream(format, &buf, "users", columns, tt.requestInfo)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := stream.write(records); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := stream.close(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rows, err := csv.NewReader(&buf).ReadAll()
+			if err != nil || len(rows) != 4 {
+				t.Fatalf("expected a header and three rows, got %v (%v)", rows, err)
+			}
+			for i, email := range tt.expected {
+				if rows[i+1][1] != email {
+					t.Errorf("%s: expected %q, got %q", rows[i+1][0], email, rows[i+1][1])
+				}
+			}
+		})
+	}
+}
+
+func TestFieldValueEmpty(t *testing.T) {
+	collection := newTestCollection()
+	collection.Fields.Add(
+		&core.DateField{Name: "published"},
+		&core.SelectField{Name: "status", MaxSelect: 1, Values: []string{"draft"}},
+	)
+	record := core.NewRecord(collection)
+
+	for _, name := range []string{"published", "status", "meta"} {
+		if value := fieldValue(record, collection.Fields.GetByName(name)); value != nil {
+			t.Errorf("expected no %s value, got %#v", name, value)
+		}
+	}
+
+	if value, ok := fieldValue(record, collection.Fields.GetByName("tags")).([]string); !ok || len(value) != 0 {
+		t.Errorf("expected an empty list for a multiple select, got %#v", value)
+	}
+}
+
+func TestNewRuleQueryForbidden(t *testing.T) {
+	users := core.NewAuthCollection("users")
+	auth := core.NewRecord(users)
+	auth.Id = "u1"
+
+	collection := newTestCollection() // nil rules: superusers only
+
+	_, _, err := newRuleQuery(nil, collection, &core.RequestInfo{Auth: auth}, collection.ListRule)
+	if !errors.Is(err, ErrExportForbidden) {
+		t.Errorf("expected ErrExportForbidden, got %v", err)
+	}
+
+	if !bypassesRules(nil) {
+		t.Error("expected jobs without an owner to bypass the rules")
+	}
+	if bypassesRules(&core.RequestInfo{Auth: auth}) {
+		t.Error("expected users to be subject to the rules")
+	}
+}
+
+func TestExportBatchSize(t *testing.T) {
+	t.Setenv("EXPORT_BATCH_SIZE", "250")
+	if size := exportBatchSize(); size != 250 {
+		t.Errorf("expected 250, got %d", size)
+	}
+
+	t.Setenv("EXPORT_BATCH_SIZE", "0")
+	if size := exportBatchSize(); size != DefaultExportBatchSize {
+		t.Errorf("expected the default batch size, got %d", size)
+	}
+}
+
+func TestExportFileOptions(t *testing.T) {
+	expiryTests := []struct {
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"", 0, false},
+		{"72h", 72 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"30m", 0, true},
+		{"9000h", 0, true},
+		{"3d", 0, true},
+	}
+	for _, tt := range expiryTests {
+		expiry, err := parseResultExpiry(tt.value)
+		if (err != nil) != tt.wantErr || expiry != tt.expected {
+			t.Errorf("parseResultExpiry(%q) = %s, %v", tt.value, expiry, err)
+		}
+	}
+
+	for _, prefix := range []string{"", "users", "verified_users-2025"} {
+		if err := validateFilenamePrefix(prefix); err != nil {
+			t.Errorf("unexpected error for %q: %v", prefix, err)
+		}
+	}
+	for _, prefix := range []string{"../users", "_users", "users export", strings.Repeat("a", 101)} {
+		if err := validateFilenamePrefix(prefix); err == nil {
+			t.Errorf("expected an error for %q", prefix)
+		}
+	}
+
+	format, _ := GetFormat("xlsx")
+	now := time.Date(2025, 3, 1, 10, 4, 5, 0, time.UTC)
+	if name := exportFilename("", "users", format, now); name != "users_export_20250301_100405.xlsx" {
+		t.Errorf("unexpected default file name %s", name)
+	}
+	if name := exportFilename("verified", "users", format, now); name != "verified_20250301_100405.xlsx" {
+		t.Errorf("unexpected file name %s", name)
+	}
+}
@@ -1,75 +0,0 @@
-package export
-
-import (
-	"testing"
-
-	"ims-pocketbase-baas-starter/pkg/jobutils"
-)
-
-func TestHandleUserExport(t *testing.T) {
-	// This is a basic test structure
-	// In a real implementation, you would set up a test database
-	// and mock PocketBase app instance
-
-	payload := &jobutils.DataProcessingJobPayload{
-		Type: jobutils.JobTypeDataProcessing,
-		Data: jobutils.DataProcessingJobData{
-			Operation: jobutils.DataProcessingOperationExport,
-			Source:    jobutils.DataProcessingCollectionUsers,
-			Target:    "export_files",
-		},
-		Options: jobutils.DataProcessingJobOptions{
-			Timeout: 300, // 5 minutes
-		},
-	}
-
-	// Test payload validation
-	if payload.Type != jobutils.JobTypeDataProcessing {
-		t.Errorf("Expected job type %s, got %s", jobutils.JobTypeDataProcessing, payload.Type)
-	}
-
-	if payload.Data.Operation != jobutils.DataProcessingOperationExport {
-		t.Errorf("Expected operation %s, got %s", jobutils.DataProcessingOperationExport, payload.Data.Operation)
-	}
-
-	if payload.Data.Source != jobutils.DataProcessingCollectionUsers {
-		t.Errorf("Expected source %s, got %s", jobutils.DataProcessingCollectionUsers, payload.Data.Source)
-	}
-
-}
-
-func TestCSVHeaders(t *testing.T) {
-	expectedHeaders := []string{
-		"ID",
-		"Email",
-		"Name",
-		"Email Visibility",
-		"Verified",
-		"Is Active",
-		"Roles",
-		"Permissions",
-		"Created",
-		"Updated",
-	}
-
-	// This test verifies that our CSV headers are correctly defined
-	// In a real test, you would call convertUsersToCSV with test data
-	// and verify the headers match
-
-	if len(expectedHeaders) != 10 {
-		t.Errorf("Expected 10 CSV headers, got %d", len(expectedHeaders))
-	}
-
-	// Verify specific headers exist
-	headerMap := make(map[string]bool)
-	for _, header := range expectedHeaders {
-		headerMap[header] = true
-	}
-
-	requiredHeaders := []string{"ID", "Email", "Name", "Roles", "Permissions"}
-	for _, required := range requiredHeaders {
-		if !headerMap[required] {
-			t.Errorf("Required header %s not found in CSV headers", required)
-		}
-	}
-}
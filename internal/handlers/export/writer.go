@@ -0,0 +1,111 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/jobutils"
+)
+
+// Writer writes the rows of an export in a file format. Row values are nil, string, bool, float64,
+// time.Time, []string or json.RawMessage.
+type Writer interface {
+	// WriteHeader writes the column headers; it is called once, before the rows
+	WriteHeader(headers []string) error
+
+	// WriteRow writes the values of a row, in the order of the headers
+	WriteRow(values []any) error
+
+	// Close ends the file; it does not close the underlying io.Writer
+	Close() error
+}
+
+// Format is an export file format and the Writer that produces it
+type Format struct {
+	Name        string                                 // Target of the export payload (e.g. csv)
+	Extension   string                                 // File name extension, without the dot
+	ContentType string                                 // Content type stored with the export file
+	NewWriter   func(w io.Writer, title string) Writer // Creates a writer; title names the sheet or document
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]Format{}
+)
+
+func init() {
+	RegisterFormat(Format{Name: jobutils.DataProcessingFileCSV, Extension: "csv", ContentType: "text/csv", NewWriter: newCSVWriter})
+	RegisterFormat(Format{Name: jobutils.DataProcessingFileXLSX, Extension: "xlsx", ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", NewWriter: newXLSXWriter})
+	RegisterFormat(Format{Name: jobutils.DataProcessingFileJSON, Extension: "json", ContentType: "application/json", NewWriter: newJSONWriter})
+	RegisterFormat(Format{Name: jobutils.DataProcessingFileNDJSON, Extension: "ndjson", ContentType: "application/x-ndjson", NewWriter: newNDJSONWriter})
+	RegisterFormat(Format{Name: jobutils.DataProcessingFilePDF, Extension: "pdf", ContentType: "application/pdf", NewWriter: newPDFWriter})
+}
+
+// RegisterFormat adds an export format, or replaces the format with the same name
+func RegisterFormat(format Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	formats[format.Name] = format
+}
+
+// GetFormat returns the export format of a payload target (CSV when empty)
+func GetFormat(name string) (Format, error) {
+	if name == "" {
+		name = jobutils.DataProcessingFileCSV
+	}
+
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	format, ok := formats[strings.ToLower(name)]
+	if !ok {
+		return Format{}, fmt.Errorf("unsupported export format: %s (supported: %s)", name, strings.Join(formatNames(), ", "))
+	}
+	return format, nil
+}
+
+// FormatNames returns the names of the export formats, sorted
+func FormatNames() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	return formatNames()
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// cellText formats a row value as text, for formats without types (CSV, PDF)
+func cellText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case []string:
+		return strings.Join(v, "; ")
+	case json.RawMessage:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,43 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// csvWriter writes exports as CSV, with a header row
+type csvWriter struct {
+	writer *csv.Writer
+	row    []string
+}
+
+func newCSVWriter(w io.Writer, title string) Writer {
+	return &csvWriter{writer: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteHeader(headers []string) error {
+	c.row = make([]string, len(headers))
+	if err := c.writer.Write(headers); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	return nil
+}
+
+func (c *csvWriter) WriteRow(values []any) error {
+	for i, value := range values {
+		c.row[i] = cellText(value)
+	}
+	if err := c.writer.Write(c.row); err != nil {
+		return fmt.Errorf("failed to write record row: %w", err)
+	}
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	if err := c.writer.Error(); err != nil {
+		return fmt.Errorf("CSV writer error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// jsonWriter writes exports as a JSON array of objects keyed by the headers, or with lines set as
+// newline delimited JSON (one object per line)
+type jsonWriter struct {
+	w     io.Writer
+	keys  [][]byte // JSON encoded headers
+	lines bool
+	rows  int
+	buf   bytes.Buffer
+}
+
+func newJSONWriter(w io.Writer, title string) Writer {
+	return &jsonWriter{w: w}
+}
+
+func newNDJSONWriter(w io.Writer, title string) Writer {
+	return &jsonWriter{w: w, lines: true}
+}
+
+func (j *jsonWriter) WriteHeader(headers []string) error {
+	j.keys = make([][]byte, len(headers))
+	for i, header := range headers {
+		key, err := json.Marshal(header)
+		if err != nil {
+			return fmt.Errorf("failed to encode header %q: %w", header, err)
+		}
+		j.keys[i] = key
+	}
+
+	if !j.lines {
+		if _, err := io.WriteString(j.w, "["); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+	}
+	return nil
+}
+
+func (j *jsonWriter) WriteRow(values []any) error {
+	j.buf.Reset()
+
+	if !j.lines {
+		if j.rows > 0 {
+			j.buf.WriteByte(',')
+		}
+		j.buf.WriteByte('\n')
+	}
+
+	j.buf.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			j.buf.WriteByte(',')
+		}
+		j.buf.Write(j.keys[i])
+		j.buf.WriteByte(':')
+
+		encoded, err := jsonValue(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode value of %s: %w", j.keys[i], err)
+		}
+		j.buf.Write(encoded)
+	}
+	j.buf.WriteByte('}')
+
+	if j.lines {
+		j.buf.WriteByte('\n')
+	}
+
+	if _, err := j.w.Write(j.buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write record row: %w", err)
+	}
+	j.rows++
+	return nil
+}
+
+func (j *jsonWriter) Close() error {
+	if j.lines {
+		return nil
+	}
+
+	end := "\n]\n"
+	if j.rows == 0 {
+		end = "]\n"
+	}
+	if _, err := io.WriteString(j.w, end); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
+
+// jsonValue encodes a row value, with dates in RFC 3339
+func jsonValue(value any) ([]byte, error) {
+	if date, ok := value.(time.Time); ok {
+		value = date.Format(time.RFC3339)
+	}
+	return json.Marshal(value)
+}
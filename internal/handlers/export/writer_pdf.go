@@ -0,0 +1,214 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Layout of PDF exports: A4 landscape pages, in points
+const (
+	pdfPageWidth    = 842.0
+	pdfPageHeight   = 595.0
+	pdfMargin       = 36.0
+	pdfTitleSize    = 12.0
+	pdfFontSize     = 8.0
+	pdfRowHeight    = 12.0
+	pdfCellPadding  = 2.0
+	pdfAvgCharWidth = 0.55 // average Helvetica character width, in font size units
+)
+
+// Fixed PDF objects; pages are numbered from pdfFirstPageObject as they are written
+const (
+	pdfCatalogObject = iota + 1
+	pdfPagesObject
+	pdfFontObject
+	pdfBoldFontObject
+	pdfFirstPageObject
+)
+
+// pdfWriter writes exports as a simple table: the headers in bold on every page, one line per row,
+// and cells cut to the width of their column. Each page is written as soon as it is full, so only
+// the current page is kept in memory. Text uses the standard Helvetica fonts (Windows-1252
+// characters; others are shown as ?).
+type pdfWriter struct {
+	w       io.Writer
+	title   string
+	offset  int64
+	offsets map[int]int64 // object number -> offset in the file, for the cross-reference table
+	pages   []int         // page object numbers
+	next    int           // next free object number
+
+	headers []string
+	width   float64 // width of a column
+	page    bytes.Buffer
+	y       float64 // baseline of the next row on the current page
+	started bool
+}
+
+func newPDFWriter(w io.Writer, title string) Writer {
+	return &pdfWriter{w: w, title: title, offsets: map[int]int64{}, next: pdfFirstPageObject}
+}
+
+func (p *pdfWriter) WriteHeader(headers []string) error {
+	p.headers = headers
+	p.width = (pdfPageWidth - 2*pdfMargin) / float64(max(len(headers), 1))
+
+	if err := p.write("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"); err != nil {
+		return err
+	}
+	if err := p.writeObject(pdfFontObject, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"); err != nil {
+		return err
+	}
+	if err := p.writeObject(pdfBoldFontObject, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>"); err != nil {
+		return err
+	}
+
+	p.started = true
+	p.startPage()
+	return nil
+}
+
+func (p *pdfWriter) WriteRow(values []any) error {
+	if p.y < pdfMargin+pdfRowHeight {
+		if err := p.flushPage(); err != nil {
+			return err
+		}
+		p.startPage()
+	}
+
+	texts := make([]string, len(values))
+	for i, value := range values {
+		texts[i] = cellText(value)
+	}
+	p.writeCells("F1", texts)
+	return nil
+}
+
+func (p *pdfWriter) Close() error {
+	if !p.started {
+		if err := p.WriteHeader(nil); err != nil {
+			return err
+		}
+	}
+
+	if err := p.flushPage(); err != nil {
+		return err
+	}
+
+	kids := make([]string, len(p.pages))
+	for i, page := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	if err := p.writeObject(pdfPagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages))); err != nil {
+		return err
+	}
+	if err := p.writeObject(pdfCatalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPagesObject)); err != nil {
+		return err
+	}
+
+	xrefOffset := p.offset
+	var xref strings.Builder
+	fmt.Fprintf(&xref, "xref\n0 %d\n0000000000 65535 f \n", p.next)
+	for object := 1; object < p.next; object++ {
+		fmt.Fprintf(&xref, "%010d 00000 n \n", p.offsets[object])
+	}
+	fmt.Fprintf(&xref, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", p.next, pdfCatalogObject, xrefOffset)
+
+	return p.write(xref.String())
+}
+
+// startPage begins a page with the title and the headers
+func (p *pdfWriter) startPage() {
+	p.page.Reset()
+	p.y = pdfPageHeight - pdfMargin - pdfTitleSize
+
+	title := fmt.Sprintf("%s - page %d", p.title, len(p.pages)+1)
+	fmt.Fprintf(&p.page, "BT /F2 %.1f Tf %.2f %.2f Td (%s) Tj ET\n", pdfTitleSize, pdfMargin, p.y, pdfText(title))
+	p.y -= 2 * pdfRowHeight
+
+	p.writeCells("F2", p.headers)
+	lineY := p.y + pdfRowHeight - pdfFontSize/2
+	fmt.Fprintf(&p.page, "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, lineY, pdfPageWidth-pdfMargin, lineY)
+}
+
+// writeCells writes a line of cells on the current page and moves to the next line
+func (p *pdfWriter) writeCells(font string, texts []string) {
+	maxChars := int((p.width - 2*pdfCellPadding) / (pdfFontSize * pdfAvgCharWidth))
+
+	for i, text := range texts {
+		text = strings.Join(strings.Fields(text), " ")
+		if text == "" {
+			continue
+		}
+		if len([]rune(text)) > maxChars {
+			text = truncateRunes(text, max(maxChars-3, 0)) + "..."
+		}
+
+		x := pdfMargin + float64(i)*p.width + pdfCellPadding
+		fmt.Fprintf(&p.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, pdfFontSize, x, p.y, pdfText(text))
+	}
+
+	p.y -= pdfRowHeight
+}
+
+// flushPage writes the current page and its content stream
+func (p *pdfWriter) flushPage() error {
+	contentObject := p.next
+	pageObject := p.next + 1
+	p.next += 2
+
+	if err := p.writeObject(contentObject, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.page.Len(), p.page.String())); err != nil {
+		return err
+	}
+
+	page := fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPagesObject, pdfPageWidth, pdfPageHeight, pdfFontObject, pdfBoldFontObject, contentObject)
+	if err := p.writeObject(pageObject, page); err != nil {
+		return err
+	}
+
+	p.pages = append(p.pages, pageObject)
+	p.page.Reset()
+	return nil
+}
+
+// writeObject writes an indirect object and records its offset
+func (p *pdfWriter) writeObject(object int, body string) error {
+	p.offsets[object] = p.offset
+	return p.write(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", object, body))
+}
+
+func (p *pdfWriter) write(s string) error {
+	n, err := io.WriteString(p.w, s)
+	p.offset += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return nil
+}
+
+// pdfText encodes text for a PDF string literal in the WinAnsi encoding of the standard fonts
+func pdfText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		c, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok {
+			c = '?'
+		}
+
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20:
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
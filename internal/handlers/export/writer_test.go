@@ -0,0 +1,230 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testHeaders = []string{"name", "price", "active", "tags", "meta", "created", "note"}
+
+func testRows() [][]any {
+	created := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	return [][]any{
+		{"Desk, oak", 129.5, true, []string{"new", "eco"}, json.RawMessage(`{"size":"L"}`), created, nil},
+		{"Chair <B&W>", 45.0, false, []string{}, nil, nil, "Café (outdoor) \\ 北京"},
+	}
+}
+
+func writeTestExport(t *testing.T, name string, rows [][]any) []byte {
+	t.Helper()
+
+	format, err := GetFormat(name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	writer := format.NewWriter(&buf, "products")
+	if err := writer.WriteHeader(testHeaders); err != nil {
+		t.Fatalf("WriteHeader failed: %v", err)
+	}
+	for _, row := range rows {
+		if err := writer.WriteRow(row); err != nil {
+			t.Fatalf("WriteRow failed: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestGetFormat(t *testing.T) {
+	for _, name := range []string{"", "csv", "XLSX", "json", "ndjson", "pdf"} {
+		format, err := GetFormat(name)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", name, err)
+			continue
+		}
+		if format.ContentType == "" || format.Extension == "" || format.NewWriter == nil {
+			t.Errorf("incomplete format %q: %+v", name, format)
+		}
+	}
+
+	if _, err := GetFormat("docx"); err == nil || !strings.Contains(err.Error(), "csv, json, ndjson, pdf, xlsx") {
+		t.Errorf("expected an error listing the formats, got %v", err)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	data := writeTestExport(t, "csv", testRows())
+
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+
+	expected := []string{"Desk, oak", "129.5", "true", "new; eco", `{"size":"L"}`, "2025-03-01T10:00:00Z", ""}
+	if strings.Join(rows[1], "|") != strings.Join(expected, "|") {
+		t.Errorf("expected %v, got %v", expected, rows[1])
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	data := writeTestExport(t, "json", testRows())
+
+	var rows []map[string]any
+	if err := json.Unmarshal(data, &rows); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+
+	first := rows[0]
+	if first["price"] != 129.5 || first["active"] != true || first["created"] != "2025-03-01T10:00:00Z" {
+		t.Errorf("expected typed values, got %v", first)
+	}
+	if meta, ok := first["meta"].(map[string]any); !ok || meta["size"] != "L" {
+		t.Errorf("expected the JSON field inline, got %v", first["meta"])
+	}
+	if tags, ok := first["tags"].([]any); !ok || len(tags) != 2 {
+		t.Errorf("expected a tags array, got %v", first["tags"])
+	}
+	if rows[1]["note"] != "Café (outdoor) \\ 北京" || rows[1]["meta"] != nil {
+		t.Errorf("unexpected second row: %v", rows[1])
+	}
+
+	if empty := writeTestExport(t, "json", nil); strings.TrimSpace(string(empty)) != "[]" {
+		t.Errorf("expected an empty array, got %q", empty)
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	data := writeTestExport(t, "ndjson", testRows())
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per row, got %d", len(lines))
+	}
+	for _, line := range lines {
+		var row map[string]any
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			t.Errorf("invalid line %q: %v", line, err)
+		}
+	}
+	if !strings.HasPrefix(lines[0], `{"name":"Desk, oak","price":129.5,`) {
+		t.Errorf("expected the columns in header order, got %s", lines[0])
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	data := writeTestExport(t, "xlsx", testRows())
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("invalid XLSX archive: %v", err)
+	}
+
+	parts := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(reader)
+		reader.Close()
+		parts[file.Name] = string(content)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+	if archive.File[0].Name != "xl/worksheets/sheet1.xml" {
+		t.Errorf("expected the sheet first, got %s", archive.File[0].Name)
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, cell := range []string{
+		`<c r="A1" t="inlineStr"><is><t xml:space="preserve">name</t></is></c>`,
+		`<c r="B2"><v>129.5</v></c>`,
+		`<c r="C2" t="b"><v>1</v></c>`,
+		`<c r="A3" t="inlineStr"><is><t xml:space="preserve">Chair &lt;B&amp;W&gt;</t></is></c>`,
+	} {
+		if !strings.Contains(sheet, cell) {
+			t.Errorf("expected the sheet to contain %s", cell)
+		}
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `name="products"`) {
+		t.Errorf("expected the sheet to be named after the title: %s", parts["xl/workbook.xml"])
+	}
+}
+
+func TestXLSXNames(t *testing.T) {
+	columns := map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"}
+	for index, name := range columns {
+		if got := xlsxColumnName(index); got != name {
+			t.Errorf("column %d: expected %s, got %s", index, name, got)
+		}
+	}
+
+	if got := xlsxSheetName("orders/2025: [archived] and more than thirty one characters"); got != "orders_2025_ _archived_ and mor" {
+		t.Errorf("unexpected sheet name %q", got)
+	}
+	if got := xlsxSheetName(""); got != "Export" {
+		t.Errorf("expected a default sheet name, got %q", got)
+	}
+}
+
+func TestPDFWriter(t *testing.T) {
+	rows := testRows()
+	for i := 0; i < 100; i++ {
+		rows = append(rows, []any{fmt.Sprintf("Item %d", i), float64(i), true, nil, nil, nil, nil})
+	}
+
+	data := writeTestExport(t, "pdf", rows)
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("expected a PDF document")
+	}
+
+	// every cross-reference entry points to its object
+	start := bytes.LastIndex(data, []byte("startxref\n"))
+	xrefOffset, err := strconv.Atoi(strings.Fields(string(data[start+len("startxref\n"):]))[0])
+	if err != nil || !bytes.HasPrefix(data[xrefOffset:], []byte("xref\n")) {
+		t.Fatalf("invalid startxref offset: %v", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data[xrefOffset:]))
+	scanner.Scan() // xref
+	scanner.Scan() // 0 N
+	size, _ := strconv.Atoi(strings.Fields(scanner.Text())[1])
+	scanner.Scan() // free entry
+	for object := 1; object < size; object++ {
+		scanner.Scan()
+		offset, _ := strconv.Atoi(strings.Fields(scanner.Text())[0])
+		if !bytes.HasPrefix(data[offset:], []byte(fmt.Sprintf("%d 0 obj\n", object))) {
+			t.Errorf("object %d is not at offset %d", object, offset)
+		}
+	}
+
+	pages := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(data)
+	if pages == nil || string(pages[1]) == "1" {
+		t.Errorf("expected the rows to span several pages")
+	}
+	if !bytes.Contains(data, []byte("(Caf\xe9 \\(outdoor\\) \\\\ ??)")) {
+		t.Error("expected escaped WinAnsi text")
+	}
+}
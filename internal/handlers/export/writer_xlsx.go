@@ -0,0 +1,178 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// xlsxMaxCellLength is the maximum number of characters of a spreadsheet cell
+const xlsxMaxCellLength = 32767
+
+// xlsxMaxSheetNameLength is the maximum length of a worksheet name
+const xlsxMaxSheetNameLength = 31
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+const xlsxSheetEnd = `</sheetData></worksheet>`
+
+// xlsxWriter writes exports as a single sheet Office Open XML workbook. The sheet is streamed into
+// the archive as rows are written, with inline strings, so rows are not kept in memory.
+type xlsxWriter struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	title   string
+	row     int
+}
+
+func newXLSXWriter(w io.Writer, title string) Writer {
+	return &xlsxWriter{archive: zip.NewWriter(w), title: title}
+}
+
+func (x *xlsxWriter) WriteHeader(headers []string) error {
+	// the sheet comes first, so the file is recognized as a workbook from its first bytes
+	sheet, err := x.archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return fmt.Errorf("failed to create XLSX sheet: %w", err)
+	}
+	x.sheet = bufio.NewWriter(sheet)
+
+	if _, err := x.sheet.WriteString(xlsxSheetStart); err != nil {
+		return fmt.Errorf("failed to write XLSX sheet: %w", err)
+	}
+
+	values := make([]any, len(headers))
+	for i, header := range headers {
+		values[i] = header
+	}
+	return x.WriteRow(values)
+}
+
+func (x *xlsxWriter) WriteRow(values []any) error {
+	x.row++
+	rowRef := strconv.Itoa(x.row)
+
+	var b strings.Builder
+	b.WriteString(`<row r="` + rowRef + `">`)
+	for i, value := range values {
+		ref := xlsxColumnName(i) + rowRef
+
+		switch v := value.(type) {
+		case nil:
+			continue
+		case bool:
+			flag := "0"
+			if v {
+				flag = "1"
+			}
+			b.WriteString(`<c r="` + ref + `" t="b"><v>` + flag + `</v></c>`)
+		case float64:
+			b.WriteString(`<c r="` + ref + `"><v>` + strconv.FormatFloat(v, 'f', -1, 64) + `</v></c>`)
+		default:
+			text := cellText(v)
+			if text == "" {
+				continue
+			}
+			b.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+			xml.EscapeText(&b, []byte(truncateRunes(text, xlsxMaxCellLength)))
+			b.WriteString(`</t></is></c>`)
+		}
+	}
+	b.WriteString(`</row>`)
+
+	if _, err := x.sheet.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to write record row: %w", err)
+	}
+	return nil
+}
+
+func (x *xlsxWriter) Close() error {
+	if x.sheet == nil {
+		if err := x.WriteHeader(nil); err != nil {
+			return err
+		}
+	}
+
+	if _, err := x.sheet.WriteString(xlsxSheetEnd); err != nil {
+		return fmt.Errorf("failed to write XLSX sheet: %w", err)
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return fmt.Errorf("failed to write XLSX sheet: %w", err)
+	}
+
+	var sheetName strings.Builder
+	xml.EscapeText(&sheetName, []byte(xlsxSheetName(x.title)))
+
+	parts := []struct{ name, content string }{
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, sheetName.String())},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"_rels/.rels", xlsxRootRels},
+		{"[Content_Types].xml", xlsxContentTypes},
+	}
+	for _, part := range parts {
+		w, err := x.archive.Create(part.name)
+		if err != nil {
+			return fmt.Errorf("failed to create XLSX part %s: %w", part.name, err)
+		}
+		if _, err := io.WriteString(w, part.content); err != nil {
+			return fmt.Errorf("failed to write XLSX part %s: %w", part.name, err)
+		}
+	}
+
+	if err := x.archive.Close(); err != nil {
+		return fmt.Errorf("failed to close XLSX archive: %w", err)
+	}
+	return nil
+}
+
+// xlsxColumnName returns the letters of a zero based column index (A, B, ..., Z, AA, ...)
+func xlsxColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// xlsxSheetName returns a valid worksheet name for a title
+func xlsxSheetName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, title)
+
+	name = truncateRunes(strings.Trim(name, "'"), xlsxMaxSheetNameLength)
+	if name == "" {
+		return "Export"
+	}
+	return name
+}
+
+// truncateRunes shortens text to at most max characters
+func truncateRunes(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	return string([]rune(text)[:max])
+}
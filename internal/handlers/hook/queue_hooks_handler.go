@@ -0,0 +1,44 @@
+package hook
+
+import (
+	"ims-pocketbase-baas-starter/internal/jobs"
+	"ims-pocketbase-baas-starter/pkg/jobutils"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// HandleQueueJobDefaults fills in lifecycle defaults for jobs that are queued without them
+// (e.g. records created through the collection API or the admin UI)
+func HandleQueueJobDefaults(e *core.RecordEvent) error {
+	if e.Record.GetString("status") == "" {
+		e.Record.Set("status", jobutils.JobStatusQueued)
+	}
+
+	if e.Record.GetString("queue") == "" {
+		e.Record.Set("queue", jobutils.DefaultQueueName)
+	}
+
+	if e.Record.GetInt("max_attempts") <= 0 {
+		e.Record.Set("max_attempts", jobutils.GetMaxAttempts(e.Record))
+	}
+
+	return e.Next()
+}
+
+// HandleQueueJobDispatch wakes the job dispatcher when a job is queued, so it is picked up
+// without waiting for the dispatcher's fallback poll
+func HandleQueueJobDispatch(e *core.RecordEvent) error {
+	if e.Record.GetString("status") == jobutils.JobStatusQueued {
+		jobs.GetJobManager().NotifyJobQueued(e.Record.GetString("queue"))
+	}
+
+	return e.Next()
+}
+
+// HandleQueueJobStatusPush pushes the new state of a saved job to the realtime clients of its owner.
+// Progress updates and claims bypass the record hooks and are published by the job processor.
+func HandleQueueJobStatusPush(e *core.RecordEvent) error {
+	jobutils.PublishJobStatus(e.App, e.Record)
+
+	return e.Next()
+}
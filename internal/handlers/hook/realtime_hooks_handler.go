@@ -1,6 +1,9 @@
 package hook
 
 import (
+	"slices"
+
+	"ims-pocketbase-baas-starter/pkg/jobutils"
 	log "ims-pocketbase-baas-starter/pkg/logger"
 
 	"github.com/pocketbase/pocketbase/core"
@@ -16,8 +19,12 @@ func HandleRealtimeConnect(e *core.RealtimeConnectRequestEvent) error {
 	return e.Next()
 }
 
-// HandleRealtimeSubscribe handles realtime subscription events
+// HandleRealtimeSubscribe handles realtime subscription events.
+// Job status topics are only delivered to authenticated users, so guests' job subscriptions are dropped.
 func HandleRealtimeSubscribe(e *core.RealtimeSubscribeRequestEvent) error {
+	if e.Auth == nil {
+		e.Subscriptions = slices.DeleteFunc(e.Subscriptions, jobutils.IsJobsRealtimeTopic)
+	}
 
 	log.Debug("Realtime subscription created",
 		"client_id", e.Client.Id(),
@@ -0,0 +1,64 @@
+package hook
+
+import (
+	"errors"
+	"time"
+
+	"ims-pocketbase-baas-starter/internal/jobs"
+	"ims-pocketbase-baas-starter/pkg/jobutils"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// HandleScheduledJobValidate rejects schedules that cannot run (invalid cron expression,
+// timezone or payload for their job type) and sets their next run
+func HandleScheduledJobValidate(e *core.RecordEvent) error {
+	// the field validation (required fields, JSON payload) runs first
+	if err := e.Next(); err != nil {
+		return err
+	}
+
+	job, err := jobutils.ParseScheduledJob(e.Record)
+	if err != nil {
+		return scheduleValidationError(err)
+	}
+
+	next, err := jobutils.ValidateScheduledJob(job, time.Now())
+	if err != nil {
+		return scheduleValidationError(err)
+	}
+
+	if next.IsZero() {
+		e.Record.Set("next_run_at", "")
+	} else {
+		e.Record.Set("next_run_at", next)
+	}
+
+	return nil
+}
+
+// scheduleValidationError reports a schedule error on the field it comes from
+func scheduleValidationError(err error) error {
+	field := "payload"
+	switch {
+	case errors.Is(err, jobutils.ErrInvalidCronExpr):
+		field = "cron_expr"
+	case errors.Is(err, jobutils.ErrInvalidTimezone):
+		field = "timezone"
+	}
+
+	return validation.Errors{field: validation.NewError("validation_invalid_schedule", err.Error())}
+}
+
+// HandleScheduledJobReload reloads the scheduler after a schedule was created, updated or deleted
+func HandleScheduledJobReload(e *core.RecordEvent) error {
+	if scheduler := jobs.GetJobManager().GetScheduler(); scheduler != nil {
+		if err := scheduler.Reload(); err != nil {
+			log.Error("Failed to reload scheduled jobs", "schedule_id", e.Record.Id, "error", err)
+		}
+	}
+
+	return e.Next()
+}
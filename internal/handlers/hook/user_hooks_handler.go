@@ -1,7 +1,6 @@
 package hook
 
 import (
-	"encoding/json"
 	"fmt"
 	"time"
 
@@ -13,6 +12,9 @@ import (
 	"github.com/pocketbase/pocketbase/core"
 )
 
+// welcomeEmailUniqueWindow is how long a sent welcome email prevents another one for the same user
+const welcomeEmailUniqueWindow = 24 * time.Hour
+
 // HandleUserWelcomeEmail handles sending a welcome email to new users
 func HandleUserWelcomeEmail(e *core.RecordEvent) error {
 	appName := common.GetEnv("APP_NAME", "N/A")
@@ -51,26 +53,15 @@ func HandleUserWelcomeEmail(e *core.RecordEvent) error {
 		},
 	}
 
-	collection, err := e.App.FindCollectionByNameOrId("queues")
-	if err != nil {
-		log.Error("Failed to find queues collection", "error", err)
-		return err
-	}
-
-	jobRecord := core.NewRecord(collection)
-	jobRecord.Set("name", fmt.Sprintf("Welcome email for %s", email))
-	jobRecord.Set("description", fmt.Sprintf("Send welcome email to new user %s", email))
-
-	// Convert payload to JSON
-	payloadBytes, err := json.Marshal(payload)
+	job, err := jobutils.Enqueue(e.App, payload,
+		jobutils.WithName(fmt.Sprintf("Welcome email for %s", email)),
+		jobutils.WithDescription(fmt.Sprintf("Send welcome email to new user %s", email)),
+		jobutils.WithQueue(jobutils.QueueEmails),
+		jobutils.WithMaxAttempts(emailMaxAttempts(payload.Options.RetryCount)),
+		// the hook may fire more than once for the same user
+		jobutils.WithUniqueKey("welcome-email:"+e.Record.Id, welcomeEmailUniqueWindow),
+	)
 	if err != nil {
-		log.Error("Failed to marshal email payload", "error", err)
-		return err
-	}
-	jobRecord.Set("payload", string(payloadBytes))
-	jobRecord.Set("attempts", 0)
-
-	if err := e.App.Save(jobRecord); err != nil {
 		log.Error("Failed to queue welcome email job", "error", err)
 		return err
 	}
@@ -78,11 +69,21 @@ func HandleUserWelcomeEmail(e *core.RecordEvent) error {
 	log.Info("Welcome email job queued successfully",
 		"user_id", e.Record.Id,
 		"email", email,
-		"job_id", jobRecord.Id)
+		"job_id", job.ID,
+		"duplicate", job.Existing)
 
 	return e.Next()
 }
 
+// emailMaxAttempts converts the retries of an email job into its attempt budget,
+// so a retry count of 0 sends the email once
+func emailMaxAttempts(retryCount int) int {
+	if retryCount < 0 {
+		retryCount = 0
+	}
+	return retryCount + 1
+}
+
 // HandleUserCreateSettings generate default user settings
 func HandleUserCreateSettings(e *core.RecordEvent) error {
 
@@ -30,6 +30,27 @@ func TestHandleUserWelcomeEmail(t *testing.T) {
 	}
 }
 
+func TestEmailMaxAttempts(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryCount int
+		expected   int
+	}{
+		{name: "no retries", retryCount: 0, expected: 1},
+		{name: "one retry", retryCount: 1, expected: 2},
+		{name: "default retries", retryCount: 3, expected: 4},
+		{name: "negative retries", retryCount: -1, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := emailMaxAttempts(tt.retryCount); got != tt.expected {
+				t.Errorf("expected %d attempts, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
 func TestHandleUserCreateSettings(t *testing.T) {
 	app := pocketbase.New()
 
@@ -1,9 +1,11 @@
 package jobs
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"ims-pocketbase-baas-starter/internal/handlers/dataimport"
 	"ims-pocketbase-baas-starter/internal/handlers/export"
 	"ims-pocketbase-baas-starter/pkg/cronutils"
 	"ims-pocketbase-baas-starter/pkg/jobutils"
@@ -24,8 +26,22 @@ func NewDataProcessingJobHandler(app *pocketbase.PocketBase) *DataProcessingJobH
 	}
 }
 
-// Handle processes a data processing job using typed payload structures
+// dataProcessingJobTimeout is the default deadline of a data processing job without options.timeout
+const dataProcessingJobTimeout = 10 * time.Minute
+
+// Handle processes a data processing job without a deadline
 func (h *DataProcessingJobHandler) Handle(ctx *cronutils.CronExecutionContext, job *jobutils.JobData) error {
+	return h.HandleContext(context.Background(), ctx, job)
+}
+
+// DefaultTimeout returns the deadline of data processing jobs that do not set options.timeout
+func (h *DataProcessingJobHandler) DefaultTimeout() time.Duration {
+	return dataProcessingJobTimeout
+}
+
+// HandleContext processes a data processing job using typed payload structures.
+// Operations stop early once the job deadline has passed or the app is shutting down.
+func (h *DataProcessingJobHandler) HandleContext(jobCtx context.Context, ctx *cronutils.CronExecutionContext, job *jobutils.JobData) error {
 	ctx.LogStart(fmt.Sprintf("Processing data processing job: %s", job.ID))
 
 	dataPayload, err := jobutils.ParseDataProcessingJobPayload(job)
@@ -48,13 +64,13 @@ func (h *DataProcessingJobHandler) Handle(ctx *cronutils.CronExecutionContext, j
 	// Handle different operation types using typed data
 	switch dataPayload.Data.Operation {
 	case jobutils.DataProcessingOperationTransform:
-		return h.handleTransformOperation(ctx, dataPayload)
+		return h.handleTransformOperation(jobCtx, ctx, job, dataPayload)
 	case jobutils.DataProcessingOperationAggregate:
-		return h.handleAggregateOperation(ctx, dataPayload)
+		return h.handleAggregateOperation(jobCtx, ctx, job, dataPayload)
 	case jobutils.DataProcessingOperationExport:
-		return h.handleExportOperation(ctx, job, dataPayload)
+		return h.handleExportOperation(jobCtx, ctx, job, dataPayload)
 	case jobutils.DataProcessingOperationImport:
-		return h.handleImportOperation(ctx, dataPayload)
+		return h.handleImportOperation(jobCtx, ctx, job, dataPayload)
 	default:
 		return fmt.Errorf("unsupported data processing operation: %s", dataPayload.Data.Operation)
 	}
@@ -65,6 +81,31 @@ func (h *DataProcessingJobHandler) GetJobType() string {
 	return jobutils.JobTypeDataProcessing
 }
 
+// ValidatePayload validates a data processing job payload before it is queued
+func (h *DataProcessingJobHandler) ValidatePayload(job *jobutils.JobData) error {
+	dataPayload, err := jobutils.ParseDataProcessingJobPayload(job)
+	if err != nil {
+		return err
+	}
+
+	if err := h.validateDataProcessingPayload(dataPayload); err != nil {
+		return err
+	}
+
+	switch dataPayload.Data.Operation {
+	case jobutils.DataProcessingOperationExport:
+		requestInfo, err := export.FindRequester(h.app, job.OwnerID)
+		if err != nil {
+			return err
+		}
+		return export.ValidateExportPayload(h.app, dataPayload, requestInfo)
+	case jobutils.DataProcessingOperationImport:
+		return dataimport.ValidateImportPayload(h.app, dataPayload)
+	}
+
+	return nil
+}
+
 // validateDataProcessingPayload validates the typed data processing job payload (additional handler-specific validation)
 func (h *DataProcessingJobHandler) validateDataProcessingPayload(payload *jobutils.DataProcessingJobPayload) error {
 	// Validate job type matches what this handler expects
@@ -98,11 +139,14 @@ func (h *DataProcessingJobHandler) validateDataProcessingPayload(payload *jobuti
 }
 
 // handleTransformOperation handles data transformation operations using typed payload
-func (h *DataProcessingJobHandler) handleTransformOperation(ctx *cronutils.CronExecutionContext, payload *jobutils.DataProcessingJobPayload) error {
+func (h *DataProcessingJobHandler) handleTransformOperation(jobCtx context.Context, ctx *cronutils.CronExecutionContext, job *jobutils.JobData, payload *jobutils.DataProcessingJobPayload) error {
 	ctx.LogDebug(payload.Data, "Handling transform operation")
+	reportProgress(job.ID, 0, "Transforming data from "+payload.Data.Source)
 
 	// Simulate processing time
-	time.Sleep(150 * time.Millisecond)
+	if err := sleepContext(jobCtx, 150*time.Millisecond); err != nil {
+		return fmt.Errorf("transform operation interrupted: %w", err)
+	}
 
 	// Create result
 	result := &jobutils.DataProcessingResult{
@@ -115,6 +159,7 @@ func (h *DataProcessingJobHandler) handleTransformOperation(ctx *cronutils.CronE
 	}
 
 	ctx.LogDebug(result, "Transform operation result")
+	reportResult(job.ID, result)
 
 	// Placeholder: In a real implementation, this would:
 	// 1. Load source data from payload.Data.Source
@@ -126,11 +171,14 @@ func (h *DataProcessingJobHandler) handleTransformOperation(ctx *cronutils.CronE
 }
 
 // handleAggregateOperation handles data aggregation operations using typed payload
-func (h *DataProcessingJobHandler) handleAggregateOperation(ctx *cronutils.CronExecutionContext, payload *jobutils.DataProcessingJobPayload) error {
+func (h *DataProcessingJobHandler) handleAggregateOperation(jobCtx context.Context, ctx *cronutils.CronExecutionContext, job *jobutils.JobData, payload *jobutils.DataProcessingJobPayload) error {
 	ctx.LogDebug(payload.Data, "Handling aggregate operation")
+	reportProgress(job.ID, 0, "Aggregating data from "+payload.Data.Source)
 
 	// Simulate processing time
-	time.Sleep(200 * time.Millisecond)
+	if err := sleepContext(jobCtx, 200*time.Millisecond); err != nil {
+		return fmt.Errorf("aggregate operation interrupted: %w", err)
+	}
 
 	// Create result
 	result := &jobutils.DataProcessingResult{
@@ -143,6 +191,7 @@ func (h *DataProcessingJobHandler) handleAggregateOperation(ctx *cronutils.CronE
 	}
 
 	ctx.LogDebug(result, "Aggregate operation result")
+	reportResult(job.ID, result)
 
 	// Placeholder: In a real implementation, this would:
 	// 1. Query source data from payload.Data.Source
@@ -153,18 +202,12 @@ func (h *DataProcessingJobHandler) handleAggregateOperation(ctx *cronutils.CronE
 	return nil
 }
 
-// handleExportOperation handles data export operations using typed payload
-func (h *DataProcessingJobHandler) handleExportOperation(ctx *cronutils.CronExecutionContext, job *jobutils.JobData, payload *jobutils.DataProcessingJobPayload) error {
-
-	switch payload.Data.Source {
-	case jobutils.DataProcessingCollectionUsers:
-		err := export.HandleUserExport(h.app, job.ID, payload)
-		if err != nil {
-			return err
-		}
-	default:
-		return fmt.Errorf("unsupported data processing source: %s", payload.Data.Source)
+// handleExportOperation exports the collection in payload.Data.Source using typed payload
+func (h *DataProcessingJobHandler) handleExportOperation(jobCtx context.Context, ctx *cronutils.CronExecutionContext, job *jobutils.JobData, payload *jobutils.DataProcessingJobPayload) error {
+	ctx.LogDebug(payload.Data, "Handling export operation")
 
+	if err := export.HandleCollectionExport(jobCtx, h.app, job, payload); err != nil {
+		return err
 	}
 
 	log.Info("Export operation completed", "source", payload.Data.Source, "target", payload.Data.Target)
@@ -172,30 +215,29 @@ func (h *DataProcessingJobHandler) handleExportOperation(ctx *cronutils.CronExec
 	return nil
 }
 
-// handleImportOperation handles data import operations using typed payload
-func (h *DataProcessingJobHandler) handleImportOperation(ctx *cronutils.CronExecutionContext, payload *jobutils.DataProcessingJobPayload) error {
+// handleImportOperation imports the uploaded file in payload.Data.Source into the collection in
+// payload.Data.Target using typed payload
+func (h *DataProcessingJobHandler) handleImportOperation(jobCtx context.Context, ctx *cronutils.CronExecutionContext, job *jobutils.JobData, payload *jobutils.DataProcessingJobPayload) error {
 	ctx.LogDebug(payload.Data, "Handling import operation")
 
-	// Simulate processing time
-	time.Sleep(250 * time.Millisecond)
-
-	// Create result
-	result := &jobutils.DataProcessingResult{
-		BaseJobResultData: jobutils.BaseJobResultData{
-			Message:   "Import operation completed successfully",
-			Timestamp: time.Now(),
-		},
-		ProcessedRecords: 750, // placeholder count
-		OutputLocation:   payload.Data.Target,
+	if err := dataimport.HandleCollectionImport(jobCtx, h.app, job, payload); err != nil {
+		return err
 	}
 
-	ctx.LogDebug(result, "Import operation result")
-
-	// Placeholder: In a real implementation, this would:
-	// 1. Read data from source at payload.Data.Source
-	// 2. Validate and clean data
-	// 3. Insert into database at payload.Data.Target
-
 	log.Info("Import operation completed", "source", payload.Data.Source, "target", payload.Data.Target)
+
 	return nil
 }
+
+// sleepContext waits for the given duration or until ctx is done
+func sleepContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -1,6 +1,8 @@
 package jobs
 
 import (
+	"context"
+	"errors"
 	"ims-pocketbase-baas-starter/pkg/cronutils"
 	"ims-pocketbase-baas-starter/pkg/jobutils"
 	"testing"
@@ -110,6 +112,7 @@ func TestDataProcessingJobHandler_handleTransformOperation(t *testing.T) {
 	app := pocketbase.New()
 	handler := NewDataProcessingJobHandler(app)
 	ctx := cronutils.NewCronExecutionContext(app, "test-job")
+	job := &jobutils.JobData{ID: "test-job"}
 
 	payload := &jobutils.DataProcessingJobPayload{
 		Data: jobutils.DataProcessingJobData{
@@ -119,7 +122,7 @@ func TestDataProcessingJobHandler_handleTransformOperation(t *testing.T) {
 		},
 	}
 
-	err := handler.handleTransformOperation(ctx, payload)
+	err := handler.handleTransformOperation(context.Background(), ctx, job, payload)
 	if err != nil {
 		t.Errorf("handleTransformOperation should not return error: %v", err)
 	}
@@ -129,6 +132,7 @@ func TestDataProcessingJobHandler_handleAggregateOperation(t *testing.T) {
 	app := pocketbase.New()
 	handler := NewDataProcessingJobHandler(app)
 	ctx := cronutils.NewCronExecutionContext(app, "test-job")
+	job := &jobutils.JobData{ID: "test-job"}
 
 	payload := &jobutils.DataProcessingJobPayload{
 		Data: jobutils.DataProcessingJobData{
@@ -138,27 +142,40 @@ func TestDataProcessingJobHandler_handleAggregateOperation(t *testing.T) {
 		},
 	}
 
-	err := handler.handleAggregateOperation(ctx, payload)
+	err := handler.handleAggregateOperation(context.Background(), ctx, job, payload)
 	if err != nil {
 		t.Errorf("handleAggregateOperation should not return error: %v", err)
 	}
 }
 
-func TestDataProcessingJobHandler_handleImportOperation(t *testing.T) {
+func TestDataProcessingJobHandler_handleTransformOperationCanceled(t *testing.T) {
 	app := pocketbase.New()
 	handler := NewDataProcessingJobHandler(app)
 	ctx := cronutils.NewCronExecutionContext(app, "test-job")
+	job := &jobutils.JobData{ID: "test-job"}
+
+	jobCtx, cancel := context.WithCancel(context.Background())
+	cancel()
 
 	payload := &jobutils.DataProcessingJobPayload{
 		Data: jobutils.DataProcessingJobData{
-			Operation: jobutils.DataProcessingOperationImport,
-			Source:    "source_file",
+			Operation: jobutils.DataProcessingOperationTransform,
+			Source:    "source_table",
 			Target:    "target_table",
 		},
 	}
 
-	err := handler.handleImportOperation(ctx, payload)
-	if err != nil {
-		t.Errorf("handleImportOperation should not return error: %v", err)
+	err := handler.handleTransformOperation(jobCtx, ctx, job, payload)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected handleTransformOperation to stop on a canceled context, got %v", err)
+	}
+}
+
+func TestDataProcessingJobHandler_DefaultTimeout(t *testing.T) {
+	handler := NewDataProcessingJobHandler(pocketbase.New())
+
+	var _ jobutils.ContextJobHandler = handler
+	if handler.DefaultTimeout() != dataProcessingJobTimeout {
+		t.Errorf("expected default timeout %s, got %s", dataProcessingJobTimeout, handler.DefaultTimeout())
 	}
 }
@@ -2,11 +2,13 @@ package jobs
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"html/template"
 	"net/mail"
 	"os"
 	"path/filepath"
+	"time"
 
 	"ims-pocketbase-baas-starter/pkg/cronutils"
 	"ims-pocketbase-baas-starter/pkg/jobutils"
@@ -29,8 +31,22 @@ func NewEmailJobHandler(app *pocketbase.PocketBase) *EmailJobHandler {
 	}
 }
 
-// Handle processes an email job using typed payload structures (with metrics instrumentation)
+// emailJobTimeout is the default deadline of an email job without options.timeout
+const emailJobTimeout = 30 * time.Second
+
+// Handle processes an email job without a deadline
 func (h *EmailJobHandler) Handle(ctx *cronutils.CronExecutionContext, job *jobutils.JobData) error {
+	return h.HandleContext(context.Background(), ctx, job)
+}
+
+// DefaultTimeout returns the deadline of email jobs that do not set options.timeout
+func (h *EmailJobHandler) DefaultTimeout() time.Duration {
+	return emailJobTimeout
+}
+
+// HandleContext processes an email job using typed payload structures (with metrics instrumentation).
+// The email is not sent once the job deadline has passed or the app is shutting down.
+func (h *EmailJobHandler) HandleContext(jobCtx context.Context, ctx *cronutils.CronExecutionContext, job *jobutils.JobData) error {
 	ctx.LogStart(fmt.Sprintf("Processing email job: %s", job.ID))
 
 	metricsProvider := metrics.GetInstance()
@@ -46,15 +62,33 @@ func (h *EmailJobHandler) Handle(ctx *cronutils.CronExecutionContext, job *jobut
 			return fmt.Errorf("invalid email job payload: %w", err)
 		}
 
+		addWorkflowVariables(emailPayload, job)
+
 		htmlContent, textContent, err := h.processEmailTemplates(emailPayload)
 		if err != nil {
 			return fmt.Errorf("failed to process email templates: %w", err)
 		}
 
+		if err := jobCtx.Err(); err != nil {
+			return fmt.Errorf("email not sent: %w", err)
+		}
+
+		reportProgress(job.ID, 50, "Sending email")
+
 		if err := h.sendEmail(emailPayload, htmlContent, textContent); err != nil {
 			return fmt.Errorf("failed to send email: %w", err)
 		}
 
+		deliveredAt := time.Now()
+		reportResult(job.ID, &jobutils.EmailResult{
+			BaseJobResultData: jobutils.BaseJobResultData{
+				Message:   "Email sent successfully",
+				Timestamp: deliveredAt,
+			},
+			DeliveredAt: &deliveredAt,
+			Recipients:  []string{emailPayload.Data.To},
+		})
+
 		ctx.LogEnd("Email job processed successfully")
 		return nil
 	})
@@ -65,6 +99,16 @@ func (h *EmailJobHandler) GetJobType() string {
 	return jobutils.JobTypeEmail
 }
 
+// ValidatePayload validates an email job payload before it is queued
+func (h *EmailJobHandler) ValidatePayload(job *jobutils.JobData) error {
+	emailPayload, err := jobutils.ParseEmailJobPayload(job)
+	if err != nil {
+		return err
+	}
+
+	return h.validateEmailPayload(emailPayload)
+}
+
 // validateEmailPayload validates the typed email job payload (additional handler-specific validation)
 func (h *EmailJobHandler) validateEmailPayload(payload *jobutils.EmailJobPayload) error {
 	if payload.Type != jobutils.JobTypeEmail {
@@ -76,6 +120,26 @@ func (h *EmailJobHandler) validateEmailPayload(payload *jobutils.EmailJobPayload
 	return nil
 }
 
+// addWorkflowVariables exposes the result of the job a chained email was queued after as
+// ParentResult, and the status of the batch a follow-up email was queued for as Batch
+func addWorkflowVariables(payload *jobutils.EmailJobPayload, job *jobutils.JobData) {
+	parentResult, hasParent := job.Payload[jobutils.ParentResultKey]
+	batch, hasBatch := job.Payload[jobutils.BatchPayloadKey]
+	if !hasParent && !hasBatch {
+		return
+	}
+
+	if payload.Data.Variables == nil {
+		payload.Data.Variables = map[string]any{}
+	}
+	if hasParent {
+		payload.Data.Variables["ParentResult"] = parentResult
+	}
+	if hasBatch {
+		payload.Data.Variables["Batch"] = batch
+	}
+}
+
 // processEmailTemplates processes both HTML and text email templates with variables
 func (h *EmailJobHandler) processEmailTemplates(payload *jobutils.EmailJobPayload) (string, string, error) {
 	if payload.Data.Template == "" {
@@ -105,3 +105,28 @@ func TestEmailJobHandler_processSingleTemplate_FileNotFound(t *testing.T) {
 		t.Error("processSingleTemplate should return empty content on error")
 	}
 }
+
+func TestAddWorkflowVariables(t *testing.T) {
+	payload := &jobutils.EmailJobPayload{}
+	addWorkflowVariables(payload, &jobutils.JobData{Payload: map[string]any{"type": jobutils.JobTypeEmail}})
+	if payload.Data.Variables != nil {
+		t.Errorf("expected no variables for a plain email job, got %v", payload.Data.Variables)
+	}
+
+	payload = &jobutils.EmailJobPayload{Data: jobutils.EmailJobData{Variables: map[string]any{"Name": "John"}}}
+	addWorkflowVariables(payload, &jobutils.JobData{Payload: map[string]any{
+		"type":                   jobutils.JobTypeEmail,
+		jobutils.ParentResultKey: map[string]any{"file_name": "users.csv"},
+		jobutils.BatchPayloadKey: map[string]any{"status": jobutils.BatchStatusCompleted},
+	}})
+
+	if payload.Data.Variables["Name"] != "John" {
+		t.Error("existing variables should be kept")
+	}
+	if _, ok := payload.Data.Variables["ParentResult"]; !ok {
+		t.Error("expected the parent result to be exposed as ParentResult")
+	}
+	if _, ok := payload.Data.Variables["Batch"]; !ok {
+		t.Error("expected the batch status to be exposed as Batch")
+	}
+}
@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"ims-pocketbase-baas-starter/pkg/jobutils"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+)
+
+// reportProgress stores the progress of a running job. Progress is informational,
+// so a failure to store it is logged and never fails the job.
+func reportProgress(jobId string, percent int, message string) {
+	if err := jobutils.ReportProgress(jobId, percent, message); err != nil {
+		log.Debug("Failed to report job progress", "job_id", jobId, "progress", percent, "error", err)
+	}
+}
+
+// reportResult stores the typed result of a running job, saved once the job completes
+func reportResult(jobId string, result any) {
+	if err := jobutils.SetJobResult(jobId, result); err != nil {
+		log.Debug("Failed to store job result", "job_id", jobId, "error", err)
+	}
+}
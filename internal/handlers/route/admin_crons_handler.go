@@ -0,0 +1,201 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ims-pocketbase-baas-starter/internal/crons"
+	"ims-pocketbase-baas-starter/pkg/cronutils"
+	"ims-pocketbase-baas-starter/pkg/response"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// HandleAdminListCrons returns every defined cron with its schedule, pause state, next fire
+// time and last run
+func HandleAdminListCrons(e *core.RequestEvent) error {
+	list, err := crons.ListCrons(e.App)
+	if err != nil {
+		return response.InternalServerError(e, "Failed to list crons", nil)
+	}
+
+	items := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		items = append(items, item.ToMap())
+	}
+
+	return response.OK(e, "Crons", map[string]any{"items": items})
+}
+
+// HandleAdminPreviewCronSchedule returns the next runs of the cron expression in the expr query
+// parameter, evaluated in the timezone query parameter (UTC by default)
+func HandleAdminPreviewCronSchedule(e *core.RequestEvent) error {
+	query := e.Request.URL.Query()
+
+	expr := query.Get("expr")
+	if expr == "" {
+		return response.ValidationError(e, "Cron expression is required", nil)
+	}
+
+	location := time.UTC
+	if timezone := query.Get("timezone"); timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return response.ValidationError(e, fmt.Sprintf("invalid timezone %q", timezone), nil)
+		}
+		location = loc
+	}
+
+	count, err := parseQueryInt(query.Get("count"))
+	if err != nil {
+		return response.ValidationError(e, fmt.Sprintf("invalid count: %v", err), nil)
+	}
+	if count == 0 {
+		count = cronutils.DefaultPreviewRuns
+	}
+	count = min(count, cronutils.MaxPreviewRuns)
+
+	schedule, err := cronutils.ParseSchedule(expr, location)
+	if err != nil {
+		return response.ValidationError(e, err.Error(), nil)
+	}
+
+	runs, err := schedule.NextRuns(time.Now(), count)
+	if errors.Is(err, cronutils.ErrNoNextRun) {
+		runs = []time.Time{}
+	} else if err != nil {
+		return response.InternalServerError(e, "Failed to preview the cron schedule", nil)
+	}
+
+	return response.OK(e, "Cron schedule preview", map[string]any{
+		"expr":     expr,
+		"timezone": schedule.Location.String(),
+		"runs":     runs,
+	})
+}
+
+// HandleAdminTriggerCron runs a cron now in the background, outside its schedule
+func HandleAdminTriggerCron(e *core.RequestEvent) error {
+	cronId := e.Request.PathValue("id")
+	if cronId == "" {
+		return response.ValidationError(e, "Cron ID is required", nil)
+	}
+
+	run, _, err := crons.TriggerCron(e.App, cronId)
+	if errors.Is(err, crons.ErrCronNotFound) {
+		return response.NotFound(e, "Cron not found")
+	}
+	if errors.Is(err, crons.ErrCronDisabled) {
+		return response.Error(e, http.StatusConflict, "Cron is disabled on this instance", nil)
+	}
+	if errors.Is(err, cronutils.ErrCronRunning) {
+		return response.Error(e, http.StatusConflict, "Cron is already running", nil)
+	}
+	if err != nil {
+		return response.InternalServerError(e, "Failed to trigger cron", nil)
+	}
+
+	return response.Success(e, http.StatusAccepted, "Cron triggered", run.ToMap())
+}
+
+// HandleAdminPauseCron pauses the scheduled runs of a cron on every instance
+func HandleAdminPauseCron(e *core.RequestEvent) error {
+	cronId := e.Request.PathValue("id")
+	if cronId == "" {
+		return response.ValidationError(e, "Cron ID is required", nil)
+	}
+
+	pause, err := crons.PauseCron(e.App, cronId, e.Auth.Id)
+	if errors.Is(err, crons.ErrCronNotFound) {
+		return response.NotFound(e, "Cron not found")
+	}
+	if err != nil {
+		return response.InternalServerError(e, "Failed to pause cron", nil)
+	}
+
+	return response.OK(e, "Cron paused", map[string]any{
+		"cron_id":   pause.CronID,
+		"paused":    true,
+		"paused_at": pause.PausedAt,
+		"paused_by": pause.PausedBy,
+	})
+}
+
+// HandleAdminResumeCron resumes the scheduled runs of a paused cron
+func HandleAdminResumeCron(e *core.RequestEvent) error {
+	cronId := e.Request.PathValue("id")
+	if cronId == "" {
+		return response.ValidationError(e, "Cron ID is required", nil)
+	}
+
+	err := crons.ResumeCron(e.App, cronId)
+	if errors.Is(err, crons.ErrCronNotFound) {
+		return response.NotFound(e, "Cron not found")
+	}
+	if err != nil {
+		return response.InternalServerError(e, "Failed to resume cron", nil)
+	}
+
+	return response.OK(e, "Cron resumed", map[string]any{
+		"cron_id": cronId,
+		"paused":  false,
+	})
+}
+
+// HandleAdminListCronRuns returns a page of the cron run history, filtered by the cron_id,
+// status, from and to (start date) query parameters
+func HandleAdminListCronRuns(e *core.RequestEvent) error {
+	filter, err := parseCronRunFilter(e)
+	if err != nil {
+		return response.ValidationError(e, err.Error(), nil)
+	}
+
+	list, err := cronutils.ListCronRuns(e.App, filter)
+	if errors.Is(err, cronutils.ErrInvalidCronRunStatus) {
+		return response.ValidationError(e, err.Error(), nil)
+	}
+	if err != nil {
+		return response.InternalServerError(e, "Failed to list cron runs", nil)
+	}
+
+	items := make([]map[string]any, 0, len(list.Items))
+	for _, item := range list.Items {
+		items = append(items, item.ToMap())
+	}
+
+	return response.OK(e, "Cron runs", map[string]any{
+		"page":        list.Page,
+		"per_page":    list.PerPage,
+		"total_items": list.TotalItems,
+		"total_pages": list.TotalPages,
+		"items":       items,
+	})
+}
+
+// parseCronRunFilter reads the cron run filter from the query parameters
+func parseCronRunFilter(e *core.RequestEvent) (cronutils.CronRunFilter, error) {
+	query := e.Request.URL.Query()
+
+	filter := cronutils.CronRunFilter{
+		CronID: query.Get("cron_id"),
+		Status: query.Get("status"),
+	}
+
+	var err error
+	if filter.StartedFrom, err = parseQueryTime(query.Get("from"), false); err != nil {
+		return filter, fmt.Errorf("invalid from date: %w", err)
+	}
+	if filter.StartedTo, err = parseQueryTime(query.Get("to"), true); err != nil {
+		return filter, fmt.Errorf("invalid to date: %w", err)
+	}
+	if filter.Page, err = parseQueryInt(query.Get("page")); err != nil {
+		return filter, fmt.Errorf("invalid page: %w", err)
+	}
+	if filter.PerPage, err = parseQueryInt(query.Get("per_page")); err != nil {
+		return filter, fmt.Errorf("invalid per_page: %w", err)
+	}
+
+	return filter, nil
+}
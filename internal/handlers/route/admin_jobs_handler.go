@@ -0,0 +1,228 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/jobutils"
+	"ims-pocketbase-baas-starter/pkg/response"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// HandleAdminListJobs returns a page of jobs, filtered by the type, status, queue, user_id,
+// from and to (created date) query parameters
+func HandleAdminListJobs(e *core.RequestEvent) error {
+	filter, err := parseJobListFilter(e)
+	if err != nil {
+		return response.ValidationError(e, err.Error(), nil)
+	}
+
+	list, err := jobutils.ListJobs(e.App, filter)
+	if errors.Is(err, jobutils.ErrInvalidJobStatus) {
+		return response.ValidationError(e, err.Error(), nil)
+	}
+	if err != nil {
+		return response.InternalServerError(e, "Failed to list jobs", nil)
+	}
+
+	items := make([]map[string]any, 0, len(list.Items))
+	for _, item := range list.Items {
+		items = append(items, item.ToMap())
+	}
+
+	return response.OK(e, "Jobs", map[string]any{
+		"page":        list.Page,
+		"per_page":    list.PerPage,
+		"total_items": list.TotalItems,
+		"total_pages": list.TotalPages,
+		"items":       items,
+	})
+}
+
+// HandleAdminGetJob returns a job with its payload and error history
+func HandleAdminGetJob(e *core.RequestEvent) error {
+	jobId := e.Request.PathValue("id")
+	if jobId == "" {
+		return response.ValidationError(e, "Job ID is required", nil)
+	}
+
+	details, err := jobutils.GetJobDetails(e.App, jobId)
+	if errors.Is(err, jobutils.ErrJobNotFound) {
+		return response.NotFound(e, "Job not found")
+	}
+	if err != nil {
+		return response.InternalServerError(e, "Failed to get job", nil)
+	}
+
+	return response.OK(e, "Job details", details.ToMap())
+}
+
+// HandleAdminGetBatch returns the aggregate status of a job batch
+func HandleAdminGetBatch(e *core.RequestEvent) error {
+	batchId := e.Request.PathValue("id")
+	if batchId == "" {
+		return response.ValidationError(e, "Batch ID is required", nil)
+	}
+
+	status, err := jobutils.GetBatchStatus(e.App, batchId)
+	if errors.Is(err, jobutils.ErrBatchNotFound) {
+		return response.NotFound(e, "Batch not found")
+	}
+	if err != nil {
+		return response.InternalServerError(e, "Failed to get batch", nil)
+	}
+
+	return response.OK(e, "Batch status", status.ToMap())
+}
+
+// HandleAdminRetryJob requeues a failed, dead or canceled job with a fresh attempt budget
+func HandleAdminRetryJob(e *core.RequestEvent) error {
+	jobId := e.Request.PathValue("id")
+	if jobId == "" {
+		return response.ValidationError(e, "Job ID is required", nil)
+	}
+
+	details, err := jobutils.RetryJob(e.App, jobId)
+	if errors.Is(err, jobutils.ErrJobNotFound) {
+		return response.NotFound(e, "Job not found")
+	}
+	if errors.Is(err, jobutils.ErrJobNotRetryable) {
+		return response.Error(e, http.StatusConflict, "Only failed, dead or canceled jobs can be retried", nil)
+	}
+	if errors.Is(err, jobutils.ErrDuplicateJob) {
+		return response.Error(e, http.StatusConflict, "Another active job holds the idempotency key of this job", map[string]any{"error": err.Error()})
+	}
+	if err != nil {
+		return response.InternalServerError(e, "Failed to retry job", nil)
+	}
+
+	return response.OK(e, "Job queued for retry", details.ToMap())
+}
+
+// HandleAdminCancelJob cancels a queued, failed or running job
+func HandleAdminCancelJob(e *core.RequestEvent) error {
+	jobId := e.Request.PathValue("id")
+	if jobId == "" {
+		return response.ValidationError(e, "Job ID is required", nil)
+	}
+
+	details, err := jobutils.CancelJob(e.App, jobId)
+	if errors.Is(err, jobutils.ErrJobNotFound) {
+		return response.NotFound(e, "Job not found")
+	}
+	if errors.Is(err, jobutils.ErrJobNotCancelable) {
+		return response.Error(e, http.StatusConflict, "Only queued, failed or running jobs can be canceled", nil)
+	}
+	if err != nil {
+		return response.InternalServerError(e, "Failed to cancel job", nil)
+	}
+
+	return response.OK(e, "Job canceled", details.ToMap())
+}
+
+// HandleAdminPurgeJobs deletes the jobs in the statuses of the status query parameter
+// (comma separated, completed and dead by default) last updated before the optional before date
+func HandleAdminPurgeJobs(e *core.RequestEvent) error {
+	query := e.Request.URL.Query()
+
+	statuses := []string{jobutils.JobStatusCompleted, jobutils.JobStatusDead}
+	if raw := query.Get("status"); raw != "" {
+		statuses = splitQueryList(raw)
+	}
+
+	before, err := parseQueryTime(query.Get("before"), false)
+	if err != nil {
+		return response.ValidationError(e, "Invalid before date", map[string]any{"before": err.Error()})
+	}
+
+	deleted, err := jobutils.PurgeJobs(e.App, statuses, before)
+	if errors.Is(err, jobutils.ErrInvalidJobStatus) {
+		return response.ValidationError(e, err.Error(), nil)
+	}
+	if err != nil {
+		return response.InternalServerError(e, "Failed to purge jobs", map[string]any{"deleted": deleted})
+	}
+
+	return response.OK(e, "Jobs purged", map[string]any{
+		"statuses": statuses,
+		"deleted":  deleted,
+	})
+}
+
+// parseJobListFilter reads the job list filter from the query parameters
+func parseJobListFilter(e *core.RequestEvent) (jobutils.JobListFilter, error) {
+	query := e.Request.URL.Query()
+
+	filter := jobutils.JobListFilter{
+		Type:    query.Get("type"),
+		Status:  query.Get("status"),
+		Queue:   query.Get("queue"),
+		OwnerID: query.Get("user_id"),
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseQueryTime(query.Get("from"), false); err != nil {
+		return filter, fmt.Errorf("invalid from date: %w", err)
+	}
+	if filter.CreatedTo, err = parseQueryTime(query.Get("to"), true); err != nil {
+		return filter, fmt.Errorf("invalid to date: %w", err)
+	}
+	if filter.Page, err = parseQueryInt(query.Get("page")); err != nil {
+		return filter, fmt.Errorf("invalid page: %w", err)
+	}
+	if filter.PerPage, err = parseQueryInt(query.Get("per_page")); err != nil {
+		return filter, fmt.Errorf("invalid per_page: %w", err)
+	}
+
+	return filter, nil
+}
+
+// parseQueryTime parses an RFC 3339 timestamp or a YYYY-MM-DD date (empty means no time).
+// Plain dates used as an upper bound cover the whole day.
+func parseQueryTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC 3339, got %q", value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Millisecond)
+	}
+	return t, nil
+}
+
+// parseQueryInt parses an optional non-negative integer query parameter
+func parseQueryInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("expected a positive number, got %q", value)
+	}
+	return n, nil
+}
+
+// splitQueryList splits a comma separated query parameter, dropping empty items
+func splitQueryList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,168 @@
+package route
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ims-pocketbase-baas-starter/internal/handlers/dataimport"
+	"ims-pocketbase-baas-starter/pkg/jobutils"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+	"ims-pocketbase-baas-starter/pkg/response"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// importIdempotencyWindow is how long a completed import answers retries sent with the same Idempotency-Key
+const importIdempotencyWindow = 24 * time.Hour
+
+// dataImportRequestFields maps the import payload values to the fields of the multipart form, for validation errors
+var dataImportRequestFields = map[string]string{"target": "collection", "source": "file"}
+
+// HandleDataImport uploads a CSV, XLSX, JSON or NDJSON file and queues its import into a collection.
+// The multipart form holds the file, the collection, and optionally the format (from the file extension
+// by default), the mapping of the file columns to the collection fields (a JSON object), the mode
+// (insert or upsert), the key field of upserts and dry_run, which validates the rows without saving them.
+// The import is validated before the job is queued; its progress, result and error report are read
+// with the job status and download routes. A retry with the Idempotency-Key of an earlier request
+// returns the existing job instead of queuing another one.
+func HandleDataImport(e *core.RequestEvent) error {
+	files, err := e.FindUploadedFiles("file")
+	if errors.Is(err, http.ErrMissingFile) {
+		return response.ValidationError(e, "An import file is required", map[string]any{"file": "missing file"})
+	}
+	if err != nil {
+		return response.ValidationError(e, "Invalid import request", map[string]any{"body": err.Error()})
+	}
+	file := files[0]
+
+	collectionName := strings.TrimSpace(e.Request.FormValue("collection"))
+	if collectionName == "" {
+		return response.ValidationError(e, "A collection is required", map[string]any{"collection": "cannot be blank"})
+	}
+	collection, err := e.App.FindCachedCollectionByNameOrId(collectionName)
+	if err != nil {
+		return response.ValidationError(e, "Collection not found", map[string]any{"collection": "collection " + strconv.Quote(collectionName) + " not found"})
+	}
+
+	formatName := e.Request.FormValue("format")
+	if formatName == "" {
+		formatName = dataimport.FormatFromFilename(file.OriginalName)
+	}
+	format, err := dataimport.GetFormat(formatName)
+	if err != nil {
+		return response.ValidationError(e, err.Error(), map[string]any{"format": err.Error()})
+	}
+
+	var mapping map[string]string
+	if raw := e.Request.FormValue("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			return response.ValidationError(e, "Invalid mapping", map[string]any{"mapping": "expected a JSON object of file columns to collection fields"})
+		}
+	}
+
+	dryRun := false
+	if raw := e.Request.FormValue("dry_run"); raw != "" {
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			return response.ValidationError(e, "Invalid dry_run", map[string]any{"dry_run": "expected true or false"})
+		}
+	}
+
+	mode := e.Request.FormValue("mode")
+	if err := dataimport.CheckImportAccess(collection, &core.RequestInfo{Auth: e.Auth}, mode); err != nil {
+		return response.Forbidden(e, err.Error())
+	}
+
+	upload, err := jobutils.SaveImportFile(e.App, jobOwner(e), collection.Name, format.Name, file)
+	if err != nil {
+		var fieldErrors validation.Errors
+		if errors.As(err, &fieldErrors) {
+			return response.ValidationError(e, "Invalid import file", map[string]any{"file": fieldErrors.Error()})
+		}
+		log.Error("Failed to save import file", "collection", collection.Name, "error", err)
+		return response.InternalServerError(e, "Failed to save import file", nil)
+	}
+
+	payload := jobutils.DataProcessingJobPayload{
+		Type: jobutils.JobTypeDataProcessing,
+		Data: jobutils.DataProcessingJobData{
+			Operation: jobutils.DataProcessingOperationImport,
+			Source:    upload.Id,
+			Target:    collection.Name,
+			Mapping:   mapping,
+			Mode:      mode,
+			KeyField:  e.Request.FormValue("key_field"),
+		},
+		Options: jobutils.DataProcessingJobOptions{
+			Timeout: 1800, // 30 minutes
+			DryRun:  dryRun,
+		},
+	}
+
+	// the upload is only kept for the job it is queued with
+	deleteUpload := func() {
+		if err := e.App.Delete(upload); err != nil {
+			log.Warn("Failed to delete import file", "import_file", upload.Id, "error", err)
+		}
+	}
+
+	if err := dataimport.ValidateImportPayload(e.App, &payload); err != nil {
+		deleteUpload()
+
+		var fieldErrors validation.Errors
+		if !errors.As(err, &fieldErrors) {
+			return response.ValidationError(e, err.Error(), nil)
+		}
+
+		details := make(map[string]any, len(fieldErrors))
+		for field, fieldErr := range fieldErrors {
+			if name, ok := dataImportRequestFields[field]; ok {
+				field = name
+			}
+			details[field] = fieldErr.Error()
+		}
+		return response.ValidationError(e, "Invalid import request", details)
+	}
+
+	description := "Import " + strings.ToUpper(format.Name) + " into " + collection.Name
+	if dryRun {
+		description = "Dry run: " + description
+	}
+
+	options := []jobutils.EnqueueOption{
+		jobutils.WithName("Data Import"),
+		jobutils.WithDescription(description),
+		jobutils.WithQueue(jobutils.QueueExports),
+		jobutils.WithOwner(jobOwner(e)),
+	}
+	if key := e.Request.Header.Get("Idempotency-Key"); key != "" {
+		options = append(options, jobutils.WithUniqueKey("data-import:"+e.Auth.Id+":"+idempotencyKeyHash(key), importIdempotencyWindow))
+	}
+
+	job, err := jobutils.Enqueue(e.App, payload, options...)
+	if err != nil {
+		deleteUpload()
+		return response.InternalServerError(e, "Failed to queue import job", nil)
+	}
+
+	status := jobutils.JobStatusQueued
+	if job.Existing {
+		// the existing job imports the file of the first request
+		deleteUpload()
+		if status, err = job.Status(); err != nil {
+			return response.InternalServerError(e, "Failed to get import job status", nil)
+		}
+	}
+
+	data := map[string]any{
+		"job_id":    job.ID,
+		"status":    status,
+		"duplicate": job.Existing,
+		"dry_run":   dryRun,
+	}
+	return response.OK(e, "Import job queued successfully", data)
+}
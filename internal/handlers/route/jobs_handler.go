@@ -1,28 +1,43 @@
 package route
 
 import (
+	"errors"
+
+	"ims-pocketbase-baas-starter/internal/middlewares"
+	"ims-pocketbase-baas-starter/pkg/jobutils"
+	"ims-pocketbase-baas-starter/pkg/permission"
 	"ims-pocketbase-baas-starter/pkg/response"
 
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
 )
 
+// HandleGetJobStatus returns the state, progress, attempts, last error, timestamps and result of a job.
+// Only the job owner and users allowed to access every job can see it; others get a 404, so job IDs
+// cannot be probed.
 func HandleGetJobStatus(e *core.RequestEvent) error {
 	jobId := e.Request.PathValue("id")
 	if jobId == "" {
 		return response.ValidationError(e, "Job ID is required", nil)
 	}
 
-	status := getJobStatus(e.App, jobId)
+	status, err := jobutils.GetJobStatus(e.App, jobId)
+	if errors.Is(err, jobutils.ErrJobNotFound) {
+		return response.NotFound(e, "Job not found")
+	}
+	if err != nil {
+		return response.InternalServerError(e, "Failed to get job status", nil)
+	}
 
-	data := map[string]any{
-		"job_id": jobId,
-		"status": status,
+	if !canAccessJob(e, status.OwnerID) {
+		return response.NotFound(e, "Job not found")
 	}
 
-	return response.OK(e, "Job status", data)
+	return response.OK(e, "Job status", status.ToMap())
 }
 
+// HandleDownloadJobFile streams the export file produced by a job to its owner
+// (or to users allowed to access every job)
 func HandleDownloadJobFile(e *core.RequestEvent) error {
 	jobId := e.Request.PathValue("id")
 	if jobId == "" {
@@ -34,30 +49,44 @@ func HandleDownloadJobFile(e *core.RequestEvent) error {
 		return response.NotFound(e, "Export file not found")
 	}
 
+	if !canAccessJob(e, getJobFileOwner(e.App, exportRecord)) {
+		return response.NotFound(e, "Export file not found")
+	}
+
 	fileName := exportRecord.GetString("file")
 	basePath := exportRecord.BaseFilesPath()
 
-	return response.File(e, fileName, basePath)
+	return response.FileWithContentType(e, fileName, basePath, exportRecord.GetString("content_type"))
 }
 
 func getJobFileRecord(app core.App, jobId string) (*core.Record, error) {
-	return app.FindFirstRecordByFilter("export_files", "job_id = {:job_id}", dbx.Params{"job_id": jobId})
+	return app.FindFirstRecordByFilter(jobutils.ExportFilesCollectionName, "job_id = {:job_id}", dbx.Params{"job_id": jobId})
+}
+
+// getJobFileOwner returns the owner of an export file, falling back to the owner of the job
+// for files saved before export files recorded their owner
+func getJobFileOwner(app core.App, exportRecord *core.Record) string {
+	if ownerId := exportRecord.GetString("user_id"); ownerId != "" {
+		return ownerId
+	}
+
+	job, err := app.FindRecordById(jobutils.QueuesCollection, exportRecord.GetString("job_id"))
+	if err != nil {
+		return ""
+	}
+	return job.GetString("user_id")
 }
 
-func getJobStatus(app core.App, jobId string) string {
-	job, err := app.FindRecordById("queues", jobId)
-	if err == nil {
-		if job.GetString("reserved_at") == "" {
-			return "queued"
-		} else {
-			return "processing"
-		}
+// canAccessJob reports whether the authenticated user owns the job or may access every job.
+// Jobs without an owner (e.g. system jobs) are only accessible with the permission.
+func canAccessJob(e *core.RequestEvent, ownerId string) bool {
+	if e.Auth == nil {
+		return false
 	}
 
-	_, err = getJobFileRecord(app, jobId)
-	if err == nil {
-		return "completed"
+	if ownerId != "" && e.Auth.Id == ownerId {
+		return true
 	}
 
-	return "failed"
+	return middlewares.NewPermissionMiddleware().UserHasPermission(e.App, e.Auth, permission.JobViewAll)
 }
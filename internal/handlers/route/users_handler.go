@@ -1,48 +1,169 @@
 package route
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"ims-pocketbase-baas-starter/internal/handlers/export"
 	"ims-pocketbase-baas-starter/pkg/jobutils"
 	"ims-pocketbase-baas-starter/pkg/response"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/pocketbase/pocketbase/core"
 )
 
+// exportIdempotencyWindow is how long a completed export answers retries sent with the same Idempotency-Key
+const exportIdempotencyWindow = 24 * time.Hour
+
+// userExportFields are the columns of the user export, with the slugs of the direct permissions
+var userExportFields = []string{
+	"id",
+	"email",
+	"name",
+	"emailVisibility",
+	"verified",
+	"is_active",
+	"roles",
+	"permissions.slug",
+	"created",
+	"updated",
+}
+
+// userExportRequest is the optional JSON body of a user export
+type userExportRequest struct {
+	Format         string   `json:"format"`          // csv, xlsx, json, ndjson or pdf (the format query parameter, else csv)
+	Fields         []string `json:"fields"`          // Columns, relation.field for a related field (userExportFields when empty)
+	Filter         string   `json:"filter"`          // PocketBase filter of the exported users
+	Sort           string   `json:"sort"`            // PocketBase sort (-created when empty)
+	FilenamePrefix string   `json:"filename_prefix"` // Start of the file name (users_export when empty)
+	ResultExpiry   string   `json:"result_expiry"`   // How long the file is kept, e.g. 72h (EXPORT_FILE_EXPIRATION_DAYS when empty)
+}
+
+// userExportRequestFields maps the export payload values to the fields of the request body, for validation errors
+var userExportRequestFields = map[string]string{"target": "format"}
+
+// HandleUserExport queues an export of the users the requester can list. The optional JSON body selects
+// the format, the fields, a filter and sort, the file name prefix and how long the file is kept; the
+// format query parameter is still read when the body has no format. The body is validated against the
+// users collection before the job is queued. A request made while the user already has the same export
+// queued or running, or retried with the Idempotency-Key of an earlier request, returns the existing
+// job instead of queuing another one.
 func HandleUserExport(e *core.RequestEvent) error {
+	var body userExportRequest
+	if err := e.BindBody(&body); err != nil {
+		return response.ValidationError(e, "Invalid export request body", map[string]any{"body": err.Error()})
+	}
+
+	if body.Format == "" {
+		body.Format = e.Request.URL.Query().Get("format")
+	}
+	if len(body.Fields) == 0 {
+		body.Fields = userExportFields
+	}
+	if body.Sort == "" {
+		body.Sort = "-created"
+	}
+
+	format, err := export.GetFormat(body.Format)
+	if err != nil {
+		return response.ValidationError(e, err.Error(), map[string]any{"format": err.Error()})
+	}
+
 	payload := jobutils.DataProcessingJobPayload{
 		Type: jobutils.JobTypeDataProcessing,
 		Data: jobutils.DataProcessingJobData{
 			Operation: jobutils.DataProcessingOperationExport,
 			Source:    jobutils.DataProcessingCollectionUsers,
-			Target:    jobutils.DataProcessingFileCSV,
+			Target:    format.Name,
+			Filter:    body.Filter,
+			Sort:      body.Sort,
+			Fields:    body.Fields,
 		},
 		Options: jobutils.DataProcessingJobOptions{
-			Timeout: 900, // 15 minutes
+			Timeout:        900, // 15 minutes
+			FilenamePrefix: body.FilenamePrefix,
+			ResultExpiry:   body.ResultExpiry,
 		},
 	}
 
-	queuesCollection, err := e.App.FindCollectionByNameOrId("queues")
+	requestInfo, err := e.RequestInfo()
 	if err != nil {
-		return response.InternalServerError(e, "Queue system unavailable", nil)
+		return response.InternalServerError(e, "Failed to read export request", nil)
 	}
 
-	job := core.NewRecord(queuesCollection)
-	job.Set("name", "User Export")
-	job.Set("description", "Export users to CSV")
+	// the filter and sort are checked with the access of the requester, as the export applies them
+	if err := export.ValidateExportPayload(e.App, &payload, requestInfo); err != nil {
+		var fieldErrors validation.Errors
+		if !errors.As(err, &fieldErrors) {
+			return response.ValidationError(e, err.Error(), nil)
+		}
 
-	payloadJSON, err := json.Marshal(payload)
-	if err != nil {
-		return response.InternalServerError(e, "Failed to create job", nil)
+		details := make(map[string]any, len(fieldErrors))
+		for field, fieldErr := range fieldErrors {
+			if name, ok := userExportRequestFields[field]; ok {
+				field = name
+			}
+			details[field] = fieldErr.Error()
+		}
+		return response.ValidationError(e, "Invalid export request", details)
+	}
+
+	// the same export (format, fields, filter, ...) is queued once at a time
+	exportKey := "user-export:" + e.Auth.Id + ":" + userExportHash(payload)
+	uniqueKey := jobutils.WithUniqueKey(exportKey, 0)
+	if key := e.Request.Header.Get("Idempotency-Key"); key != "" {
+		uniqueKey = jobutils.WithUniqueKey(exportKey+":"+idempotencyKeyHash(key), exportIdempotencyWindow)
 	}
-	job.Set("payload", string(payloadJSON))
 
-	if err := e.App.Save(job); err != nil {
+	job, err := jobutils.Enqueue(e.App, payload,
+		jobutils.WithName("User Export"),
+		jobutils.WithDescription("Export users to "+strings.ToUpper(format.Name)),
+		jobutils.WithQueue(jobutils.QueueExports),
+		jobutils.WithOwner(jobOwner(e)),
+		uniqueKey,
+	)
+	if err != nil {
 		return response.InternalServerError(e, "Failed to queue export job", nil)
 	}
 
+	status := jobutils.JobStatusQueued
+	if job.Existing {
+		if status, err = job.Status(); err != nil {
+			return response.InternalServerError(e, "Failed to get export job status", nil)
+		}
+	}
+
 	data := map[string]any{
-		"job_id": job.Id,
-		"status": "queued",
+		"job_id":    job.ID,
+		"status":    status,
+		"duplicate": job.Existing,
 	}
 	return response.OK(e, "User export job queued successfully", data)
 }
+
+// userExportHash identifies the export a payload produces, for the unique key of the job
+func userExportHash(payload jobutils.DataProcessingJobPayload) string {
+	encoded, _ := json.Marshal(payload)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:8])
+}
+
+// jobOwner returns the owner of the jobs and files a request creates: the requesting user, or none for
+// superusers, as the owner fields relate to the users collection (jobs without an owner run without rules)
+func jobOwner(e *core.RequestEvent) string {
+	if e.HasSuperuserAuth() {
+		return ""
+	}
+	return e.Auth.Id
+}
+
+// idempotencyKeyHash returns the Idempotency-Key header of a request hashed to a fixed length, so
+// that keys of any length fit in the idempotency key of a job
+func idempotencyKeyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
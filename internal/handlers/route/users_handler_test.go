@@ -0,0 +1,24 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func TestJobOwner(t *testing.T) {
+	user := core.NewRecord(core.NewAuthCollection("users"))
+	user.Id = "u1"
+
+	superuser := core.NewRecord(core.NewAuthCollection(core.CollectionNameSuperusers))
+	superuser.Id = "s1"
+
+	if owner := jobOwner(&core.RequestEvent{Auth: user}); owner != "u1" {
+		t.Errorf("expected the user to own the export, got %q", owner)
+	}
+
+	// the owner fields relate to users, so superuser exports have no owner
+	if owner := jobOwner(&core.RequestEvent{Auth: superuser}); owner != "" {
+		t.Errorf("expected no owner for a superuser export, got %q", owner)
+	}
+}
@@ -3,6 +3,7 @@ package hooks
 import (
 	"fmt"
 	"ims-pocketbase-baas-starter/internal/handlers/hook"
+	"ims-pocketbase-baas-starter/pkg/jobutils"
 	log "ims-pocketbase-baas-starter/pkg/logger"
 	"ims-pocketbase-baas-starter/pkg/metrics"
 
@@ -85,6 +86,41 @@ func registerRecordHooks(app *pocketbase.PocketBase) error {
 	//     return hook.HandleCacheInvalidation(e)
 	// })
 
+	// Apply lifecycle defaults to newly queued jobs
+	app.OnRecordCreate(jobutils.QueuesCollection).BindFunc(func(e *core.RecordEvent) error {
+		return hook.HandleQueueJobDefaults(e)
+	})
+
+	// Dispatch newly queued (or requeued) jobs right away
+	app.OnRecordAfterCreateSuccess(jobutils.QueuesCollection).BindFunc(func(e *core.RecordEvent) error {
+		return hook.HandleQueueJobDispatch(e)
+	})
+	app.OnRecordAfterUpdateSuccess(jobutils.QueuesCollection).BindFunc(func(e *core.RecordEvent) error {
+		return hook.HandleQueueJobDispatch(e)
+	})
+
+	// Push job state changes to the realtime clients of the job owner
+	app.OnRecordAfterCreateSuccess(jobutils.QueuesCollection).BindFunc(func(e *core.RecordEvent) error {
+		return hook.HandleQueueJobStatusPush(e)
+	})
+	app.OnRecordAfterUpdateSuccess(jobutils.QueuesCollection).BindFunc(func(e *core.RecordEvent) error {
+		return hook.HandleQueueJobStatusPush(e)
+	})
+
+	// Validate recurring jobs and reload the scheduler when they change
+	app.OnRecordValidate(jobutils.ScheduledJobsCollection).BindFunc(func(e *core.RecordEvent) error {
+		return hook.HandleScheduledJobValidate(e)
+	})
+	app.OnRecordAfterCreateSuccess(jobutils.ScheduledJobsCollection).BindFunc(func(e *core.RecordEvent) error {
+		return hook.HandleScheduledJobReload(e)
+	})
+	app.OnRecordAfterUpdateSuccess(jobutils.ScheduledJobsCollection).BindFunc(func(e *core.RecordEvent) error {
+		return hook.HandleScheduledJobReload(e)
+	})
+	app.OnRecordAfterDeleteSuccess(jobutils.ScheduledJobsCollection).BindFunc(func(e *core.RecordEvent) error {
+		return hook.HandleScheduledJobReload(e)
+	})
+
 	// Send welcome email to new users
 	app.OnRecordAfterCreateSuccess("users").BindFunc(func(e *core.RecordEvent) error {
 		return hook.HandleUserWelcomeEmail(e)
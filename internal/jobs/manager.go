@@ -1,6 +1,7 @@
 package jobs
 
 import (
+	"context"
 	"sync"
 
 	"ims-pocketbase-baas-starter/pkg/jobutils"
@@ -12,6 +13,8 @@ import (
 // JobManager manages the global job processor instance
 type JobManager struct {
 	processor   *jobutils.JobProcessor
+	dispatcher  *jobutils.Dispatcher
+	scheduler   *jobutils.Scheduler
 	mu          sync.RWMutex
 	initialized bool
 }
@@ -41,6 +44,8 @@ func (jm *JobManager) Initialize(app *pocketbase.PocketBase) error {
 	log.Info("Initializing job manager and processors")
 
 	jm.processor = jobutils.NewJobProcessor(app)
+	jm.dispatcher = jobutils.NewDispatcher(jm.processor)
+	jm.scheduler = jobutils.NewScheduler(app)
 
 	jm.initialized = true
 	log.Info("Job manager initialization completed - ready for job processing")
@@ -60,6 +65,54 @@ func (jm *JobManager) GetProcessor() *jobutils.JobProcessor {
 	return jm.processor
 }
 
+// GetDispatcher returns the continuous job dispatcher (nil before initialization)
+func (jm *JobManager) GetDispatcher() *jobutils.Dispatcher {
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+
+	if !jm.initialized {
+		return nil
+	}
+
+	return jm.dispatcher
+}
+
+// GetScheduler returns the scheduler of the scheduled_jobs collection (nil before initialization)
+func (jm *JobManager) GetScheduler() *jobutils.Scheduler {
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+
+	if !jm.initialized {
+		return nil
+	}
+
+	return jm.scheduler
+}
+
+// NotifyJobQueued wakes the dispatcher of the job's queue so the job is picked up right away
+func (jm *JobManager) NotifyJobQueued(queue string) {
+	if dispatcher := jm.GetDispatcher(); dispatcher != nil && dispatcher.IsRunning() {
+		dispatcher.Notify(queue)
+	}
+}
+
+// Shutdown stops dispatching new jobs and drains the worker pools until ctx is done.
+// Reservations of jobs that did not finish in time are released.
+func (jm *JobManager) Shutdown(ctx context.Context) error {
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+
+	if !jm.initialized {
+		return nil
+	}
+
+	if jm.dispatcher != nil {
+		jm.dispatcher.Stop()
+	}
+
+	return jm.processor.Shutdown(ctx)
+}
+
 // IsInitialized returns whether the job manager has been initialized
 func (jm *JobManager) IsInitialized() bool {
 	jm.mu.RLock()
@@ -135,6 +135,29 @@ func (m *PermissionMiddleware) HasPermission(userPermissions []string, permissio
 	return false
 }
 
+// UserHasPermission checks if a user has any of the specified permissions, for handlers that
+// authorize access per record (e.g. owner-or-permission checks) instead of per route.
+// Superusers of pocketbase bypass this check.
+//
+// Parameters:
+//   - app: The PocketBase app instance
+//   - user: The authenticated user record (nil is never allowed)
+//   - permissions: String array of permission slugs to check
+//
+// Returns:
+//   - bool: True if the user is a superuser or has any of the specified permissions
+func (m *PermissionMiddleware) UserHasPermission(app core.App, user *core.Record, permissions ...string) bool {
+	if user == nil {
+		return false
+	}
+
+	if user.IsSuperuser() {
+		return true
+	}
+
+	return m.HasPermission(m.getUserPermissions(app, user), permissions)
+}
+
 // RequirePermission returns a middleware function that requires specific permissions
 // This middleware checks if the authenticated user has any of the specified permissions
 //
@@ -156,18 +179,11 @@ func (m *PermissionMiddleware) RequirePermission(permissions ...string) func(*co
 
 		user := e.Auth
 
-		userPermissions := m.getUserPermissions(e.App, user)
-
 		if user == nil {
 			return apis.NewForbiddenError("Authentication required", nil)
 		}
 
-		// Check if the user is a superuser of pocketbase they will bypass this check
-		if user.IsSuperuser() {
-			return nil
-		}
-
-		if m.HasPermission(userPermissions, permissions) {
+		if m.UserHasPermission(e.App, user, permissions...) {
 			return nil
 		}
 
@@ -2,6 +2,9 @@ package middlewares
 
 import (
 	"testing"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
 )
 
 // TestHasPermission tests the HasPermission function with various scenarios
@@ -61,3 +64,19 @@ func TestHasPermission(t *testing.T) {
 		})
 	}
 }
+
+// TestUserHasPermission tests the per-record permission check used by handlers
+func TestUserHasPermission(t *testing.T) {
+	pm := NewPermissionMiddleware()
+	app := pocketbase.New()
+
+	if pm.UserHasPermission(app, nil, "job.view.all") {
+		t.Error("Expected nil user to have no permission")
+	}
+
+	superuser := core.NewRecord(core.NewAuthCollection(core.CollectionNameSuperusers))
+	superuser.Id = "superuser"
+	if !pm.UserHasPermission(app, superuser, "job.view.all") {
+		t.Error("Expected superuser to bypass the permission check")
+	}
+}
@@ -63,6 +63,17 @@ func RegisterCustom(e *core.ServeEvent) error {
 			Enabled:     true,
 			Description: "User export route",
 		},
+		{
+			Method:  "POST",
+			Path:    "/imports",
+			Handler: route.HandleDataImport,
+			Middlewares: []func(*core.RequestEvent) error{
+				authMiddleware.RequireAuthFunc(),
+				permissionMiddleware.RequirePermission(permission.DataImport),
+			},
+			Enabled:     true,
+			Description: "Upload a file and queue its import into a collection (requires auth and data.import permission)",
+		},
 		{
 			Method:  "GET",
 			Path:    "/jobs/{id}/status",
@@ -83,6 +94,138 @@ func RegisterCustom(e *core.ServeEvent) error {
 			Enabled:     true,
 			Description: "Download job file route",
 		},
+		{
+			Method:  "GET",
+			Path:    "/admin/jobs",
+			Handler: route.HandleAdminListJobs,
+			Middlewares: []func(*core.RequestEvent) error{
+				authMiddleware.RequireAuthFunc(),
+				permissionMiddleware.RequirePermission(permission.JobViewAll),
+			},
+			Enabled:     true,
+			Description: "List jobs with filters (requires auth and job.view.all permission)",
+		},
+		{
+			Method:  "DELETE",
+			Path:    "/admin/jobs",
+			Handler: route.HandleAdminPurgeJobs,
+			Middlewares: []func(*core.RequestEvent) error{
+				authMiddleware.RequireAuthFunc(),
+				permissionMiddleware.RequirePermission(permission.JobPurge),
+			},
+			Enabled:     true,
+			Description: "Purge completed, dead or canceled jobs (requires auth and job.purge permission)",
+		},
+		{
+			Method:  "GET",
+			Path:    "/admin/jobs/{id}",
+			Handler: route.HandleAdminGetJob,
+			Middlewares: []func(*core.RequestEvent) error{
+				authMiddleware.RequireAuthFunc(),
+				permissionMiddleware.RequirePermission(permission.JobViewAll),
+			},
+			Enabled:     true,
+			Description: "Get job details with payload and error history (requires auth and job.view.all permission)",
+		},
+		{
+			Method:  "GET",
+			Path:    "/admin/jobs/batches/{id}",
+			Handler: route.HandleAdminGetBatch,
+			Middlewares: []func(*core.RequestEvent) error{
+				authMiddleware.RequireAuthFunc(),
+				permissionMiddleware.RequirePermission(permission.JobViewAll),
+			},
+			Enabled:     true,
+			Description: "Get the aggregate status of a job batch (requires auth and job.view.all permission)",
+		},
+		{
+			Method:  "POST",
+			Path:    "/admin/jobs/{id}/retry",
+			Handler: route.HandleAdminRetryJob,
+			Middlewares: []func(*core.RequestEvent) error{
+				authMiddleware.RequireAuthFunc(),
+				permissionMiddleware.RequirePermission(permission.JobRetry),
+			},
+			Enabled:     true,
+			Description: "Retry a failed, dead or canceled job (requires auth and job.retry permission)",
+		},
+		{
+			Method:  "POST",
+			Path:    "/admin/jobs/{id}/cancel",
+			Handler: route.HandleAdminCancelJob,
+			Middlewares: []func(*core.RequestEvent) error{
+				authMiddleware.RequireAuthFunc(),
+				permissionMiddleware.RequirePermission(permission.JobCancel),
+			},
+			Enabled:     true,
+			Description: "Cancel a queued or running job (requires auth and job.cancel permission)",
+		},
+		{
+			Method:  "GET",
+			Path:    "/admin/crons",
+			Handler: route.HandleAdminListCrons,
+			Middlewares: []func(*core.RequestEvent) error{
+				authMiddleware.RequireAuthFunc(),
+				permissionMiddleware.RequirePermission(permission.CronView),
+			},
+			Enabled:     true,
+			Description: "List the crons with their schedule and next fire time (requires auth and cron.view permission)",
+		},
+		{
+			Method:  "GET",
+			Path:    "/admin/crons/preview",
+			Handler: route.HandleAdminPreviewCronSchedule,
+			Middlewares: []func(*core.RequestEvent) error{
+				authMiddleware.RequireAuthFunc(),
+				permissionMiddleware.RequirePermission(permission.CronView),
+			},
+			Enabled:     true,
+			Description: "Preview the next runs of a cron expression (requires auth and cron.view permission)",
+		},
+		{
+			Method:  "POST",
+			Path:    "/admin/crons/{id}/run",
+			Handler: route.HandleAdminTriggerCron,
+			Middlewares: []func(*core.RequestEvent) error{
+				authMiddleware.RequireAuthFunc(),
+				permissionMiddleware.RequirePermission(permission.CronManage),
+			},
+			Enabled:     true,
+			Description: "Run a cron now (requires auth and cron.manage permission)",
+		},
+		{
+			Method:  "POST",
+			Path:    "/admin/crons/{id}/pause",
+			Handler: route.HandleAdminPauseCron,
+			Middlewares: []func(*core.RequestEvent) error{
+				authMiddleware.RequireAuthFunc(),
+				permissionMiddleware.RequirePermission(permission.CronManage),
+			},
+			Enabled:     true,
+			Description: "Pause the scheduled runs of a cron (requires auth and cron.manage permission)",
+		},
+		{
+			Method:  "POST",
+			Path:    "/admin/crons/{id}/resume",
+			Handler: route.HandleAdminResumeCron,
+			Middlewares: []func(*core.RequestEvent) error{
+				authMiddleware.RequireAuthFunc(),
+				permissionMiddleware.RequirePermission(permission.CronManage),
+			},
+			Enabled:     true,
+			Description: "Resume the scheduled runs of a paused cron (requires auth and cron.manage permission)",
+		},
+		{
+			Method:  "GET",
+			Path:    "/admin/crons/runs",
+			Handler: route.HandleAdminListCronRuns,
+			Middlewares: []func(*core.RequestEvent) error{
+				authMiddleware.RequireAuthFunc(),
+				permissionMiddleware.RequirePermission(permission.CronView),
+			},
+			Enabled:     true,
+			Description: "List the cron run history with filters (requires auth and cron.view permission)",
+		},
 		// Add more routes here as needed:
 	}
 
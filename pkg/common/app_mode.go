@@ -0,0 +1,21 @@
+package common
+
+import "strings"
+
+// App run modes selected with the APP_MODE environment variable
+const (
+	AppModeAll    = "all"    // HTTP server, crons and job workers in one process (default)
+	AppModeHTTP   = "http"   // HTTP server and crons only, jobs are processed by worker instances
+	AppModeWorker = "worker" // Job dispatcher and worker pools only, no HTTP server or crons
+)
+
+// GetAppMode returns the run mode from APP_MODE, falling back to AppModeAll when it is
+// not set or not a known mode
+func GetAppMode() string {
+	switch mode := strings.ToLower(strings.TrimSpace(GetEnv("APP_MODE", AppModeAll))); mode {
+	case AppModeHTTP, AppModeWorker:
+		return mode
+	default:
+		return AppModeAll
+	}
+}
@@ -0,0 +1,28 @@
+package common
+
+import "testing"
+
+func TestGetAppMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "not set", value: "", expected: AppModeAll},
+		{name: "all", value: "all", expected: AppModeAll},
+		{name: "http", value: "http", expected: AppModeHTTP},
+		{name: "worker", value: "worker", expected: AppModeWorker},
+		{name: "case and spaces are ignored", value: " Worker ", expected: AppModeWorker},
+		{name: "unknown mode", value: "scheduler", expected: AppModeAll},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("APP_MODE", tt.value)
+
+			if got := GetAppMode(); got != tt.expected {
+				t.Errorf("expected mode %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
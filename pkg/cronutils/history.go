@@ -0,0 +1,479 @@
+package cronutils
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/common"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// CronRunsCollection is the collection cron runs are recorded in
+const CronRunsCollection = "cron_runs"
+
+// Cron run statuses
+const (
+	CronRunStatusRunning = "running"
+	CronRunStatusSuccess = "success"
+	CronRunStatusFailed  = "failed"
+	CronRunStatusSkipped = "skipped"
+)
+
+// Cron run triggers
+const (
+	CronRunTriggerSchedule = "schedule"
+	CronRunTriggerManual   = "manual"
+)
+
+// Cron run history defaults
+const (
+	// DefaultCronLockTimeout is how long a run holds its cron's lock before it is considered abandoned
+	DefaultCronLockTimeout = time.Hour
+
+	// DefaultCronRunHistoryLimit is how many runs are kept per cron (CRON_RUN_HISTORY_LIMIT)
+	DefaultCronRunHistoryLimit = 100
+
+	DefaultCronRunListPerPage = 30
+	MaxCronRunListPerPage     = 200
+)
+
+var (
+	// ErrInvalidCronRunStatus is returned for a status filter that is not a cron run status
+	ErrInvalidCronRunStatus = errors.New("invalid cron run status")
+
+	// ErrCronRunning is returned when triggering a cron whose previous run is still going
+	ErrCronRunning = errors.New("cron is already running")
+
+	// errNoRunHistory is returned when the cron_runs collection does not exist yet
+	errNoRunHistory = errors.New("cron run history not available")
+)
+
+// CronRunStatuses lists every cron run status
+var CronRunStatuses = []string{
+	CronRunStatusRunning,
+	CronRunStatusSuccess,
+	CronRunStatusFailed,
+	CronRunStatusSkipped,
+}
+
+// activeRuns holds the failure of the run of each cron in progress in this process, by cron ID
+var activeRuns sync.Map
+
+// activeRun collects the failure of a cron run while it is in progress
+type activeRun struct {
+	mu  sync.Mutex
+	err error
+}
+
+// fail records the first failure of the run
+func (r *activeRun) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// failure returns the failure of the run, nil when it succeeded
+func (r *activeRun) failure() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// failActiveRun marks the run of a cron in progress in this process as failed
+func failActiveRun(cronID string, err error) {
+	if run, ok := activeRuns.Load(cronID); ok {
+		run.(*activeRun).fail(err)
+	}
+}
+
+// instanceName identifies this server instance in the run history
+var instanceName = sync.OnceValue(func() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+})
+
+// WithRunHistory wraps a cron function so each run is recorded in the cron_runs collection with
+// its duration, outcome and error. A run is skipped (and recorded as skipped) while a previous
+// run of the same cron still holds the lock, on this or any other instance sharing the database.
+// A lock older than lockTimeout (DefaultCronLockTimeout when 0) is treated as abandoned.
+// A run fails when the cron panics or logs an error through its CronExecutionContext.
+// Runs of a paused cron are skipped without being recorded.
+func WithRunHistory(app core.App, cronID string, lockTimeout time.Duration, jobFunc func()) func() {
+	if lockTimeout <= 0 {
+		lockTimeout = DefaultCronLockTimeout
+	}
+
+	return func() {
+		if IsCronPaused(app, cronID) {
+			log.Debug("Skipped paused cron", "cron_id", cronID)
+			return
+		}
+
+		startedAt := time.Now()
+
+		run, holder, err := acquireCronRun(app, cronID, CronRunTriggerSchedule, lockTimeout, startedAt)
+		if errors.Is(err, errNoRunHistory) {
+			// the history must not stop the crons before the migrations ran
+			log.Warn("Cron run history not available, running without overlap protection", "cron_id", cronID, "error", err)
+			jobFunc()
+			return
+		}
+		if err != nil {
+			// without the lock the run could overlap another one, the next tick tries again
+			log.Error("Failed to take cron lock, skipping run", "cron_id", cronID, "error", err)
+			return
+		}
+
+		if run == nil {
+			recordSkippedRun(app, cronID, holder, startedAt)
+			return
+		}
+
+		executeCronRun(app, run, startedAt, jobFunc)
+	}
+}
+
+// StartCronRun runs a cron now, outside its schedule and even when it is paused, as a recorded
+// run in the background. It returns the started run and a channel that receives the run once it
+// finished, or ErrCronRunning when a previous run of the cron still holds the lock.
+func StartCronRun(app core.App, cronID string, lockTimeout time.Duration, jobFunc func()) (*CronRun, <-chan *CronRun, error) {
+	if lockTimeout <= 0 {
+		lockTimeout = DefaultCronLockTimeout
+	}
+
+	startedAt := time.Now()
+
+	run, holder, err := acquireCronRun(app, cronID, CronRunTriggerManual, lockTimeout, startedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if run == nil {
+		if holder != nil {
+			return nil, nil, fmt.Errorf("%w: run %s started at %s", ErrCronRunning, holder.Id, holder.GetDateTime("started_at").String())
+		}
+		return nil, nil, ErrCronRunning
+	}
+
+	started := CronRunFromRecord(run)
+
+	done := make(chan *CronRun, 1)
+	go func() {
+		executeCronRun(app, run, startedAt, jobFunc)
+		done <- CronRunFromRecord(run)
+	}()
+
+	return started, done, nil
+}
+
+// executeCronRun runs the cron function of a started run and records its outcome
+func executeCronRun(app core.App, run *core.Record, startedAt time.Time, jobFunc func()) {
+	cronID := run.GetString("cron_id")
+
+	active := &activeRun{}
+	activeRuns.Store(cronID, active)
+	defer activeRuns.Delete(cronID)
+
+	jobFunc()
+
+	finishCronRun(app, run, active.failure(), startedAt)
+	pruneCronRuns(app, cronID, common.GetEnvInt("CRON_RUN_HISTORY_LIMIT", DefaultCronRunHistoryLimit))
+}
+
+// acquireCronRun records a running run of the cron, which holds the cron's lock until it
+// finishes. When another run still holds the lock, it returns no run and that other run
+// (nil when it is not known).
+func acquireCronRun(app core.App, cronID string, trigger string, lockTimeout time.Duration, now time.Time) (*core.Record, *core.Record, error) {
+	collection, err := app.FindCachedCollectionByNameOrId(CronRunsCollection)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", errNoRunHistory, err)
+	}
+
+	var run, holder *core.Record
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		running, err := txApp.FindFirstRecordByFilter(
+			CronRunsCollection,
+			"cron_id = {:cronId} && status = {:status}",
+			dbx.Params{"cronId": cronID, "status": CronRunStatusRunning},
+		)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if running != nil {
+			if running.GetDateTime("started_at").Time().Add(lockTimeout).After(now) {
+				holder = running
+				return nil
+			}
+
+			// the instance running it stopped or the run hangs, release its lock
+			running.Set("status", CronRunStatusFailed)
+			running.Set("finished_at", now)
+			running.Set("error", fmt.Sprintf("run abandoned: still running after the %s lock timeout", lockTimeout))
+			if err := txApp.Save(running); err != nil {
+				return fmt.Errorf("failed to release abandoned cron run %s: %w", running.Id, err)
+			}
+
+			log.Warn("Released abandoned cron run", "cron_id", cronID, "run_id", running.Id, "instance", running.GetString("instance"))
+		}
+
+		record := core.NewRecord(collection)
+		record.Set("cron_id", cronID)
+		record.Set("status", CronRunStatusRunning)
+		record.Set("trigger", trigger)
+		record.Set("instance", instanceName())
+		record.Set("started_at", now)
+
+		if err := txApp.Save(record); err != nil {
+			return err
+		}
+
+		run = record
+		return nil
+	})
+
+	// the unique running index rejects a run another instance started at the same time
+	var validationErrs validation.Errors
+	if errors.As(err, &validationErrs) && validationErrs["cron_id"] != nil {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return run, holder, nil
+}
+
+// recordSkippedRun records a run skipped because a previous run still holds the lock
+func recordSkippedRun(app core.App, cronID string, holder *core.Record, now time.Time) {
+	reason := "previous run still running"
+	if holder != nil {
+		reason = fmt.Sprintf("previous run %s still running on %s since %s",
+			holder.Id, holder.GetString("instance"), holder.GetDateTime("started_at").String())
+	}
+
+	log.Warn("Skipped cron run, previous run still running", "cron_id", cronID, "reason", reason)
+
+	collection, err := app.FindCachedCollectionByNameOrId(CronRunsCollection)
+	if err != nil {
+		return
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("cron_id", cronID)
+	record.Set("status", CronRunStatusSkipped)
+	record.Set("trigger", CronRunTriggerSchedule)
+	record.Set("instance", instanceName())
+	record.Set("started_at", now)
+	record.Set("finished_at", now)
+	record.Set("error", reason)
+
+	if err := app.Save(record); err != nil {
+		log.Error("Failed to record skipped cron run", "cron_id", cronID, "error", err)
+	}
+}
+
+// finishCronRun records the outcome of a run and releases the cron's lock
+func finishCronRun(app core.App, run *core.Record, failure error, startedAt time.Time) {
+	status := CronRunStatusSuccess
+	message := ""
+	if failure != nil {
+		status = CronRunStatusFailed
+		message = failure.Error()
+	}
+
+	run.Set("status", status)
+	run.Set("finished_at", time.Now())
+	run.Set("duration_ms", time.Since(startedAt).Milliseconds())
+	run.Set("error", message)
+
+	if err := app.Save(run); err != nil {
+		log.Error("Failed to record cron run outcome", "cron_id", run.GetString("cron_id"), "run_id", run.Id, "error", err)
+	}
+}
+
+// pruneCronRuns deletes the finished runs of a cron beyond the newest limit runs
+func pruneCronRuns(app core.App, cronID string, limit int) {
+	if limit <= 0 {
+		return
+	}
+
+	_, err := app.NonconcurrentDB().NewQuery(`
+		DELETE FROM {{cron_runs}}
+		WHERE [[cron_id]] = {:cronId} AND [[status]] != {:running} AND [[id]] NOT IN (
+			SELECT [[id]] FROM {{cron_runs}} WHERE [[cron_id]] = {:cronId}
+			ORDER BY [[started_at]] DESC, [[id]] DESC LIMIT {:limit}
+		)
+	`).Bind(dbx.Params{"cronId": cronID, "running": CronRunStatusRunning, "limit": limit}).Execute()
+	if err != nil {
+		log.Error("Failed to prune cron run history", "cron_id", cronID, "error", err)
+	}
+}
+
+// CronRun is a recorded run of a cron
+type CronRun struct {
+	ID         string     `json:"id"`
+	CronID     string     `json:"cron_id"`
+	Status     string     `json:"status"`
+	Trigger    string     `json:"trigger"`
+	Instance   string     `json:"instance"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	DurationMs int64      `json:"duration_ms"`
+	Error      string     `json:"error"`
+}
+
+// CronRunFromRecord builds a cron run from its cron_runs record
+func CronRunFromRecord(record *core.Record) *CronRun {
+	return &CronRun{
+		ID:         record.Id,
+		CronID:     record.GetString("cron_id"),
+		Status:     record.GetString("status"),
+		Trigger:    record.GetString("trigger"),
+		Instance:   record.GetString("instance"),
+		StartedAt:  optionalTime(record.GetDateTime("started_at")),
+		FinishedAt: optionalTime(record.GetDateTime("finished_at")),
+		DurationMs: int64(record.GetInt("duration_ms")),
+		Error:      record.GetString("error"),
+	}
+}
+
+// ToMap returns the run as response data
+func (r *CronRun) ToMap() map[string]any {
+	return map[string]any{
+		"id":          r.ID,
+		"cron_id":     r.CronID,
+		"status":      r.Status,
+		"trigger":     r.Trigger,
+		"instance":    r.Instance,
+		"started_at":  r.StartedAt,
+		"finished_at": r.FinishedAt,
+		"duration_ms": r.DurationMs,
+		"error":       r.Error,
+	}
+}
+
+// CronRunFilter narrows down the runs returned by ListCronRuns. Empty fields are not filtered on.
+type CronRunFilter struct {
+	CronID      string    // Cron ID (e.g. system_queue)
+	Status      string    // Run status
+	StartedFrom time.Time // Runs started at or after this time
+	StartedTo   time.Time // Runs started at or before this time
+	Page        int       // 1-based page number
+	PerPage     int       // Runs per page (DefaultCronRunListPerPage when 0, at most MaxCronRunListPerPage)
+}
+
+// CronRunList is a page of runs returned by ListCronRuns
+type CronRunList struct {
+	Page       int        `json:"page"`
+	PerPage    int        `json:"per_page"`
+	TotalItems int64      `json:"total_items"`
+	TotalPages int        `json:"total_pages"`
+	Items      []*CronRun `json:"items"`
+}
+
+// normalize validates the filter and applies the paging defaults
+func (f *CronRunFilter) normalize() error {
+	if f.Status != "" && !slices.Contains(CronRunStatuses, f.Status) {
+		return fmt.Errorf("%w: %s", ErrInvalidCronRunStatus, f.Status)
+	}
+
+	if !f.StartedFrom.IsZero() && !f.StartedTo.IsZero() && f.StartedFrom.After(f.StartedTo) {
+		return fmt.Errorf("started from date must be before the started to date")
+	}
+
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.PerPage <= 0 {
+		f.PerPage = DefaultCronRunListPerPage
+	}
+	f.PerPage = min(f.PerPage, MaxCronRunListPerPage)
+
+	return nil
+}
+
+// expressions returns the where expressions of the filter
+func (f *CronRunFilter) expressions() []dbx.Expression {
+	exprs := []dbx.Expression{}
+
+	if f.CronID != "" {
+		exprs = append(exprs, dbx.HashExp{"cron_id": f.CronID})
+	}
+	if f.Status != "" {
+		exprs = append(exprs, dbx.HashExp{"status": f.Status})
+	}
+	if !f.StartedFrom.IsZero() {
+		from, _ := types.ParseDateTime(f.StartedFrom)
+		exprs = append(exprs, dbx.NewExp("[[started_at]] >= {:from}", dbx.Params{"from": from.String()}))
+	}
+	if !f.StartedTo.IsZero() {
+		to, _ := types.ParseDateTime(f.StartedTo)
+		exprs = append(exprs, dbx.NewExp("[[started_at]] <= {:to}", dbx.Params{"to": to.String()}))
+	}
+
+	return exprs
+}
+
+// ListCronRuns returns a page of cron runs matching the filter, newest first
+func ListCronRuns(app core.App, filter CronRunFilter) (*CronRunList, error) {
+	if err := filter.normalize(); err != nil {
+		return nil, err
+	}
+
+	exprs := filter.expressions()
+
+	total, err := app.CountRecords(CronRunsCollection, exprs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count cron runs: %w", err)
+	}
+
+	records := []*core.Record{}
+	err = app.RecordQuery(CronRunsCollection).
+		AndWhere(dbx.And(exprs...)).
+		OrderBy("started_at DESC", "id DESC").
+		Limit(int64(filter.PerPage)).
+		Offset(int64((filter.Page - 1) * filter.PerPage)).
+		All(&records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch cron runs: %w", err)
+	}
+
+	items := make([]*CronRun, 0, len(records))
+	for _, record := range records {
+		items = append(items, CronRunFromRecord(record))
+	}
+
+	return &CronRunList{
+		Page:       filter.Page,
+		PerPage:    filter.PerPage,
+		TotalItems: total,
+		TotalPages: int((total + int64(filter.PerPage) - 1) / int64(filter.PerPage)),
+		Items:      items,
+	}, nil
+}
+
+// optionalTime returns nil for an unset date
+func optionalTime(value types.DateTime) *time.Time {
+	if value.IsZero() {
+		return nil
+	}
+	t := value.Time()
+	return &t
+}
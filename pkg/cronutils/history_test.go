@@ -0,0 +1,183 @@
+package cronutils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func newTestCronRunRecord() *core.Record {
+	collection := core.NewBaseCollection(CronRunsCollection)
+	collection.Fields.Add(
+		&core.TextField{Name: "cron_id"},
+		&core.SelectField{Name: "status", MaxSelect: 1, Values: CronRunStatuses},
+		&core.TextField{Name: "instance"},
+		&core.DateField{Name: "started_at"},
+		&core.DateField{Name: "finished_at"},
+		&core.NumberField{Name: "duration_ms", OnlyInt: true},
+		&core.TextField{Name: "error"},
+	)
+
+	record := core.NewRecord(collection)
+	record.Id = "run-1"
+	record.Set("cron_id", "system_queue")
+	record.Set("instance", "host:42")
+	return record
+}
+
+func TestCronRunFromRecord(t *testing.T) {
+	startedAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	record := newTestCronRunRecord()
+	record.Set("status", CronRunStatusFailed)
+	record.Set("started_at", startedAt)
+	record.Set("finished_at", startedAt.Add(1500*time.Millisecond))
+	record.Set("duration_ms", 1500)
+	record.Set("error", "Cleanup had errors")
+
+	run := CronRunFromRecord(record)
+
+	if run.ID != "run-1" || run.CronID != "system_queue" || run.Instance != "host:42" {
+		t.Errorf("unexpected run identity: %+v", run)
+	}
+	if run.Status != CronRunStatusFailed {
+		t.Errorf("expected status %q, got %q", CronRunStatusFailed, run.Status)
+	}
+	if run.StartedAt == nil || !run.StartedAt.Equal(startedAt) {
+		t.Errorf("expected started_at %v, got %v", startedAt, run.StartedAt)
+	}
+	if run.DurationMs != 1500 {
+		t.Errorf("expected duration 1500ms, got %d", run.DurationMs)
+	}
+	if run.Error != "Cleanup had errors" {
+		t.Errorf("unexpected error %q", run.Error)
+	}
+
+	data := run.ToMap()
+	if data["status"] != CronRunStatusFailed || data["duration_ms"] != int64(1500) {
+		t.Errorf("unexpected response data: %v", data)
+	}
+}
+
+func TestCronRunFromRecordRunning(t *testing.T) {
+	record := newTestCronRunRecord()
+	record.Set("status", CronRunStatusRunning)
+	record.Set("started_at", time.Now())
+
+	run := CronRunFromRecord(record)
+
+	if run.FinishedAt != nil {
+		t.Errorf("expected no finished_at for a running run, got %v", run.FinishedAt)
+	}
+}
+
+func TestCronRunFilterNormalize(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		filter      CronRunFilter
+		expectError bool
+		wantPerPage int
+	}{
+		{"defaults", CronRunFilter{}, false, DefaultCronRunListPerPage},
+		{"valid status", CronRunFilter{Status: CronRunStatusSkipped}, false, DefaultCronRunListPerPage},
+		{"per page capped", CronRunFilter{PerPage: 1000}, false, MaxCronRunListPerPage},
+		{"invalid status", CronRunFilter{Status: "done"}, true, 0},
+		{"from after to", CronRunFilter{StartedFrom: now, StartedTo: now.Add(-time.Hour)}, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.normalize()
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.filter.Page != 1 {
+				t.Errorf("expected page 1, got %d", tt.filter.Page)
+			}
+			if tt.filter.PerPage != tt.wantPerPage {
+				t.Errorf("expected per page %d, got %d", tt.wantPerPage, tt.filter.PerPage)
+			}
+		})
+	}
+
+	filter := CronRunFilter{Status: "done"}
+	if err := filter.normalize(); !errors.Is(err, ErrInvalidCronRunStatus) {
+		t.Errorf("expected ErrInvalidCronRunStatus, got %v", err)
+	}
+}
+
+func TestCronRunFilterExpressions(t *testing.T) {
+	filter := CronRunFilter{
+		CronID:      "system_queue",
+		Status:      CronRunStatusFailed,
+		StartedFrom: time.Now().Add(-time.Hour),
+		StartedTo:   time.Now(),
+	}
+
+	if got := len(filter.expressions()); got != 4 {
+		t.Errorf("expected 4 expressions, got %d", got)
+	}
+	if got := len((&CronRunFilter{}).expressions()); got != 0 {
+		t.Errorf("expected no expressions for an empty filter, got %d", got)
+	}
+}
+
+func TestActiveRunFailure(t *testing.T) {
+	app := pocketbase.New()
+	cronID := "test-active-run"
+
+	active := &activeRun{}
+	activeRuns.Store(cronID, active)
+	defer activeRuns.Delete(cronID)
+
+	ctx := NewCronExecutionContext(app, cronID)
+	ctx.LogEnd("nothing failed yet")
+	if err := active.failure(); err != nil {
+		t.Fatalf("expected no failure, got %v", err)
+	}
+
+	ctx.LogError(fmt.Errorf("disk full"), "Failed to delete export file")
+	WithRecovery(app, cronID, func() { panic("later panic") })()
+
+	err := active.failure()
+	if err == nil {
+		t.Fatal("expected the run to be failed")
+	}
+	if !strings.Contains(err.Error(), "Failed to delete export file") || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("expected the first failure to be kept, got %q", err)
+	}
+}
+
+func TestActiveRunPanic(t *testing.T) {
+	app := pocketbase.New()
+	cronID := "test-active-panic"
+
+	active := &activeRun{}
+	activeRuns.Store(cronID, active)
+	defer activeRuns.Delete(cronID)
+
+	WithRecovery(app, cronID, func() { panic("boom") })()
+
+	if err := active.failure(); err == nil || !strings.Contains(err.Error(), "panic: boom") {
+		t.Errorf("expected the panic to fail the run, got %v", err)
+	}
+
+	// errors logged for other crons do not fail the run
+	NewCronExecutionContext(app, "other-cron").LogError(nil, "unrelated")
+	if err := active.failure(); strings.Contains(err.Error(), "unrelated") {
+		t.Errorf("unexpected failure from another cron: %v", err)
+	}
+}
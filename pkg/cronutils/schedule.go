@@ -0,0 +1,520 @@
+package cronutils
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/cron"
+)
+
+// nextRunSearchLimit bounds the search for the next run of a schedule that never matches
+// (e.g. February 31st). It spans a leap day, so February 29th schedules are found.
+const nextRunSearchLimit = 5 * 366 * 24 * time.Hour
+
+// everyMinute is the expression a schedule the PocketBase cron cannot express is registered
+// with; the run is then gated on the schedule itself
+const everyMinute = "* * * * *"
+
+const (
+	// DefaultPreviewRuns is the number of runs a schedule preview lists by default
+	DefaultPreviewRuns = 5
+
+	// MaxPreviewRuns is the maximum number of runs a schedule preview lists
+	MaxPreviewRuns = 100
+)
+
+var (
+	// ErrNoNextRun is returned when a schedule has no run within the next years
+	ErrNoNextRun = errors.New("schedule has no run within five years")
+
+	// ErrSecondsNotSupported is returned when adding a schedule with a seconds field to a
+	// minute based cron
+	ErrSecondsNotSupported = errors.New("the seconds field is not supported, crons run at most once a minute")
+)
+
+// scheduleMacros are the supported @ shortcuts and the expressions they stand for
+var scheduleMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+var weekdayNames = map[string]int{
+	"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+}
+
+// scheduleField describes the values a field of a cron expression accepts
+type scheduleField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField  = scheduleField{name: "second", min: 0, max: 59}
+	minuteField  = scheduleField{name: "minute", min: 0, max: 59}
+	hourField    = scheduleField{name: "hour", min: 0, max: 23}
+	dayField     = scheduleField{name: "day of month", min: 1, max: 31}
+	monthField   = scheduleField{name: "month", min: 1, max: 12, names: monthNames}
+	weekdayField = scheduleField{name: "day of week", min: 0, max: 7, names: weekdayNames}
+)
+
+// Schedule is a parsed cron expression evaluated in a timezone.
+//
+// Expressions have 5 fields (minute hour day-of-month month day-of-week) or 6 with a leading
+// seconds field. Fields accept *, values, ranges (1-5), lists (1,15), steps (*/5, 10-40/10, 5/15),
+// month names (JAN-DEC) and weekday names (SUN-SAT). ? is an alias of * in the day fields.
+// The day-of-month field accepts L (last day of the month), and the day-of-week field accepts
+// 5L (last Friday of the month) and MON#2 (second Monday of the month). When both day fields are
+// restricted, a day matches either of them, as in standard cron. The @yearly, @annually,
+// @monthly, @weekly, @daily, @midnight and @hourly macros are supported, and an expression can
+// start with TZ=<IANA zone> (or CRON_TZ=) to be evaluated in that timezone.
+type Schedule struct {
+	Expr     string         // Expression the schedule was parsed from
+	Location *time.Location // Timezone the schedule is evaluated in
+
+	seconds  uint64
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+
+	lastDay      bool     // L in the day-of-month field
+	lastWeekdays uint64   // weekdays with an L modifier (e.g. 5L)
+	nthWeekdays  [7]uint8 // occurrences (bits 1-5) of a weekday with a # modifier (e.g. 1#2)
+
+	hasSeconds  bool
+	anyDay      bool // the day-of-month field is * or ?
+	anyWeekday  bool // the day-of-week field is * or ?
+	nthModifier bool // the day fields use L or #
+}
+
+// ParseSchedule parses a cron expression evaluated in location (UTC when nil). A TZ= prefix
+// in the expression takes precedence over location.
+func ParseSchedule(expr string, location *time.Location) (*Schedule, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	schedule := &Schedule{Expr: expr, Location: location}
+
+	fields := strings.Fields(expr)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("cron expression cannot be empty")
+	}
+
+	if zone, ok := cutTimezonePrefix(fields[0]); ok {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron timezone %q: %w", zone, err)
+		}
+		schedule.Location = loc
+		fields = fields[1:]
+	}
+
+	if len(fields) == 1 && strings.HasPrefix(fields[0], "@") {
+		macro, ok := scheduleMacros[strings.ToLower(fields[0])]
+		if !ok {
+			return nil, fmt.Errorf("unknown cron macro %s", fields[0])
+		}
+		fields = strings.Fields(macro)
+	}
+
+	if len(fields) != 5 && len(fields) != 6 {
+		return nil, fmt.Errorf("invalid cron expression: expected 5 or 6 fields, got %d", len(fields))
+	}
+
+	if len(fields) == 6 {
+		schedule.hasSeconds = true
+		seconds, err := parseScheduleField(fields[0], secondField)
+		if err != nil {
+			return nil, err
+		}
+		schedule.seconds = seconds
+		fields = fields[1:]
+	} else {
+		schedule.seconds = 1 // second 0
+	}
+
+	var err error
+	if schedule.minutes, err = parseScheduleField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if schedule.hours, err = parseScheduleField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if err := schedule.parseDayField(fields[2]); err != nil {
+		return nil, err
+	}
+	if schedule.months, err = parseScheduleField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if err := schedule.parseWeekdayField(fields[4]); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// cutTimezonePrefix returns the zone of a TZ= or CRON_TZ= prefix
+func cutTimezonePrefix(field string) (string, bool) {
+	for _, prefix := range []string{"TZ=", "CRON_TZ="} {
+		if zone, ok := strings.CutPrefix(field, prefix); ok {
+			return zone, true
+		}
+	}
+	return "", false
+}
+
+// parseDayField parses the day-of-month field, which also accepts L
+func (s *Schedule) parseDayField(field string) error {
+	s.anyDay = field == "*" || field == "?"
+
+	parts := []string{}
+	for _, part := range strings.Split(field, ",") {
+		if strings.EqualFold(part, "L") {
+			s.lastDay = true
+			s.nthModifier = true
+			continue
+		}
+		parts = append(parts, part)
+	}
+
+	if len(parts) == 0 {
+		return nil
+	}
+
+	days, err := parseScheduleField(strings.Join(parts, ","), dayField)
+	if err != nil {
+		return err
+	}
+	s.days = days
+	return nil
+}
+
+// parseWeekdayField parses the day-of-week field, which also accepts the L and # modifiers
+func (s *Schedule) parseWeekdayField(field string) error {
+	s.anyWeekday = field == "*" || field == "?"
+
+	parts := []string{}
+	for _, part := range strings.Split(field, ",") {
+		upper := strings.ToUpper(part)
+
+		if value, ok := strings.CutSuffix(upper, "L"); ok && value != "" {
+			weekday, err := parseScheduleValue(value, weekdayField)
+			if err != nil {
+				return err
+			}
+			s.lastWeekdays |= 1 << (weekday % 7)
+			s.nthModifier = true
+			continue
+		}
+
+		if value, nth, ok := strings.Cut(upper, "#"); ok {
+			weekday, err := parseScheduleValue(value, weekdayField)
+			if err != nil {
+				return err
+			}
+			n, err := strconv.Atoi(nth)
+			if err != nil || n < 1 || n > 5 {
+				return fmt.Errorf("invalid cron field %s: %q must be followed by #1 to #5", weekdayField.name, part)
+			}
+			s.nthWeekdays[weekday%7] |= 1 << n
+			s.nthModifier = true
+			continue
+		}
+
+		parts = append(parts, part)
+	}
+
+	if len(parts) == 0 {
+		return nil
+	}
+
+	weekdays, err := parseScheduleField(strings.Join(parts, ","), weekdayField)
+	if err != nil {
+		return err
+	}
+
+	// 7 is Sunday as well
+	if weekdays&(1<<7) != 0 {
+		weekdays = weekdays&^(1<<7) | 1
+	}
+	s.weekdays = weekdays
+	return nil
+}
+
+// parseScheduleField parses a comma separated list of values, ranges and steps into a bit set
+func parseScheduleField(field string, spec scheduleField) (uint64, error) {
+	var set uint64
+
+	for _, part := range strings.Split(field, ",") {
+		bits, err := parseScheduleRange(part, spec)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cron field %s: %w", spec.name, err)
+		}
+		set |= bits
+	}
+
+	return set, nil
+}
+
+// parseScheduleRange parses a single value, range or step of a field into a bit set
+func parseScheduleRange(part string, spec scheduleField) (uint64, error) {
+	if part == "" {
+		return 0, fmt.Errorf("empty value")
+	}
+
+	base, stepValue, hasStep := strings.Cut(part, "/")
+
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepValue)
+		if err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid step %q", stepValue)
+		}
+	}
+
+	var start, end int
+	switch {
+	case base == "*" || (base == "?" && (spec.name == dayField.name || spec.name == weekdayField.name)):
+		start, end = spec.min, spec.max
+	case strings.Contains(base, "-"):
+		from, to, _ := strings.Cut(base, "-")
+		var err error
+		if start, err = parseScheduleValue(from, spec); err != nil {
+			return 0, err
+		}
+		if end, err = parseScheduleValue(to, spec); err != nil {
+			return 0, err
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range %q: start is after end", base)
+		}
+	default:
+		var err error
+		if start, err = parseScheduleValue(base, spec); err != nil {
+			return 0, err
+		}
+		end = start
+		if hasStep {
+			// 5/15 means every 15 starting at 5
+			end = spec.max
+		}
+	}
+
+	var set uint64
+	for value := start; value <= end; value += step {
+		set |= 1 << value
+	}
+	return set, nil
+}
+
+// parseScheduleValue parses a number or a name of a field
+func parseScheduleValue(value string, spec scheduleField) (int, error) {
+	if n, ok := spec.names[strings.ToUpper(value)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	if n < spec.min || n > spec.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", n, spec.min, spec.max)
+	}
+	return n, nil
+}
+
+// HasSeconds reports whether the expression has a seconds field
+func (s *Schedule) HasSeconds() bool {
+	return s.hasSeconds
+}
+
+// Matches reports whether the schedule runs at the second of t
+func (s *Schedule) Matches(t time.Time) bool {
+	t = t.In(s.Location)
+	return s.IsDue(t) && hasBit(s.seconds, t.Second())
+}
+
+// IsDue reports whether the schedule runs in the minute of t
+func (s *Schedule) IsDue(t time.Time) bool {
+	t = t.In(s.Location)
+	return hasBit(s.months, int(t.Month())) &&
+		s.dayMatches(t) &&
+		hasBit(s.hours, t.Hour()) &&
+		hasBit(s.minutes, t.Minute())
+}
+
+// dayMatches reports whether the schedule runs on the day of t
+func (s *Schedule) dayMatches(t time.Time) bool {
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyWeekday:
+		return s.dayOfMonthMatches(t)
+	case s.anyDay:
+		return s.dayOfWeekMatches(t)
+	default:
+		return s.dayOfMonthMatches(t) || s.dayOfWeekMatches(t)
+	}
+}
+
+// dayOfMonthMatches reports whether the day-of-month field matches the day of t
+func (s *Schedule) dayOfMonthMatches(t time.Time) bool {
+	return hasBit(s.days, t.Day()) || (s.lastDay && t.Day() == daysInMonth(t))
+}
+
+// dayOfWeekMatches reports whether the day-of-week field matches the day of t
+func (s *Schedule) dayOfWeekMatches(t time.Time) bool {
+	weekday := int(t.Weekday())
+	occurrence := (t.Day()-1)/7 + 1
+
+	return hasBit(s.weekdays, weekday) ||
+		(hasBit(s.lastWeekdays, weekday) && t.Day()+7 > daysInMonth(t)) ||
+		s.nthWeekdays[weekday]&(1<<occurrence) != 0
+}
+
+// Next returns the first time after t the schedule runs at: the start of a minute, or the
+// second for expressions with a seconds field
+func (s *Schedule) Next(after time.Time) (time.Time, error) {
+	t := after.In(s.Location)
+	if s.hasSeconds {
+		t = t.Truncate(time.Second).Add(time.Second)
+	} else {
+		t = t.Truncate(time.Minute).Add(time.Minute)
+	}
+	limit := t.Add(nextRunSearchLimit)
+
+	for t.Before(limit) {
+		// skip whole months, days, hours and minutes that cannot match
+		var next time.Time
+		switch {
+		case !hasBit(s.months, int(t.Month())):
+			next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.Location)
+		case !s.dayMatches(t):
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.Location)
+		case !hasBit(s.hours, t.Hour()):
+			next = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.Location)
+		case !hasBit(s.minutes, t.Minute()):
+			next = t.Truncate(time.Minute).Add(time.Minute)
+		case !hasBit(s.seconds, t.Second()):
+			next = t.Add(time.Second)
+		default:
+			return t, nil
+		}
+
+		// around daylight saving changes a wall clock time can map to an earlier instant
+		if !next.After(t) {
+			next = t.Truncate(time.Minute).Add(time.Minute)
+		}
+		t = next
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %s", ErrNoNextRun, s.Expr)
+}
+
+// NextRuns returns the next n times after t the schedule runs at
+func (s *Schedule) NextRuns(after time.Time, n int) ([]time.Time, error) {
+	runs := make([]time.Time, 0, n)
+	for len(runs) < n {
+		next, err := s.Next(after)
+		if err != nil {
+			if len(runs) > 0 && errors.Is(err, ErrNoNextRun) {
+				break
+			}
+			return nil, err
+		}
+		runs = append(runs, next)
+		after = next
+	}
+	return runs, nil
+}
+
+// NextRuns parses a cron expression and returns its next n runs from now, e.g. to preview a
+// schedule. The expression is evaluated in UTC unless it starts with TZ=.
+func NextRuns(expr string, n int) ([]time.Time, error) {
+	schedule, err := ParseSchedule(expr, nil)
+	if err != nil {
+		return nil, err
+	}
+	return schedule.NextRuns(time.Now(), n)
+}
+
+// pocketBaseExpr returns the schedule as an expression of the PocketBase cron, which runs in UTC
+// and has no seconds, names, L and # modifiers, or "either day" matching. It reports false when
+// the schedule cannot be expressed that way.
+func (s *Schedule) pocketBaseExpr() (string, bool) {
+	if s.hasSeconds || s.nthModifier || s.Location.String() != time.UTC.String() || (!s.anyDay && !s.anyWeekday) {
+		return "", false
+	}
+
+	return strings.Join([]string{
+		bitSetExpr(s.minutes, minuteField.min, minuteField.max),
+		bitSetExpr(s.hours, hourField.min, hourField.max),
+		bitSetExpr(s.days, dayField.min, dayField.max),
+		bitSetExpr(s.months, monthField.min, monthField.max),
+		bitSetExpr(s.weekdays, 0, 6),
+	}, " "), true
+}
+
+// AddToCron registers fn to run on the schedule with a PocketBase cron. Schedules the PocketBase
+// cron can express are registered as they are, so they also show up and can be run from the
+// dashboard. Other schedules (timezones, names resolved, L and # modifiers) are registered to
+// run every minute and only call fn when the schedule is due.
+func AddToCron(c *cron.Cron, id string, schedule *Schedule, fn func()) error {
+	if schedule.hasSeconds {
+		return fmt.Errorf("%w: %s", ErrSecondsNotSupported, schedule.Expr)
+	}
+
+	if expr, ok := schedule.pocketBaseExpr(); ok {
+		return c.Add(id, expr, fn)
+	}
+
+	return c.Add(id, everyMinute, func() {
+		if schedule.IsDue(time.Now()) {
+			fn()
+		}
+	})
+}
+
+// bitSetExpr returns * for a full set, otherwise the comma separated values of the set
+func bitSetExpr(set uint64, min, max int) string {
+	full := uint64(1)<<(max+1) - uint64(1)<<min
+	if set&full == full {
+		return "*"
+	}
+
+	values := make([]string, 0, bits.OnesCount64(set))
+	for value := min; value <= max; value++ {
+		if hasBit(set, value) {
+			values = append(values, strconv.Itoa(value))
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+// hasBit reports whether value is in the bit set
+func hasBit(set uint64, value int) bool {
+	return set&(1<<value) != 0
+}
+
+// daysInMonth returns the number of days of the month of t
+func daysInMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
@@ -0,0 +1,227 @@
+package cronutils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/cron"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		name        string
+		expr        string
+		expectError bool
+	}{
+		// Valid expressions
+		{"numeric", "0 2 * * *", false},
+		{"weekday names", "0 9 * * MON-FRI", false},
+		{"lowercase names", "0 9 * jan,jul sun", false},
+		{"question mark day", "0 9 ? * MON", false},
+		{"last day of month", "0 12 L * *", false},
+		{"last weekday of month", "0 12 * * 5L", false},
+		{"nth weekday of month", "0 12 * * MON#2", false},
+		{"step from a value", "5/15 * * * *", false},
+		{"macro", "@daily", false},
+		{"macro with timezone", "TZ=Europe/Paris @hourly", false},
+		{"timezone prefix", "CRON_TZ=America/New_York 0 2 * * *", false},
+		{"seconds field", "30 */20 * * * *", false},
+
+		// Invalid expressions
+		{"empty", "", true},
+		{"unknown macro", "@every", true},
+		{"unknown timezone", "TZ=Mars/Olympus 0 0 * * *", true},
+		{"unknown name", "0 12 * * FOO", true},
+		{"month name in weekday field", "0 12 * * JAN", true},
+		{"weekday out of range", "0 12 * * 8", true},
+		{"nth out of range", "0 12 * * MON#6", true},
+		{"unsupported L offset", "0 12 L-2 * *", true},
+		{"question mark minute", "? * * * *", true},
+		{"zero step", "*/0 * * * *", true},
+		{"reversed range", "0 0 5-3 * *", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchedule(tt.expr, nil)
+			if tt.expectError && err == nil {
+				t.Errorf("expected error for %q, but got none", tt.expr)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error for %q: %v", tt.expr, err)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("timezone data not available: %v", err)
+	}
+
+	after := time.Date(2025, 3, 1, 10, 30, 15, 0, time.UTC) // a Saturday
+
+	tests := []struct {
+		name     string
+		expr     string
+		location *time.Location
+		after    time.Time
+		expected time.Time
+	}{
+		{"every minute", "* * * * *", nil, after, time.Date(2025, 3, 1, 10, 31, 0, 0, time.UTC)},
+		{"daily at 2 AM", "0 2 * * *", nil, after, time.Date(2025, 3, 2, 2, 0, 0, 0, time.UTC)},
+		{"weekday names", "0 9 * * MON-FRI", nil, after, time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)},
+		{"month name", "0 0 1 JAN *", nil, after, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"hourly macro", "@hourly", nil, after, time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC)},
+		{"weekly macro", "@weekly", nil, after, time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{"last day of month", "0 12 L * *", nil, after, time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)},
+		{"last friday of month", "0 12 * * 5L", nil, after, time.Date(2025, 3, 28, 12, 0, 0, 0, time.UTC)},
+		{"second monday of month", "0 12 * * MON#2", nil, after, time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)},
+		{"either day field", "0 12 15 * MON", nil, after, time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", nil, after, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"seconds field", "30 */20 * * * *", nil, after, time.Date(2025, 3, 1, 10, 40, 30, 0, time.UTC)},
+		{"location", "0 12 * * *", paris, after, time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC)},
+		{"timezone prefix wins", "TZ=Europe/Paris 0 12 * * *", time.UTC, after, time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC)},
+		{
+			"skipped by daylight saving",
+			"30 2 * * *",
+			paris,
+			time.Date(2025, 3, 29, 3, 0, 0, 0, time.UTC),
+			time.Date(2025, 3, 31, 0, 30, 0, 0, time.UTC), // 02:30 does not exist on March 30th in Paris
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.expr, tt.location)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			next, err := schedule.Next(tt.after)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !next.Equal(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, next.UTC())
+			}
+			if !schedule.IsDue(next) || !schedule.Matches(next) {
+				t.Errorf("expected the schedule to be due at its next run %v", next)
+			}
+		})
+	}
+}
+
+func TestScheduleNextNeverMatches(t *testing.T) {
+	schedule, err := ParseSchedule("0 0 31 2 *", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := schedule.Next(time.Now()); !errors.Is(err, ErrNoNextRun) {
+		t.Errorf("expected ErrNoNextRun, got %v", err)
+	}
+}
+
+func TestScheduleNextRuns(t *testing.T) {
+	schedule, err := ParseSchedule("0 9 * * MON#1,MON#3", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runs, err := schedule.NextRuns(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []time.Time{
+		time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 17, 9, 0, 0, 0, time.UTC),
+		time.Date(2025, 4, 7, 9, 0, 0, 0, time.UTC),
+	}
+	if len(runs) != len(expected) {
+		t.Fatalf("expected %d runs, got %d", len(expected), len(runs))
+	}
+	for i := range expected {
+		if !runs[i].Equal(expected[i]) {
+			t.Errorf("run %d: expected %v, got %v", i, expected[i], runs[i])
+		}
+	}
+
+	preview, err := NextRuns("@daily", 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(preview) != 5 || preview[1].Sub(preview[0]) != 24*time.Hour {
+		t.Errorf("unexpected daily preview: %v", preview)
+	}
+
+	if _, err := NextRuns("not a cron", 5); err == nil {
+		t.Error("expected an error for an invalid expression")
+	}
+}
+
+func TestSchedulePocketBaseExpr(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		ok       bool
+	}{
+		{"0 2 * * *", "0 2 * * *", true},
+		{"0 9 * * MON-FRI", "0 9 * * 1,2,3,4,5", true},
+		{"*/15 * * * *", "0,15,30,45 * * * *", true},
+		{"@daily", "0 0 * * *", true},
+		{"0 0 * * 0,7", "0 0 * * 0", true},
+		{"0 12 L * *", "", false},
+		{"0 12 * * MON#2", "", false},
+		{"0 0 1 * MON", "", false},
+		{"TZ=Europe/Paris 0 2 * * *", "", false},
+		{"0 0 2 * * *", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.expr, nil)
+			if err != nil {
+				t.Skipf("cannot parse %q: %v", tt.expr, err)
+			}
+
+			expr, ok := schedule.pocketBaseExpr()
+			if ok != tt.ok || expr != tt.expected {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tt.expected, tt.ok, expr, ok)
+			}
+		})
+	}
+}
+
+func TestAddToCron(t *testing.T) {
+	c := cron.New()
+
+	weekdays, _ := ParseSchedule("0 9 * * MON-FRI", nil)
+	if err := AddToCron(c, "weekdays", weekdays, func() {}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lastDay, _ := ParseSchedule("0 12 L * *", nil)
+	if err := AddToCron(c, "last_day", lastDay, func() {}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expressions := map[string]string{}
+	for _, job := range c.Jobs() {
+		expressions[job.Id()] = job.Expression()
+	}
+	if expressions["weekdays"] != "0 9 * * 1,2,3,4,5" {
+		t.Errorf("expected the PocketBase expression, got %q", expressions["weekdays"])
+	}
+	if expressions["last_day"] != everyMinute {
+		t.Errorf("expected a gated every minute cron, got %q", expressions["last_day"])
+	}
+
+	withSeconds, _ := ParseSchedule("0 0 2 * * *", nil)
+	if err := AddToCron(c, "seconds", withSeconds, func() {}); !errors.Is(err, ErrSecondsNotSupported) {
+		t.Errorf("expected ErrSecondsNotSupported, got %v", err)
+	}
+}
@@ -21,6 +21,10 @@ func TestValidateCronExpression(t *testing.T) {
 		{"valid complex", "0,15,30,45 8-17 * * 1-5", false},
 		{"valid 6-field with seconds", "0 0 0 * * *", false},
 		{"valid wildcard", "* * * * *", false},
+		{"valid names", "0 9 * JAN-JUN MON-FRI", false},
+		{"valid macro", "@hourly", false},
+		{"valid modifiers", "0 12 L * 5L", false},
+		{"valid timezone", "TZ=Europe/Paris 0 2 * * *", false},
 
 		// Invalid expressions
 		{"empty expression", "", true},
@@ -0,0 +1,280 @@
+package jobutils
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Job listing defaults
+const (
+	DefaultJobListPerPage = 30
+	MaxJobListPerPage     = 200
+
+	// purgeBatchSize is how many jobs PurgeJobs deletes per query
+	purgeBatchSize = 100
+)
+
+var (
+	// ErrJobNotRetryable is returned when retrying a job that is not failed, dead or canceled
+	ErrJobNotRetryable = errors.New("job cannot be retried")
+
+	// ErrJobNotCancelable is returned when canceling a job that already finished
+	ErrJobNotCancelable = errors.New("job cannot be canceled")
+
+	// ErrInvalidJobStatus is returned for a status filter that is not a job status
+	ErrInvalidJobStatus = errors.New("invalid job status")
+)
+
+// JobStatuses lists every job lifecycle status
+var JobStatuses = []string{
+	JobStatusQueued,
+	JobStatusProcessing,
+	JobStatusCompleted,
+	JobStatusFailed,
+	JobStatusDead,
+	JobStatusCanceled,
+}
+
+// PurgeableJobStatuses lists the final statuses whose jobs may be purged
+var PurgeableJobStatuses = []string{
+	JobStatusCompleted,
+	JobStatusDead,
+	JobStatusCanceled,
+}
+
+// JobListFilter narrows down the jobs returned by ListJobs. Empty fields are not filtered on.
+type JobListFilter struct {
+	Type        string    // Job type from the payload (e.g. email)
+	Status      string    // Lifecycle status
+	Queue       string    // Queue name
+	OwnerID     string    // ID of the user the jobs belong to
+	CreatedFrom time.Time // Jobs created at or after this time
+	CreatedTo   time.Time // Jobs created at or before this time
+	Page        int       // 1-based page number
+	PerPage     int       // Jobs per page (DefaultJobListPerPage when 0, at most MaxJobListPerPage)
+}
+
+// JobList is a page of jobs returned by ListJobs
+type JobList struct {
+	Page       int          `json:"page"`
+	PerPage    int          `json:"per_page"`
+	TotalItems int64        `json:"total_items"`
+	TotalPages int          `json:"total_pages"`
+	Items      []*JobStatus `json:"items"`
+}
+
+// normalize validates the filter and applies the paging defaults
+func (f *JobListFilter) normalize() error {
+	if f.Status != "" && !slices.Contains(JobStatuses, f.Status) {
+		return fmt.Errorf("%w: %s", ErrInvalidJobStatus, f.Status)
+	}
+
+	if !f.CreatedFrom.IsZero() && !f.CreatedTo.IsZero() && f.CreatedFrom.After(f.CreatedTo) {
+		return fmt.Errorf("created from date must be before the created to date")
+	}
+
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.PerPage <= 0 {
+		f.PerPage = DefaultJobListPerPage
+	}
+	f.PerPage = min(f.PerPage, MaxJobListPerPage)
+
+	return nil
+}
+
+// expressions returns the where expressions of the filter
+func (f *JobListFilter) expressions() []dbx.Expression {
+	exprs := []dbx.Expression{}
+
+	if f.Type != "" {
+		exprs = append(exprs, dbx.NewExp("json_extract([[payload]], '$.type') = {:type}", dbx.Params{"type": f.Type}))
+	}
+	if f.Status != "" {
+		exprs = append(exprs, dbx.HashExp{"status": f.Status})
+	}
+	if f.Queue != "" {
+		exprs = append(exprs, dbx.HashExp{"queue": f.Queue})
+	}
+	if f.OwnerID != "" {
+		exprs = append(exprs, dbx.HashExp{"user_id": f.OwnerID})
+	}
+	if !f.CreatedFrom.IsZero() {
+		from, _ := types.ParseDateTime(f.CreatedFrom)
+		exprs = append(exprs, dbx.NewExp("[[created]] >= {:from}", dbx.Params{"from": from.String()}))
+	}
+	if !f.CreatedTo.IsZero() {
+		to, _ := types.ParseDateTime(f.CreatedTo)
+		exprs = append(exprs, dbx.NewExp("[[created]] <= {:to}", dbx.Params{"to": to.String()}))
+	}
+
+	return exprs
+}
+
+// ListJobs returns a page of jobs matching the filter, newest first
+func ListJobs(app core.App, filter JobListFilter) (*JobList, error) {
+	if err := filter.normalize(); err != nil {
+		return nil, err
+	}
+
+	exprs := filter.expressions()
+
+	total, err := app.CountRecords(QueuesCollection, exprs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	records := []*core.Record{}
+	err = app.RecordQuery(QueuesCollection).
+		AndWhere(dbx.And(exprs...)).
+		OrderBy("created DESC", "id DESC").
+		Limit(int64(filter.PerPage)).
+		Offset(int64((filter.Page - 1) * filter.PerPage)).
+		All(&records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch jobs: %w", err)
+	}
+
+	items := make([]*JobStatus, 0, len(records))
+	for _, record := range records {
+		items = append(items, JobStatusFromRecord(record))
+	}
+
+	return &JobList{
+		Page:       filter.Page,
+		PerPage:    filter.PerPage,
+		TotalItems: total,
+		TotalPages: int((total + int64(filter.PerPage) - 1) / int64(filter.PerPage)),
+		Items:      items,
+	}, nil
+}
+
+// GetJobDetails returns the full state of a job, including its payload and error history
+func GetJobDetails(app core.App, jobId string) (*JobDetails, error) {
+	record, err := app.FindRecordById(QueuesCollection, jobId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return JobDetailsFromRecord(record), nil
+}
+
+// RetryJob requeues a failed, dead or canceled job with a fresh attempt budget
+func RetryJob(app core.App, jobId string) (*JobDetails, error) {
+	record, err := RequeueJob(app, jobId)
+	if err != nil {
+		return nil, err
+	}
+
+	return JobDetailsFromRecord(record), nil
+}
+
+// CancelJob cancels a queued, failed (waiting for a retry) or processing job. A processing
+// job is interrupted right away when it runs in this process; other instances notice the
+// cancellation when they renew the job lease, and their outcome of the job is discarded.
+func CancelJob(app core.App, jobId string) (*JobDetails, error) {
+	var canceled *core.Record
+	var previous string
+
+	err := app.RunInTransaction(func(txApp core.App) error {
+		record, err := txApp.FindRecordById(QueuesCollection, jobId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrJobNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		previous = record.GetString("status")
+		if previous != JobStatusQueued && previous != JobStatusFailed && previous != JobStatusProcessing {
+			return fmt.Errorf("%w: job %s is %s", ErrJobNotCancelable, jobId, previous)
+		}
+
+		record.Set("status", JobStatusCanceled)
+		record.Set("reserved_at", "")
+		record.Set("lease_token", "")
+
+		if err := txApp.Save(record); err != nil {
+			return fmt.Errorf("failed to cancel job %s: %w", jobId, err)
+		}
+
+		canceled = record
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if previous == JobStatusProcessing {
+		interruptRunningJob(jobId, ErrJobCancelRequested)
+	}
+
+	log.Info("Job canceled", "job_id", jobId, "previous_status", previous)
+	settleFinishedJob(app, canceled)
+	return JobDetailsFromRecord(canceled), nil
+}
+
+// PurgeJobs deletes the jobs in the given final statuses (completed, dead or canceled) that were
+// last updated before the given time (zero means any time) and returns how many were deleted
+func PurgeJobs(app core.App, statuses []string, before time.Time) (int, error) {
+	if len(statuses) == 0 {
+		return 0, fmt.Errorf("%w: at least one status is required", ErrInvalidJobStatus)
+	}
+
+	values := make([]any, 0, len(statuses))
+	for _, status := range statuses {
+		if !slices.Contains(PurgeableJobStatuses, status) {
+			return 0, fmt.Errorf("%w: %s jobs cannot be purged", ErrInvalidJobStatus, status)
+		}
+		values = append(values, status)
+	}
+
+	exprs := []dbx.Expression{dbx.In("status", values...)}
+	if !before.IsZero() {
+		cutoff, _ := types.ParseDateTime(before)
+		exprs = append(exprs, dbx.NewExp("[[updated]] < {:before}", dbx.Params{"before": cutoff.String()}))
+	}
+
+	deleted := 0
+	for {
+		records := []*core.Record{}
+		err := app.RecordQuery(QueuesCollection).
+			AndWhere(dbx.And(exprs...)).
+			OrderBy("updated ASC").
+			Limit(purgeBatchSize).
+			All(&records)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to fetch jobs to purge: %w", err)
+		}
+
+		for _, record := range records {
+			if err := app.Delete(record); err != nil {
+				return deleted, fmt.Errorf("failed to delete job %s: %w", record.Id, err)
+			}
+			deleted++
+		}
+
+		if len(records) < purgeBatchSize {
+			break
+		}
+	}
+
+	if deleted > 0 {
+		log.Info("Jobs purged", "statuses", statuses, "deleted", deleted)
+	}
+
+	return deleted, nil
+}
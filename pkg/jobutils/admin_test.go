@@ -0,0 +1,192 @@
+package jobutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+)
+
+func TestJobListFilterNormalize(t *testing.T) {
+	from := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		filter          JobListFilter
+		expectErr       bool
+		expectedPage    int
+		expectedPerPage int
+	}{
+		{name: "defaults", filter: JobListFilter{}, expectedPage: 1, expectedPerPage: DefaultJobListPerPage},
+		{name: "custom paging", filter: JobListFilter{Page: 3, PerPage: 10}, expectedPage: 3, expectedPerPage: 10},
+		{name: "per page is capped", filter: JobListFilter{PerPage: 1000}, expectedPage: 1, expectedPerPage: MaxJobListPerPage},
+		{name: "known status", filter: JobListFilter{Status: JobStatusCanceled}, expectedPage: 1, expectedPerPage: DefaultJobListPerPage},
+		{name: "date range", filter: JobListFilter{CreatedFrom: from, CreatedTo: to}, expectedPage: 1, expectedPerPage: DefaultJobListPerPage},
+		{name: "unknown status", filter: JobListFilter{Status: "paused"}, expectErr: true},
+		{name: "inverted date range", filter: JobListFilter{CreatedFrom: to, CreatedTo: from}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			err := filter.normalize()
+
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if filter.Page != tt.expectedPage || filter.PerPage != tt.expectedPerPage {
+				t.Errorf("expected page %d/%d, got %d/%d", tt.expectedPage, tt.expectedPerPage, filter.Page, filter.PerPage)
+			}
+		})
+	}
+}
+
+func TestJobListFilterExpressions(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   JobListFilter
+		expected int
+	}{
+		{name: "no filters", filter: JobListFilter{}, expected: 0},
+		{name: "type and status", filter: JobListFilter{Type: JobTypeEmail, Status: JobStatusDead}, expected: 2},
+		{
+			name: "all filters",
+			filter: JobListFilter{
+				Type:        JobTypeDataProcessing,
+				Status:      JobStatusFailed,
+				Queue:       QueueExports,
+				OwnerID:     "user-1",
+				CreatedFrom: time.Now().Add(-time.Hour),
+				CreatedTo:   time.Now(),
+			},
+			expected: 6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.filter.expressions()); got != tt.expected {
+				t.Errorf("expected %d expressions, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPurgeJobsRejectsActiveStatuses(t *testing.T) {
+	app := pocketbase.New()
+
+	tests := []struct {
+		name     string
+		statuses []string
+	}{
+		{name: "no statuses", statuses: nil},
+		{name: "queued jobs", statuses: []string{JobStatusQueued}},
+		{name: "processing among final statuses", statuses: []string{JobStatusCompleted, JobStatusProcessing}},
+		{name: "unknown status", statuses: []string{"archived"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted, err := PurgeJobs(app, tt.statuses, time.Time{})
+			if !errors.Is(err, ErrInvalidJobStatus) {
+				t.Errorf("expected ErrInvalidJobStatus, got %v", err)
+			}
+			if deleted != 0 {
+				t.Errorf("expected nothing to be deleted, got %d", deleted)
+			}
+		})
+	}
+}
+
+func TestAppendErrorHistory(t *testing.T) {
+	history := []JobErrorEntry{}
+	for attempt := 1; attempt <= maxErrorHistoryEntries+5; attempt++ {
+		history = appendErrorHistory(history, JobErrorEntry{Attempt: attempt, Error: fmt.Sprintf("attempt %d failed", attempt)})
+	}
+
+	if len(history) != maxErrorHistoryEntries {
+		t.Fatalf("expected history to be capped at %d entries, got %d", maxErrorHistoryEntries, len(history))
+	}
+	if history[0].Attempt != 6 {
+		t.Errorf("expected the oldest entries to be dropped, first attempt is %d", history[0].Attempt)
+	}
+	if last := history[len(history)-1]; last.Attempt != maxErrorHistoryEntries+5 {
+		t.Errorf("expected the latest attempt to be kept, got %d", last.Attempt)
+	}
+}
+
+func TestJobDetailsFromRecord(t *testing.T) {
+	record := newTestQueueRecord()
+	record.Id = "job-details"
+	record.Set("name", "Welcome email")
+	record.Set("description", "Send the welcome email")
+	record.Set("queue", QueueEmails)
+	record.Set("priority", 5)
+	record.Set("status", JobStatusFailed)
+	record.Set("payload", map[string]any{"type": JobTypeEmail, "data": map[string]any{"to": "user@example.com"}})
+	record.Set("error_history", []JobErrorEntry{
+		{Attempt: 1, Error: "smtp unavailable", FailureReason: JobFailureError},
+		{Attempt: 2, Error: "job timed out", FailureReason: JobFailureTimeout},
+	})
+
+	details := JobDetailsFromRecord(record)
+
+	if details.Type != JobTypeEmail {
+		t.Errorf("expected type %q, got %q", JobTypeEmail, details.Type)
+	}
+	if details.Queue != QueueEmails || details.Priority != 5 || details.Description != "Send the welcome email" {
+		t.Errorf("unexpected details: %+v", details)
+	}
+	if details.Payload["type"] != JobTypeEmail {
+		t.Errorf("expected payload to be returned, got %v", details.Payload)
+	}
+	if len(details.ErrorHistory) != 2 || details.ErrorHistory[1].FailureReason != JobFailureTimeout {
+		t.Errorf("unexpected error history: %+v", details.ErrorHistory)
+	}
+
+	data := details.ToMap()
+	for _, key := range []string{"job_id", "type", "status", "payload", "error_history", "priority"} {
+		if _, ok := data[key]; !ok {
+			t.Errorf("expected %q in the response data", key)
+		}
+	}
+}
+
+func TestGetErrorHistoryEmpty(t *testing.T) {
+	history := GetErrorHistory(newTestQueueRecord())
+	if history == nil || len(history) != 0 {
+		t.Errorf("expected an empty history, got %v", history)
+	}
+}
+
+func TestInterruptRunningJob(t *testing.T) {
+	if interruptRunningJob("unknown-job", ErrJobCancelRequested) {
+		t.Error("expected unknown jobs not to be interrupted")
+	}
+
+	record := newTestQueueRecord()
+	record.Id = "job-to-interrupt"
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	reporter := trackRunningJob(pocketbase.New(), record, "token", cancel)
+	defer reporter.close()
+
+	if !interruptRunningJob(record.Id, ErrJobCancelRequested) {
+		t.Fatal("expected the running job to be interrupted")
+	}
+	if !errors.Is(context.Cause(ctx), ErrJobCancelRequested) {
+		t.Errorf("expected the job context to be canceled by request, got %v", context.Cause(ctx))
+	}
+}
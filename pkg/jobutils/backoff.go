@@ -0,0 +1,60 @@
+package jobutils
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/common"
+)
+
+// BackoffConfig controls how long a failed job waits before it becomes available again
+type BackoffConfig struct {
+	Base   time.Duration // Delay after the first failed attempt
+	Max    time.Duration // Upper bound for the delay
+	Jitter float64       // Random spread applied to the delay (0.2 = +/-20%)
+}
+
+// GetBackoffConfig loads the retry backoff configuration from the environment
+func GetBackoffConfig() BackoffConfig {
+	baseSeconds := common.GetEnvInt("JOB_BACKOFF_BASE_SECONDS", DefaultBackoffBaseSeconds)
+	if baseSeconds < 0 {
+		baseSeconds = DefaultBackoffBaseSeconds
+	}
+
+	maxSeconds := common.GetEnvInt("JOB_BACKOFF_MAX_SECONDS", DefaultBackoffMaxSeconds)
+	if maxSeconds < baseSeconds {
+		maxSeconds = baseSeconds
+	}
+
+	jitterPercent := common.GetEnvInt("JOB_BACKOFF_JITTER_PERCENT", DefaultBackoffJitterPercent)
+	if jitterPercent < 0 || jitterPercent > 100 {
+		jitterPercent = DefaultBackoffJitterPercent
+	}
+
+	return BackoffConfig{
+		Base:   time.Duration(baseSeconds) * time.Second,
+		Max:    time.Duration(maxSeconds) * time.Second,
+		Jitter: float64(jitterPercent) / 100,
+	}
+}
+
+// Delay returns the wait time before the next attempt after the given number of failed attempts.
+// The delay doubles with every attempt (base, 2*base, 4*base, ...) up to Max, then jitter is applied.
+func (c BackoffConfig) Delay(attempts int) time.Duration {
+	if attempts < 1 || c.Base <= 0 {
+		return 0
+	}
+
+	delay := float64(c.Base) * math.Pow(2, float64(attempts-1))
+	if delay > float64(c.Max) {
+		delay = float64(c.Max)
+	}
+
+	if c.Jitter > 0 {
+		// spread uniformly across [delay*(1-jitter), delay*(1+jitter)]
+		delay += delay * c.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(delay)
+}
@@ -0,0 +1,65 @@
+package jobutils
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestBackoffConfig_Delay(t *testing.T) {
+	config := BackoffConfig{Base: 10 * time.Second, Max: 60 * time.Second}
+
+	tests := []struct {
+		name     string
+		attempts int
+		expected time.Duration
+	}{
+		{name: "no attempts", attempts: 0, expected: 0},
+		{name: "first failure uses base", attempts: 1, expected: 10 * time.Second},
+		{name: "second failure doubles", attempts: 2, expected: 20 * time.Second},
+		{name: "third failure doubles again", attempts: 3, expected: 40 * time.Second},
+		{name: "capped at max", attempts: 10, expected: 60 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := config.Delay(tt.attempts); got != tt.expected {
+				t.Errorf("expected delay %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBackoffConfig_DelayWithJitter(t *testing.T) {
+	config := BackoffConfig{Base: 100 * time.Second, Max: time.Hour, Jitter: 0.2}
+
+	for i := 0; i < 100; i++ {
+		delay := config.Delay(1)
+		if delay < 80*time.Second || delay > 120*time.Second {
+			t.Fatalf("delay %s outside jitter bounds [80s, 120s]", delay)
+		}
+	}
+}
+
+func TestGetBackoffConfig(t *testing.T) {
+	os.Setenv("JOB_BACKOFF_BASE_SECONDS", "5")
+	os.Setenv("JOB_BACKOFF_MAX_SECONDS", "2") // lower than base, raised to base
+	os.Setenv("JOB_BACKOFF_JITTER_PERCENT", "150")
+	defer func() {
+		os.Unsetenv("JOB_BACKOFF_BASE_SECONDS")
+		os.Unsetenv("JOB_BACKOFF_MAX_SECONDS")
+		os.Unsetenv("JOB_BACKOFF_JITTER_PERCENT")
+	}()
+
+	config := GetBackoffConfig()
+
+	if config.Base != 5*time.Second {
+		t.Errorf("expected base 5s, got %s", config.Base)
+	}
+	if config.Max != 5*time.Second {
+		t.Errorf("expected max to be raised to 5s, got %s", config.Max)
+	}
+	if config.Jitter != float64(DefaultBackoffJitterPercent)/100 {
+		t.Errorf("expected invalid jitter to fall back to default, got %v", config.Jitter)
+	}
+}
@@ -0,0 +1,382 @@
+package jobutils
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// JobBatchesCollection is the collection batches of jobs are stored in
+const JobBatchesCollection = "job_batches"
+
+// BatchPayloadKey is the payload key the follow-up job of a batch receives the batch status under
+const BatchPayloadKey = "batch"
+
+// Batch statuses
+const (
+	BatchStatusPending   = "pending"   // Some jobs of the batch have not finished yet
+	BatchStatusCompleted = "completed" // Every job of the batch completed
+	BatchStatusFailed    = "failed"    // Every job finished, at least one of them ended dead or canceled
+)
+
+var (
+	// ErrBatchNotFound is returned when a batch does not exist
+	ErrBatchNotFound = errors.New("batch not found")
+
+	// ErrEmptyBatch is returned when a batch is enqueued without jobs
+	ErrEmptyBatch = errors.New("batch has no jobs")
+)
+
+// BatchOptions holds the batch record settings applied by EnqueueBatch
+type BatchOptions struct {
+	Name       string       // Batch name
+	OwnerID    string       // Owner of the batch, also the default owner of its jobs and follow-up
+	OnComplete *JobRequest  // Job queued once every job of the batch completed
+	OnFailure  *JobRequest  // Job queued once every job finished and at least one ended dead or canceled
+	Registry   *JobRegistry // Registry used to validate the follow-up jobs (defaults to the default registry)
+}
+
+// BatchOption configures a batch being enqueued
+type BatchOption func(*BatchOptions)
+
+// WithBatchName sets the batch name
+func WithBatchName(name string) BatchOption {
+	return func(o *BatchOptions) { o.Name = name }
+}
+
+// WithBatchOwner assigns the batch, its jobs and its follow-up job to a user
+func WithBatchOwner(userId string) BatchOption {
+	return func(o *BatchOptions) { o.OwnerID = userId }
+}
+
+// OnBatchComplete queues a job once every job of the batch completed
+func OnBatchComplete(payload any, opts ...EnqueueOption) BatchOption {
+	return func(o *BatchOptions) {
+		request := NewJobRequest(payload, opts...)
+		o.OnComplete = &request
+	}
+}
+
+// OnBatchFailure queues a job once every job of the batch finished and at least one of them
+// ended dead or canceled
+func OnBatchFailure(payload any, opts ...EnqueueOption) BatchOption {
+	return func(o *BatchOptions) {
+		request := NewJobRequest(payload, opts...)
+		o.OnFailure = &request
+	}
+}
+
+// BatchHandle references an enqueued batch so the caller can poll its status
+type BatchHandle struct {
+	ID     string   // Batch ID in the job_batches collection
+	JobIDs []string // IDs of the jobs of the batch, in the order they were given
+	app    core.App
+}
+
+// Status returns the aggregate status of the batch
+func (h *BatchHandle) Status() (*BatchStatus, error) {
+	return GetBatchStatus(h.app, h.ID)
+}
+
+// BatchStatus is the aggregate state of a batch as reported by the batch status API and
+// passed to its follow-up job
+type BatchStatus struct {
+	BatchID       string     `json:"batch_id"`
+	Name          string     `json:"name"`
+	OwnerID       string     `json:"user_id"`
+	Status        string     `json:"status"`
+	Total         int        `json:"total"`
+	Pending       int        `json:"pending"`
+	Completed     int        `json:"completed"`
+	Failed        int        `json:"failed"`
+	FollowUpJobID string     `json:"follow_up_job_id"`
+	CreatedAt     *time.Time `json:"created_at"`
+	CompletedAt   *time.Time `json:"completed_at"`
+}
+
+// ToMap returns the status as response data
+func (s *BatchStatus) ToMap() map[string]any {
+	return map[string]any{
+		"batch_id":         s.BatchID,
+		"name":             s.Name,
+		"user_id":          s.OwnerID,
+		"status":           s.Status,
+		"total":            s.Total,
+		"pending":          s.Pending,
+		"completed":        s.Completed,
+		"failed":           s.Failed,
+		"follow_up_job_id": s.FollowUpJobID,
+		"created_at":       s.CreatedAt,
+		"completed_at":     s.CompletedAt,
+	}
+}
+
+// batchJobCounts is how many jobs of a batch are still pending, completed, or ended dead or canceled
+type batchJobCounts struct {
+	Pending   int
+	Completed int
+	Failed    int
+}
+
+// EnqueueBatch validates and queues a set of jobs that share an aggregate status. Once every job
+// (and every job chained after them) finished, the batch is completed or failed and the matching
+// follow-up job is queued with the batch status. The batch and its jobs are queued atomically.
+func EnqueueBatch(app core.App, jobs []JobRequest, opts ...BatchOption) (*BatchHandle, error) {
+	if app == nil {
+		return nil, fmt.Errorf("app cannot be nil")
+	}
+	if len(jobs) == 0 {
+		return nil, ErrEmptyBatch
+	}
+
+	options := &BatchOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	onComplete, err := newFollowUpSpecs(options.OnComplete, options.Registry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch completion job: %w", err)
+	}
+	onFailure, err := newFollowUpSpecs(options.OnFailure, options.Registry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch failure job: %w", err)
+	}
+
+	collection, err := app.FindCollectionByNameOrId(JobBatchesCollection)
+	if err != nil {
+		return nil, fmt.Errorf("batch system unavailable: %w", err)
+	}
+
+	handle := &BatchHandle{JobIDs: make([]string, 0, len(jobs)), app: app}
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		batch := core.NewRecord(collection)
+		batch.Set("name", options.Name)
+		batch.Set("user_id", options.OwnerID)
+		batch.Set("status", BatchStatusPending)
+		batch.Set("total_jobs", len(jobs))
+		batch.Set("on_complete", onComplete)
+		batch.Set("on_failure", onFailure)
+
+		if err := txApp.Save(batch); err != nil {
+			return fmt.Errorf("failed to create batch: %w", err)
+		}
+
+		for i, job := range jobs {
+			jobOpts := append([]EnqueueOption{WithOwner(options.OwnerID)}, job.Options...)
+			jobOpts = append(jobOpts, func(o *EnqueueOptions) { o.batchID = batch.Id })
+
+			queued, err := Enqueue(txApp, job.Payload, jobOpts...)
+			if err != nil {
+				return fmt.Errorf("batch job %d: %w", i+1, err)
+			}
+			if queued.Existing {
+				// the existing job belongs to another batch (or none) and would never settle this one
+				return fmt.Errorf("batch job %d: %w: job %s", i+1, ErrDuplicateJob, queued.ID)
+			}
+
+			handle.JobIDs = append(handle.JobIDs, queued.ID)
+		}
+
+		handle.ID = batch.Id
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Batch queued", "batch_id", handle.ID, "batch_name", options.Name, "jobs", len(handle.JobIDs))
+	return handle, nil
+}
+
+// newFollowUpSpecs validates the follow-up job of a batch (nil when there is none)
+func newFollowUpSpecs(request *JobRequest, registry *JobRegistry) ([]jobSpec, error) {
+	if request == nil {
+		return nil, nil
+	}
+	return newJobSpecs(*request, registry)
+}
+
+// GetBatchStatus returns the aggregate status of a batch. The job counts of a pending batch are
+// live, those of a finished batch are the counts it finished with.
+func GetBatchStatus(app core.App, batchId string) (*BatchStatus, error) {
+	batch, err := app.FindRecordById(JobBatchesCollection, batchId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if batch.GetString("status") != BatchStatusPending {
+		return batchStatusFromRecord(batch, batchJobCounts{
+			Completed: batch.GetInt("completed_jobs"),
+			Failed:    batch.GetInt("failed_jobs"),
+		}), nil
+	}
+
+	counts, err := countBatchJobs(app, batchId)
+	if err != nil {
+		return nil, err
+	}
+
+	return batchStatusFromRecord(batch, counts), nil
+}
+
+// batchStatusFromRecord builds the status of a batch from its record and job counts
+func batchStatusFromRecord(batch *core.Record, counts batchJobCounts) *BatchStatus {
+	return &BatchStatus{
+		BatchID:       batch.Id,
+		Name:          batch.GetString("name"),
+		OwnerID:       batch.GetString("user_id"),
+		Status:        batch.GetString("status"),
+		Total:         counts.Pending + counts.Completed + counts.Failed,
+		Pending:       counts.Pending,
+		Completed:     counts.Completed,
+		Failed:        counts.Failed,
+		FollowUpJobID: batch.GetString("follow_up_job_id"),
+		CreatedAt:     optionalTime(batch.GetDateTime("created")),
+		CompletedAt:   optionalTime(batch.GetDateTime("completed_at")),
+	}
+}
+
+// countBatchJobs counts the jobs of a batch by outcome
+func countBatchJobs(app core.App, batchId string) (batchJobCounts, error) {
+	rows := []struct {
+		Status string `db:"status"`
+		Total  int    `db:"total"`
+	}{}
+
+	err := app.DB().
+		Select("status", "COUNT(*) AS total").
+		From(QueuesCollection).
+		Where(dbx.HashExp{"batch_id": batchId}).
+		GroupBy("status").
+		All(&rows)
+	if err != nil {
+		return batchJobCounts{}, fmt.Errorf("failed to count jobs of batch %s: %w", batchId, err)
+	}
+
+	counts := batchJobCounts{}
+	for _, row := range rows {
+		switch row.Status {
+		case JobStatusCompleted:
+			counts.Completed += row.Total
+		case JobStatusDead, JobStatusCanceled:
+			counts.Failed += row.Total
+		default:
+			counts.Pending += row.Total
+		}
+	}
+
+	return counts, nil
+}
+
+// settleBatch completes or fails a pending batch once none of its jobs are pending anymore and
+// queues the matching follow-up job. It runs in a transaction, so concurrent workers finishing
+// the last jobs of a batch settle it (and queue its follow-up) exactly once.
+func settleBatch(app core.App, batchId string) error {
+	if batchId == "" {
+		return nil
+	}
+
+	return app.RunInTransaction(func(txApp core.App) error {
+		batch, err := txApp.FindRecordById(JobBatchesCollection, batchId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil // the batch was deleted, its jobs run on their own
+		}
+		if err != nil {
+			return err
+		}
+		if batch.GetString("status") != BatchStatusPending {
+			return nil
+		}
+
+		counts, err := countBatchJobs(txApp, batchId)
+		if err != nil {
+			return err
+		}
+		if counts.Pending > 0 {
+			return nil
+		}
+
+		status, followUpField := BatchStatusCompleted, "on_complete"
+		if counts.Failed > 0 {
+			status, followUpField = BatchStatusFailed, "on_failure"
+		}
+
+		batch.Set("status", status)
+		batch.Set("total_jobs", counts.Completed+counts.Failed)
+		batch.Set("completed_jobs", counts.Completed)
+		batch.Set("failed_jobs", counts.Failed)
+		batch.Set("completed_at", types.NowDateTime())
+
+		followUp := []jobSpec{}
+		_ = batch.UnmarshalJSONField(followUpField, &followUp)
+		if len(followUp) > 0 {
+			summary, err := json.Marshal(batchStatusFromRecord(batch, counts))
+			if err != nil {
+				return fmt.Errorf("failed to encode status of batch %s: %w", batchId, err)
+			}
+
+			var batchData map[string]any
+			if err := json.Unmarshal(summary, &batchData); err != nil {
+				return fmt.Errorf("failed to encode status of batch %s: %w", batchId, err)
+			}
+
+			job, err := enqueueJobSpecs(txApp, followUp, &EnqueueOptions{OwnerID: batch.GetString("user_id")}, map[string]any{BatchPayloadKey: batchData})
+			if err != nil {
+				return fmt.Errorf("failed to queue the follow-up job of batch %s: %w", batchId, err)
+			}
+			batch.Set("follow_up_job_id", job.Id)
+		}
+
+		if err := txApp.Save(batch); err != nil {
+			return fmt.Errorf("failed to settle batch %s: %w", batchId, err)
+		}
+
+		log.Info("Batch finished", "batch_id", batchId, "status", status, "completed", counts.Completed, "failed", counts.Failed, "follow_up_job_id", batch.GetString("follow_up_job_id"))
+		return nil
+	})
+}
+
+// settleFinishedJob settles the batch of a job that reached a final status (completed, dead or
+// canceled). Failures are logged: the job itself is already finished.
+func settleFinishedJob(app core.App, record *core.Record) {
+	if status := record.GetString("status"); status != JobStatusCompleted && len(getChain(record)) > 0 {
+		log.Warn("Job chain stopped", "job_id", record.Id, "status", status, "skipped_jobs", len(getChain(record)))
+	}
+
+	if err := settleBatch(app, record.GetString("batch_id")); err != nil {
+		log.Error("Failed to settle job batch", "job_id", record.Id, "batch_id", record.GetString("batch_id"), "error", err)
+	}
+}
+
+// ParseBatchResult decodes the status of the batch a follow-up job was queued for.
+// It returns nil when the job is not a batch follow-up.
+func ParseBatchResult(job *JobData) (*BatchStatus, error) {
+	if job == nil || job.Payload == nil || job.Payload[BatchPayloadKey] == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(job.Payload[BatchPayloadKey])
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch status: %w", err)
+	}
+
+	var status BatchStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal batch status: %w", err)
+	}
+
+	return &status, nil
+}
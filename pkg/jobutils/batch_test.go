@@ -0,0 +1,119 @@
+package jobutils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func newTestBatchRecord() *core.Record {
+	collection := core.NewBaseCollection(JobBatchesCollection)
+	collection.Fields.Add(
+		&core.TextField{Name: "name"},
+		&core.TextField{Name: "user_id"},
+		&core.SelectField{Name: "status", MaxSelect: 1, Values: []string{
+			BatchStatusPending, BatchStatusCompleted, BatchStatusFailed,
+		}},
+		&core.NumberField{Name: "total_jobs"},
+		&core.NumberField{Name: "completed_jobs"},
+		&core.NumberField{Name: "failed_jobs"},
+		&core.JSONField{Name: "on_complete"},
+		&core.JSONField{Name: "on_failure"},
+		&core.TextField{Name: "follow_up_job_id"},
+		&core.DateField{Name: "completed_at"},
+	)
+	return core.NewRecord(collection)
+}
+
+func TestBatchOptions(t *testing.T) {
+	options := &BatchOptions{}
+	for _, opt := range []BatchOption{
+		WithBatchName("Monthly exports"),
+		WithBatchOwner("user-1"),
+		OnBatchComplete(map[string]any{"type": JobTypeEmail}, WithQueue(QueueEmails)),
+		OnBatchFailure(map[string]any{"type": "alert_job"}),
+	} {
+		opt(options)
+	}
+
+	if options.Name != "Monthly exports" || options.OwnerID != "user-1" {
+		t.Errorf("name or owner not applied: %+v", options)
+	}
+	if options.OnComplete == nil || options.OnComplete.Payload.(map[string]any)["type"] != JobTypeEmail || len(options.OnComplete.Options) != 1 {
+		t.Errorf("completion job not applied: %+v", options.OnComplete)
+	}
+	if options.OnFailure == nil || options.OnFailure.Payload.(map[string]any)["type"] != "alert_job" {
+		t.Errorf("failure job not applied: %+v", options.OnFailure)
+	}
+}
+
+func TestEnqueueBatchValidation(t *testing.T) {
+	if _, err := EnqueueBatch(nil, []JobRequest{NewJobRequest(map[string]any{"type": "test_job"})}); err == nil {
+		t.Error("expected error for nil app")
+	}
+
+	app := pocketbase.New()
+
+	if _, err := EnqueueBatch(app, nil); !errors.Is(err, ErrEmptyBatch) {
+		t.Errorf("expected ErrEmptyBatch, got %v", err)
+	}
+
+	registry := NewJobRegistry()
+	_, err := EnqueueBatch(app,
+		[]JobRequest{NewJobRequest(map[string]any{"type": "test_job"})},
+		OnBatchComplete(map[string]any{"type": "unknown_job"}, WithRegistry(registry)),
+	)
+	if err == nil {
+		t.Error("expected an invalid completion job to be rejected")
+	}
+}
+
+func TestBatchStatusFromRecord(t *testing.T) {
+	batch := newTestBatchRecord()
+	batch.Id = "batch-1"
+	batch.Set("name", "Monthly exports")
+	batch.Set("user_id", "user-1")
+	batch.Set("status", BatchStatusFailed)
+	batch.Set("follow_up_job_id", "job-9")
+
+	status := batchStatusFromRecord(batch, batchJobCounts{Pending: 1, Completed: 3, Failed: 2})
+
+	if status.Total != 6 || status.Pending != 1 || status.Completed != 3 || status.Failed != 2 {
+		t.Errorf("unexpected counts: %+v", status)
+	}
+	if status.BatchID != "batch-1" || status.OwnerID != "user-1" || status.Status != BatchStatusFailed || status.FollowUpJobID != "job-9" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	data := status.ToMap()
+	for _, key := range []string{"batch_id", "status", "total", "pending", "completed", "failed", "follow_up_job_id"} {
+		if _, ok := data[key]; !ok {
+			t.Errorf("expected %q in the response data", key)
+		}
+	}
+}
+
+func TestParseBatchResult(t *testing.T) {
+	status, err := ParseBatchResult(&JobData{Payload: map[string]any{"type": JobTypeEmail}})
+	if err != nil || status != nil {
+		t.Errorf("expected no batch status, got %v, %v", status, err)
+	}
+
+	status, err = ParseBatchResult(&JobData{Payload: map[string]any{
+		"type": JobTypeEmail,
+		BatchPayloadKey: map[string]any{
+			"batch_id":  "batch-1",
+			"status":    BatchStatusCompleted,
+			"total":     4,
+			"completed": 4,
+		},
+	}})
+	if err != nil || status == nil {
+		t.Fatalf("expected the batch status, got %v, %v", status, err)
+	}
+	if status.BatchID != "batch-1" || status.Status != BatchStatusCompleted || status.Completed != 4 {
+		t.Errorf("unexpected batch status: %+v", status)
+	}
+}
@@ -0,0 +1,176 @@
+package jobutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// ParentResultKey is the payload key a chained job receives the result of the job before it under
+const ParentResultKey = "parent_result"
+
+// JobRequest is a job to be queued later, as part of a chain or a batch
+type JobRequest struct {
+	Payload any             // Typed job payload, as passed to Enqueue
+	Options []EnqueueOption // Enqueue options of the job
+}
+
+// NewJobRequest returns a job to be queued later, as part of a chain or a batch
+func NewJobRequest(payload any, opts ...EnqueueOption) JobRequest {
+	return JobRequest{Payload: payload, Options: opts}
+}
+
+// WithThen chains a job that is queued with this job's result once it completes.
+// Several WithThen options run one after another. A job that ends dead or canceled
+// stops the rest of the chain. Chained jobs belong to the same owner and batch.
+func WithThen(payload any, opts ...EnqueueOption) EnqueueOption {
+	return func(o *EnqueueOptions) { o.Then = append(o.Then, NewJobRequest(payload, opts...)) }
+}
+
+// jobSpec is a validated job stored on a queue or batch record until it is queued
+type jobSpec struct {
+	Payload     map[string]any `json:"payload"`
+	Name        string         `json:"name,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Queue       string         `json:"queue,omitempty"`
+	Priority    int            `json:"priority,omitempty"`
+	MaxAttempts int            `json:"max_attempts,omitempty"`
+	Delay       time.Duration  `json:"delay,omitempty"`
+}
+
+// newJobSpecs validates a job request and returns it followed by the jobs chained after it
+func newJobSpecs(request JobRequest, registry *JobRegistry) ([]jobSpec, error) {
+	options := &EnqueueOptions{Registry: registry}
+	for _, opt := range request.Options {
+		opt(options)
+	}
+
+	payloadMap, err := toPayloadMap(request.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateEnqueuePayload(payloadMap, options); err != nil {
+		return nil, err
+	}
+
+	spec := jobSpec{
+		Payload:     payloadMap,
+		Name:        options.Name,
+		Description: options.Description,
+		Queue:       options.Queue,
+		Priority:    options.Priority,
+		MaxAttempts: options.MaxAttempts,
+		Delay:       options.Delay,
+	}
+
+	chain, err := buildChain(options.Then, options.Registry)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]jobSpec{spec}, chain...), nil
+}
+
+// buildChain validates the chained jobs and flattens them in the order they run
+func buildChain(requests []JobRequest, registry *JobRegistry) ([]jobSpec, error) {
+	chain := []jobSpec{}
+	for i, request := range requests {
+		specs, err := newJobSpecs(request, registry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chained job %d: %w", i+1, err)
+		}
+		chain = append(chain, specs...)
+	}
+	return chain, nil
+}
+
+// getChain returns the jobs still to be queued after a job, in order
+func getChain(record *core.Record) []jobSpec {
+	chain := []jobSpec{}
+	if err := record.UnmarshalJSONField("chain", &chain); err != nil {
+		return []jobSpec{}
+	}
+	return chain
+}
+
+// enqueueJobSpecs queues the first job of specs, with the rest chained after it and the
+// given values added to its payload. The job was validated when it was specified, so it
+// is not validated again.
+func enqueueJobSpecs(app core.App, specs []jobSpec, options *EnqueueOptions, extra map[string]any) (*core.Record, error) {
+	spec := specs[0]
+
+	payload := maps.Clone(spec.Payload)
+	maps.Copy(payload, extra)
+
+	options.Name = spec.Name
+	options.Description = spec.Description
+	options.Queue = spec.Queue
+	options.Priority = spec.Priority
+	options.MaxAttempts = spec.MaxAttempts
+	options.Delay = spec.Delay
+	options.chain = specs[1:]
+
+	record, err := newJobRecord(app, payload, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := app.Save(record); err != nil {
+		return nil, fmt.Errorf("failed to queue job: %w", err)
+	}
+
+	return record, nil
+}
+
+// enqueueNextInChain queues the job chained after a completed job with its result,
+// passing on the rest of the chain, the owner and the batch
+func enqueueNextInChain(app core.App, record *core.Record) error {
+	chain := getChain(record)
+	if len(chain) == 0 {
+		return nil
+	}
+
+	var parentResult any
+	if raw := record.GetString("result"); raw != "" && raw != "null" {
+		if err := json.Unmarshal([]byte(raw), &parentResult); err != nil {
+			return fmt.Errorf("failed to decode result of job %s: %w", record.Id, err)
+		}
+	}
+
+	next, err := enqueueJobSpecs(app, chain, &EnqueueOptions{
+		OwnerID:  record.GetString("user_id"),
+		batchID:  record.GetString("batch_id"),
+		parentID: record.Id,
+	}, map[string]any{ParentResultKey: parentResult})
+	if err != nil {
+		return fmt.Errorf("failed to queue the job chained after %s: %w", record.Id, err)
+	}
+
+	log.Info("Chained job queued", "job_id", next.Id, "parent_id", record.Id, "remaining", len(chain)-1)
+	return nil
+}
+
+// ParseParentResult decodes the result of the job a chained job was queued after into v.
+// It reports whether the job has a parent result.
+func ParseParentResult(job *JobData, v any) (bool, error) {
+	if job == nil || job.Payload == nil || job.Payload[ParentResultKey] == nil {
+		return false, nil
+	}
+
+	data, err := json.Marshal(job.Payload[ParentResultKey])
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal parent result: %w", err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to unmarshal parent result: %w", err)
+	}
+
+	return true, nil
+}
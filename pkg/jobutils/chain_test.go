@@ -0,0 +1,106 @@
+package jobutils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithThen(t *testing.T) {
+	options := &EnqueueOptions{}
+	for _, opt := range []EnqueueOption{
+		WithThen(map[string]any{"type": "send_link"}, WithQueue(QueueEmails)),
+		WithThen(map[string]any{"type": "cleanup"}),
+	} {
+		opt(options)
+	}
+
+	if len(options.Then) != 2 {
+		t.Fatalf("expected 2 chained jobs, got %d", len(options.Then))
+	}
+	if len(options.Then[0].Options) != 1 || len(options.Then[1].Options) != 0 {
+		t.Errorf("chained job options not kept: %+v", options.Then)
+	}
+}
+
+func TestBuildChain(t *testing.T) {
+	registry := NewJobRegistry()
+	_ = registry.Register(&MockJobHandler{jobType: "export_job"})
+	_ = registry.Register(&MockJobHandler{jobType: "email_job"})
+	_ = registry.Register(&MockJobHandler{jobType: "cleanup_job"})
+
+	chain, err := buildChain([]JobRequest{
+		NewJobRequest(map[string]any{"type": "email_job"},
+			WithName("Send link"),
+			WithQueue(QueueEmails),
+			WithMaxAttempts(5),
+			WithDelay(time.Minute),
+			WithThen(map[string]any{"type": "cleanup_job"}),
+		),
+		NewJobRequest(map[string]any{"type": "export_job"}),
+	}, registry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	types := []string{}
+	for _, spec := range chain {
+		types = append(types, spec.Payload["type"].(string))
+	}
+	if strings.Join(types, ",") != "email_job,cleanup_job,export_job" {
+		t.Errorf("expected nested chains to run before the next job, got %v", types)
+	}
+
+	first := chain[0]
+	if first.Name != "Send link" || first.Queue != QueueEmails || first.MaxAttempts != 5 || first.Delay != time.Minute {
+		t.Errorf("chained job options not stored: %+v", first)
+	}
+
+	if _, err := buildChain([]JobRequest{NewJobRequest(map[string]any{"type": "unknown_job"})}, registry); err == nil ||
+		!strings.Contains(err.Error(), "invalid chained job 1") {
+		t.Errorf("expected an invalid chained job error, got %v", err)
+	}
+}
+
+func TestGetChain(t *testing.T) {
+	record := newTestQueueRecord()
+	if chain := getChain(record); len(chain) != 0 {
+		t.Errorf("expected an empty chain, got %v", chain)
+	}
+
+	record.Set("chain", []jobSpec{
+		{Payload: map[string]any{"type": "email_job"}, Queue: QueueEmails},
+		{Payload: map[string]any{"type": "cleanup_job"}},
+	})
+
+	chain := getChain(record)
+	if len(chain) != 2 || chain[0].Queue != QueueEmails || chain[1].Payload["type"] != "cleanup_job" {
+		t.Errorf("unexpected chain: %+v", chain)
+	}
+}
+
+func TestParseParentResult(t *testing.T) {
+	var result FileExportResult
+
+	found, err := ParseParentResult(&JobData{Payload: map[string]any{"type": JobTypeEmail}}, &result)
+	if err != nil || found {
+		t.Errorf("expected no parent result, got %v, %v", found, err)
+	}
+
+	job := &JobData{Payload: map[string]any{
+		"type": JobTypeEmail,
+		ParentResultKey: map[string]any{
+			"export_record_id": "export-1",
+			"file_name":        "users.csv",
+			"record_count":     42,
+		},
+	}}
+
+	found, err = ParseParentResult(job, &result)
+	if err != nil || !found {
+		t.Fatalf("expected the parent result, got %v, %v", found, err)
+	}
+	if result.ExportRecordId != "export-1" || result.FileName != "users.csv" || result.RecordCount != 42 {
+		t.Errorf("unexpected parent result: %+v", result)
+	}
+}
@@ -0,0 +1,194 @@
+package jobutils
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/cronutils"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+)
+
+// QueuePauseCronID is the cron whose pause (crons:pause or the admin cron routes) also pauses the
+// dispatcher: while it is paused no new job is claimed, and jobs already running finish
+const QueuePauseCronID = "system_queue"
+
+// Dispatcher continuously feeds ready jobs to the worker pool of every configured queue.
+// Each queue has its own loop that dispatches as soon as it is notified of a new job, whenever
+// one of its workers frees up and, as a fallback for jobs created by other server instances
+// or becoming available later, every PollInterval of the queue.
+// Nothing is dispatched while the system_queue cron is paused (see QueuePauseCronID).
+type Dispatcher struct {
+	processor *JobProcessor
+	wake      map[string]chan struct{}
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	running   bool
+	mu        sync.Mutex
+	paused    func() bool // reports whether dispatching is paused, checked before each claim
+}
+
+// NewDispatcher creates a dispatcher for the queues of the given processor
+func NewDispatcher(processor *JobProcessor) *Dispatcher {
+	wake := make(map[string]chan struct{}, len(processor.queueConfigs))
+	for name := range processor.queueConfigs {
+		wake[name] = make(chan struct{}, 1)
+	}
+
+	return &Dispatcher{
+		processor: processor,
+		wake:      wake,
+		paused: func() bool {
+			return cronutils.IsCronPaused(processor.app, QueuePauseCronID)
+		},
+	}
+}
+
+// Start launches one dispatch loop per queue. Calling Start on a running dispatcher is a no-op.
+func (d *Dispatcher) Start() {
+	d.start(d.processor.queueConfigs.Names())
+}
+
+// StartQueues launches the dispatch loops of the given configured queues only, e.g. for a worker
+// dedicated to one queue. Calling StartQueues on a running dispatcher is a no-op.
+func (d *Dispatcher) StartQueues(queues ...string) error {
+	for _, name := range queues {
+		if _, exists := d.processor.queueConfigs[name]; !exists {
+			return fmt.Errorf("queue '%s' is not configured", name)
+		}
+	}
+
+	d.start(queues)
+	return nil
+}
+
+// start launches the dispatch loops of the given queues
+func (d *Dispatcher) start(queues []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.running {
+		return
+	}
+
+	d.stop = make(chan struct{})
+	d.running = true
+
+	for _, name := range queues {
+		d.wg.Add(1)
+		go d.run(name)
+	}
+
+	log.Info("Job dispatcher started", "queues", queues)
+}
+
+// Stop stops dispatching new jobs and waits for the dispatch loops to exit.
+// Jobs already handed to a worker pool keep running.
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	if !d.running {
+		d.mu.Unlock()
+		return
+	}
+	d.running = false
+	close(d.stop)
+	d.mu.Unlock()
+
+	d.wg.Wait()
+	log.Info("Job dispatcher stopped")
+}
+
+// IsRunning returns whether the dispatch loops are running
+func (d *Dispatcher) IsRunning() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.running
+}
+
+// Notify wakes the dispatch loop of a queue, e.g. right after a job was created.
+// Jobs on queues without a config of their own are dispatched by the default queue.
+func (d *Dispatcher) Notify(queue string) {
+	wake, exists := d.wake[queue]
+	if !exists {
+		wake = d.wake[DefaultQueueName]
+	}
+
+	select {
+	case wake <- struct{}{}:
+	default: // a wake-up is already pending
+	}
+}
+
+// NotifyAll wakes the dispatch loops of every configured queue
+func (d *Dispatcher) NotifyAll() {
+	for name := range d.wake {
+		d.Notify(name)
+	}
+}
+
+// run is the dispatch loop of a single queue
+func (d *Dispatcher) run(queue string) {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.processor.queueConfigs[queue].PollInterval)
+	defer ticker.Stop()
+
+	pool := d.processor.queuePools[queue]
+
+	// pick up the jobs that queued up while the dispatcher was not running
+	d.dispatch(queue)
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-d.wake[queue]:
+		case <-pool.Freed():
+		case <-ticker.C:
+		}
+
+		d.dispatch(queue)
+	}
+}
+
+// dispatch submits ready jobs of the queue until its worker pool is busy or no job is left.
+// A paused dispatcher submits nothing; the poll of the queue picks the jobs up once it is resumed.
+func (d *Dispatcher) dispatch(queue string) {
+	pool := d.processor.queuePools[queue]
+	batchSize := d.processor.queueConfigs[queue].BatchSize
+	queueNames := d.processor.queueConfigs.Names()
+
+	for {
+		limit := min(pool.Available(), batchSize)
+		if limit <= 0 {
+			return
+		}
+
+		if d.paused() {
+			log.Debug("Job dispatcher paused, not dispatching", "queue", queue, "cron_id", QueuePauseCronID)
+			return
+		}
+
+		records, err := FindPendingQueueJobs(d.processor.app, queue, queueNames, limit)
+		if err != nil {
+			log.Error("Failed to fetch jobs to dispatch", "queue", queue, "error", err)
+			return
+		}
+
+		submitted := 0
+		for _, record := range records {
+			if pool.Submit(record) {
+				submitted++
+			}
+		}
+
+		if submitted > 0 {
+			log.Debug("Dispatched jobs", "queue", queue, "jobs", submitted)
+		}
+
+		// stop when the queue is drained or only returned jobs that are already in flight
+		if len(records) < limit || submitted == 0 {
+			return
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package jobutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+)
+
+func TestDispatcher_Notify(t *testing.T) {
+	processor := NewJobProcessorWithQueues(pocketbase.New(), QueueConfigs{
+		QueueEmails: {Workers: 1, PollInterval: time.Second, BatchSize: 10},
+	})
+	dispatcher := NewDispatcher(processor)
+
+	if dispatcher.IsRunning() {
+		t.Fatal("dispatcher should not run before Start")
+	}
+
+	dispatcher.Notify(QueueEmails)
+	dispatcher.Notify(QueueEmails) // coalesced with the pending wake-up
+
+	if len(dispatcher.wake[QueueEmails]) != 1 {
+		t.Errorf("expected one pending wake-up for the emails queue, got %d", len(dispatcher.wake[QueueEmails]))
+	}
+
+	dispatcher.Notify("unconfigured")
+
+	if len(dispatcher.wake[DefaultQueueName]) != 1 {
+		t.Error("expected jobs on unconfigured queues to wake the default queue")
+	}
+
+	// stopping a dispatcher that never started is a no-op
+	dispatcher.Stop()
+}
+
+func TestDispatcher_PausedDispatchesNothing(t *testing.T) {
+	processor := NewJobProcessorWithQueues(pocketbase.New(), QueueConfigs{
+		QueueEmails: {Workers: 1, PollInterval: time.Second, BatchSize: 10},
+	})
+	dispatcher := NewDispatcher(processor)
+
+	checks := 0
+	dispatcher.paused = func() bool {
+		checks++
+		return true
+	}
+
+	// returns before fetching jobs, which would fail without a bootstrapped app
+	dispatcher.dispatch(QueueEmails)
+
+	if checks != 1 {
+		t.Errorf("expected the pause to be checked once before claiming jobs, got %d checks", checks)
+	}
+	if processor.queuePools[QueueEmails].Available() != 1 {
+		t.Error("expected no job to be submitted while paused")
+	}
+}
+
+func TestDispatcher_NotifyAll(t *testing.T) {
+	processor := NewJobProcessorWithQueues(pocketbase.New(), QueueConfigs{
+		QueueEmails: {Workers: 1, PollInterval: time.Second, BatchSize: 10},
+	})
+	dispatcher := NewDispatcher(processor)
+
+	dispatcher.NotifyAll()
+
+	for name, wake := range dispatcher.wake {
+		if len(wake) != 1 {
+			t.Errorf("expected a pending wake-up for queue %s, got %d", name, len(wake))
+		}
+	}
+}
+
+func TestDispatcher_StartQueuesRejectsUnknownQueue(t *testing.T) {
+	processor := NewJobProcessorWithQueues(pocketbase.New(), QueueConfigs{
+		QueueEmails: {Workers: 1, PollInterval: time.Second, BatchSize: 10},
+	})
+	dispatcher := NewDispatcher(processor)
+
+	if err := dispatcher.StartQueues("unconfigured"); err == nil {
+		t.Fatal("expected an error for a queue without a config")
+	}
+	if dispatcher.IsRunning() {
+		t.Error("dispatcher should not run after a rejected StartQueues")
+	}
+}
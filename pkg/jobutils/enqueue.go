@@ -0,0 +1,378 @@
+package jobutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+var (
+	defaultRegistry   *JobRegistry
+	defaultRegistryMu sync.RWMutex
+)
+
+// ErrDuplicateJob is returned by Enqueue in reject mode when a job with the same uniqueness key
+// is queued, running, or completed within the uniqueness window
+var ErrDuplicateJob = errors.New("duplicate job")
+
+// SetDefaultRegistry sets the registry Enqueue uses to validate payloads against their handlers
+func SetDefaultRegistry(registry *JobRegistry) {
+	defaultRegistryMu.Lock()
+	defer defaultRegistryMu.Unlock()
+	defaultRegistry = registry
+}
+
+// GetDefaultRegistry returns the registry Enqueue uses to validate payloads (nil if none is set)
+func GetDefaultRegistry() *JobRegistry {
+	defaultRegistryMu.RLock()
+	defer defaultRegistryMu.RUnlock()
+	return defaultRegistry
+}
+
+// EnqueueOptions holds the queue record settings applied by Enqueue
+type EnqueueOptions struct {
+	Name            string        // Job name (defaults to the payload type)
+	Description     string        // Job description
+	Queue           string        // Queue name (defaults to DefaultQueueName)
+	Priority        int           // Higher priority jobs are picked up first
+	Delay           time.Duration // Delay before the job becomes available
+	RunAt           time.Time     // Absolute time the job becomes available (takes precedence over Delay)
+	MaxAttempts     int           // Attempts before the job is moved to the dead-letter set
+	IdempotencyKey  string        // Uniqueness key; duplicates are collapsed while a job with the same key is active
+	UniqueWindow    time.Duration // How long a completed job keeps holding its uniqueness key
+	RejectDuplicate bool          // Return ErrDuplicateJob instead of collapsing into the existing job
+	OwnerID         string        // ID of the user the job belongs to; only the owner receives its realtime updates
+	Then            []JobRequest  // Jobs queued one after another once this job completes, see WithThen
+	Registry        *JobRegistry  // Registry used for payload validation (defaults to the default registry)
+
+	batchID  string    // Batch the job belongs to, see EnqueueBatch
+	parentID string    // Job whose completion queued this job
+	chain    []jobSpec // Validated Then jobs stored on the record
+}
+
+// EnqueueOption configures a job being enqueued
+type EnqueueOption func(*EnqueueOptions)
+
+// WithName sets the job name
+func WithName(name string) EnqueueOption {
+	return func(o *EnqueueOptions) { o.Name = name }
+}
+
+// WithDescription sets the job description
+func WithDescription(description string) EnqueueOption {
+	return func(o *EnqueueOptions) { o.Description = description }
+}
+
+// WithQueue places the job on a named queue
+func WithQueue(queue string) EnqueueOption {
+	return func(o *EnqueueOptions) { o.Queue = queue }
+}
+
+// WithPriority sets the job priority (higher runs first)
+func WithPriority(priority int) EnqueueOption {
+	return func(o *EnqueueOptions) { o.Priority = priority }
+}
+
+// WithDelay makes the job available only after the given delay
+func WithDelay(delay time.Duration) EnqueueOption {
+	return func(o *EnqueueOptions) { o.Delay = delay }
+}
+
+// WithRunAt makes the job available only at the given time
+func WithRunAt(runAt time.Time) EnqueueOption {
+	return func(o *EnqueueOptions) { o.RunAt = runAt }
+}
+
+// WithMaxAttempts sets how many attempts the job gets before it is moved to the dead-letter set
+func WithMaxAttempts(maxAttempts int) EnqueueOption {
+	return func(o *EnqueueOptions) { o.MaxAttempts = maxAttempts }
+}
+
+// WithIdempotencyKey collapses the enqueue into an existing active job with the same key
+func WithIdempotencyKey(key string) EnqueueOption {
+	return func(o *EnqueueOptions) { o.IdempotencyKey = key }
+}
+
+// WithUniqueKey treats the enqueue as a duplicate while a job with the same key is active
+// or completed less than window ago
+func WithUniqueKey(key string, window time.Duration) EnqueueOption {
+	return func(o *EnqueueOptions) {
+		o.IdempotencyKey = key
+		o.UniqueWindow = window
+	}
+}
+
+// WithRejectDuplicate makes Enqueue return ErrDuplicateJob for a duplicate instead of
+// collapsing it into the existing job
+func WithRejectDuplicate() EnqueueOption {
+	return func(o *EnqueueOptions) { o.RejectDuplicate = true }
+}
+
+// WithOwner assigns the job to a user, e.g. the user who requested an export
+func WithOwner(userId string) EnqueueOption {
+	return func(o *EnqueueOptions) { o.OwnerID = userId }
+}
+
+// WithRegistry validates the payload against a specific registry instead of the default one
+func WithRegistry(registry *JobRegistry) EnqueueOption {
+	return func(o *EnqueueOptions) { o.Registry = registry }
+}
+
+// JobHandle references an enqueued job so the caller can poll its status
+type JobHandle struct {
+	ID       string // Job ID in the queues collection
+	Existing bool   // True when the enqueue collapsed into (or was rejected by) an existing job
+	app      core.App
+}
+
+// NewJobHandle returns a handle for an existing job ID
+func NewJobHandle(app core.App, jobId string) *JobHandle {
+	return &JobHandle{ID: jobId, app: app}
+}
+
+// Record fetches the current queue record of the job
+func (h *JobHandle) Record() (*core.Record, error) {
+	return h.app.FindRecordById(QueuesCollection, h.ID)
+}
+
+// Status returns the current lifecycle status of the job
+func (h *JobHandle) Status() (string, error) {
+	record, err := h.Record()
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch job %s: %w", h.ID, err)
+	}
+	return record.GetString("status"), nil
+}
+
+// Wait polls the job until it reaches completed, dead or canceled, or the context is done
+func (h *JobHandle) Wait(ctx context.Context, interval time.Duration) (string, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status, err := h.Status()
+		if err != nil {
+			return "", err
+		}
+		if status == JobStatusCompleted || status == JobStatusDead || status == JobStatusCanceled {
+			return status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Enqueue validates a typed job payload (e.g. EmailJobPayload) and stores it in the queues collection.
+// The payload must contain a "type" registered in the job registry; handlers implementing
+// PayloadValidator also get to validate the payload before it is queued.
+//
+// When a uniqueness key is set, a duplicate returns the handle of the existing job with
+// Existing set, together with ErrDuplicateJob in reject mode.
+func Enqueue(app core.App, payload any, opts ...EnqueueOption) (*JobHandle, error) {
+	if app == nil {
+		return nil, fmt.Errorf("app cannot be nil")
+	}
+
+	options := &EnqueueOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	payloadMap, err := toPayloadMap(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateEnqueuePayload(payloadMap, options); err != nil {
+		return nil, err
+	}
+
+	if options.chain, err = buildChain(options.Then, options.Registry); err != nil {
+		return nil, err
+	}
+
+	if options.IdempotencyKey != "" {
+		if existing := findDuplicateJob(app, options.IdempotencyKey, options.UniqueWindow); existing != nil {
+			return duplicateJobHandle(app, existing, options)
+		}
+	}
+
+	record, err := newJobRecord(app, payloadMap, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := app.Save(record); err != nil {
+		// another enqueuer may have won the race for the same idempotency key
+		if options.IdempotencyKey != "" {
+			if existing := findDuplicateJob(app, options.IdempotencyKey, options.UniqueWindow); existing != nil {
+				return duplicateJobHandle(app, existing, options)
+			}
+		}
+		return nil, fmt.Errorf("failed to queue job: %w", err)
+	}
+
+	log.Info("Job queued", "job_id", record.Id, "job_type", payloadMap["type"], "queue", record.GetString("queue"))
+	return &JobHandle{ID: record.Id, app: app}, nil
+}
+
+// toPayloadMap converts a typed payload struct into the generic map stored on the queue record
+func toPayloadMap(payload any) (map[string]any, error) {
+	if payload == nil {
+		return nil, fmt.Errorf("job payload cannot be nil")
+	}
+
+	if payloadMap, ok := payload.(map[string]any); ok {
+		return payloadMap, nil
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+
+	var payloadMap map[string]any
+	if err := json.Unmarshal(payloadBytes, &payloadMap); err != nil {
+		return nil, fmt.Errorf("job payload must be a JSON object: %w", err)
+	}
+
+	return payloadMap, nil
+}
+
+// validateEnqueuePayload checks the payload structure and, when a registry is available,
+// that a handler is registered for its type and accepts it
+func validateEnqueuePayload(payload map[string]any, options *EnqueueOptions) error {
+	if err := ValidateJobPayload(payload); err != nil {
+		return err
+	}
+
+	registry := options.Registry
+	if registry == nil {
+		registry = GetDefaultRegistry()
+	}
+	if registry == nil {
+		return nil
+	}
+
+	jobType, _ := payload["type"].(string)
+	handler, err := registry.GetHandler(jobType)
+	if err != nil {
+		return err
+	}
+
+	if validator, ok := handler.(PayloadValidator); ok {
+		job := &JobData{Name: options.Name, OwnerID: options.OwnerID, Type: jobType, Payload: payload}
+		if err := validator.ValidatePayload(job); err != nil {
+			return fmt.Errorf("invalid %s job payload: %w", jobType, err)
+		}
+	}
+
+	return nil
+}
+
+// newJobRecord builds an unsaved queue record from the payload and options
+func newJobRecord(app core.App, payload map[string]any, options *EnqueueOptions) (*core.Record, error) {
+	collection, err := app.FindCollectionByNameOrId(QueuesCollection)
+	if err != nil {
+		return nil, fmt.Errorf("queue system unavailable: %w", err)
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+
+	name := options.Name
+	if name == "" {
+		jobType, _ := payload["type"].(string)
+		name = strings.ReplaceAll(jobType, "_", " ") + " job"
+	}
+
+	queue := options.Queue
+	if queue == "" {
+		queue = DefaultQueueName
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("name", name)
+	record.Set("description", options.Description)
+	record.Set("user_id", options.OwnerID)
+	record.Set("queue", queue)
+	record.Set("priority", options.Priority)
+	record.Set("payload", string(payloadJSON))
+	record.Set("status", JobStatusQueued)
+	record.Set("attempts", 0)
+	record.Set("idempotency_key", options.IdempotencyKey)
+	record.Set("batch_id", options.batchID)
+	record.Set("parent_id", options.parentID)
+
+	if len(options.chain) > 0 {
+		record.Set("chain", options.chain)
+	}
+
+	if options.MaxAttempts > 0 {
+		record.Set("max_attempts", options.MaxAttempts)
+	}
+
+	if !options.RunAt.IsZero() {
+		ScheduleJobAt(record, options.RunAt)
+	} else if options.Delay > 0 {
+		ScheduleJobIn(record, options.Delay)
+	}
+
+	return record, nil
+}
+
+// duplicateJobHandle returns the handle of the job a duplicate enqueue ran into,
+// with ErrDuplicateJob in reject mode
+func duplicateJobHandle(app core.App, existing *core.Record, options *EnqueueOptions) (*JobHandle, error) {
+	handle := &JobHandle{ID: existing.Id, Existing: true, app: app}
+
+	if options.RejectDuplicate {
+		log.Info("Duplicate job rejected", "job_id", existing.Id, "idempotency_key", options.IdempotencyKey, "status", existing.GetString("status"))
+		return handle, fmt.Errorf("%w: job %s holds key %q", ErrDuplicateJob, existing.Id, options.IdempotencyKey)
+	}
+
+	log.Info("Job already queued, collapsing duplicate enqueue", "job_id", existing.Id, "idempotency_key", options.IdempotencyKey, "status", existing.GetString("status"))
+	return handle, nil
+}
+
+// findDuplicateJob returns the job holding the uniqueness key: a queued, processing or failed job,
+// or a job completed less than window ago
+func findDuplicateJob(app core.App, key string, window time.Duration) *core.Record {
+	filter := "idempotency_key = {:key} && (status = {:queued} || status = {:processing} || status = {:failed})"
+	params := dbx.Params{
+		"key":        key,
+		"queued":     JobStatusQueued,
+		"processing": JobStatusProcessing,
+		"failed":     JobStatusFailed,
+	}
+
+	if window > 0 {
+		filter = "idempotency_key = {:key} && (status = {:queued} || status = {:processing} || status = {:failed} || " +
+			"(status = {:completed} && completed_at >= {:completed_since}))"
+		params["completed"] = JobStatusCompleted
+		since, _ := types.ParseDateTime(time.Now().Add(-window))
+		params["completed_since"] = since.String()
+	}
+
+	record, err := app.FindFirstRecordByFilter(QueuesCollection, filter, params)
+	if err != nil {
+		return nil
+	}
+	return record
+}
@@ -0,0 +1,175 @@
+package jobutils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/cronutils"
+)
+
+type MockValidatingJobHandler struct {
+	jobType     string
+	validateErr error
+}
+
+func (m *MockValidatingJobHandler) Handle(ctx *cronutils.CronExecutionContext, job *JobData) error {
+	return nil
+}
+
+func (m *MockValidatingJobHandler) GetJobType() string {
+	return m.jobType
+}
+
+func (m *MockValidatingJobHandler) ValidatePayload(job *JobData) error {
+	return m.validateErr
+}
+
+func TestToPayloadMap(t *testing.T) {
+	if _, err := toPayloadMap(nil); err == nil {
+		t.Error("expected error for nil payload")
+	}
+
+	payload := EmailJobPayload{
+		Type: JobTypeEmail,
+		Data: EmailJobData{To: "user@example.com", Subject: "Hello"},
+	}
+
+	payloadMap, err := toPayloadMap(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if payloadMap["type"] != JobTypeEmail {
+		t.Errorf("expected type %q, got %v", JobTypeEmail, payloadMap["type"])
+	}
+
+	data, ok := payloadMap["data"].(map[string]any)
+	if !ok || data["to"] != "user@example.com" {
+		t.Errorf("expected data.to to be preserved, got %v", payloadMap["data"])
+	}
+
+	if _, err := toPayloadMap([]string{"not", "an", "object"}); err == nil {
+		t.Error("expected error for non-object payload")
+	}
+}
+
+func TestValidateEnqueuePayload(t *testing.T) {
+	registry := NewJobRegistry()
+	_ = registry.Register(&MockJobHandler{jobType: "plain_job"})
+	_ = registry.Register(&MockValidatingJobHandler{jobType: "valid_job"})
+	_ = registry.Register(&MockValidatingJobHandler{jobType: "rejecting_job", validateErr: errors.New("missing recipient")})
+
+	tests := []struct {
+		name        string
+		payload     map[string]any
+		expectError string
+	}{
+		{name: "missing type", payload: map[string]any{}, expectError: "'type' field"},
+		{name: "unregistered type", payload: map[string]any{"type": "unknown_job"}, expectError: "no handler registered"},
+		{name: "handler without validator", payload: map[string]any{"type": "plain_job"}},
+		{name: "validator accepts", payload: map[string]any{"type": "valid_job"}},
+		{name: "validator rejects", payload: map[string]any{"type": "rejecting_job"}, expectError: "missing recipient"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateEnqueuePayload(tt.payload, &EnqueueOptions{Registry: registry})
+
+			if tt.expectError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestEnqueueOptions(t *testing.T) {
+	runAt := time.Now().Add(time.Hour)
+	registry := NewJobRegistry()
+
+	options := &EnqueueOptions{}
+	for _, opt := range []EnqueueOption{
+		WithName("Report"),
+		WithDescription("Nightly report"),
+		WithQueue("reports"),
+		WithPriority(10),
+		WithDelay(time.Minute),
+		WithRunAt(runAt),
+		WithMaxAttempts(5),
+		WithIdempotencyKey("report:2025-01-01"),
+		WithRegistry(registry),
+	} {
+		opt(options)
+	}
+
+	if options.Name != "Report" || options.Description != "Nightly report" || options.Queue != "reports" {
+		t.Errorf("name, description or queue not applied: %+v", options)
+	}
+	if options.Priority != 10 || options.Delay != time.Minute || !options.RunAt.Equal(runAt) {
+		t.Errorf("priority or scheduling not applied: %+v", options)
+	}
+	if options.MaxAttempts != 5 || options.IdempotencyKey != "report:2025-01-01" || options.Registry != registry {
+		t.Errorf("max attempts, idempotency key or registry not applied: %+v", options)
+	}
+}
+
+func TestUniqueKeyOptions(t *testing.T) {
+	options := &EnqueueOptions{}
+	for _, opt := range []EnqueueOption{
+		WithUniqueKey("welcome-email:user-1", time.Hour),
+		WithRejectDuplicate(),
+	} {
+		opt(options)
+	}
+
+	if options.IdempotencyKey != "welcome-email:user-1" || options.UniqueWindow != time.Hour {
+		t.Errorf("unique key or window not applied: %+v", options)
+	}
+	if !options.RejectDuplicate {
+		t.Error("expected duplicates to be rejected")
+	}
+}
+
+func TestDuplicateJobHandle(t *testing.T) {
+	existing := newTestQueueRecord()
+	existing.Id = "existing-job"
+	existing.Set("status", JobStatusCompleted)
+
+	tests := []struct {
+		name      string
+		reject    bool
+		expectErr bool
+	}{
+		{name: "collapse", reject: false},
+		{name: "reject", reject: true, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &EnqueueOptions{IdempotencyKey: "export:user-1", RejectDuplicate: tt.reject}
+
+			handle, err := duplicateJobHandle(nil, existing, options)
+
+			if tt.expectErr != errors.Is(err, ErrDuplicateJob) {
+				t.Errorf("expected ErrDuplicateJob %v, got %v", tt.expectErr, err)
+			}
+			if handle == nil || handle.ID != existing.Id || !handle.Existing {
+				t.Errorf("expected the handle of the existing job, got %+v", handle)
+			}
+		})
+	}
+}
+
+func TestEnqueueWithNilApp(t *testing.T) {
+	if _, err := Enqueue(nil, map[string]any{"type": "test_job"}); err == nil {
+		t.Error("expected error for nil app")
+	}
+}
@@ -2,6 +2,8 @@ package jobutils
 
 import (
 	"fmt"
+	"mime"
+	"path/filepath"
 	"time"
 
 	"ims-pocketbase-baas-starter/pkg/common"
@@ -17,18 +19,47 @@ const (
 	DefaultFileExpirationDays = 30
 )
 
+// Constants for the files uploaded for imports
+const (
+	ImportFilesCollectionName       = "import_files"
+	DefaultImportFileExpirationDays = 7
+)
+
 // SaveExportFile saves file data to the export_files collection
 func SaveExportFile(app *pocketbase.PocketBase, jobId, filename string, fileData []byte, recordCount int) (*core.Record, error) {
-	return saveExportFile(app, jobId, "", filename, fileData, recordCount)
+	return SaveExportFileWithContentType(app, jobId, "", filename, mime.TypeByExtension(filepath.Ext(filename)), fileData, recordCount)
 }
 
 // SaveExportFileWithUser saves file data to the export_files collection with a specific user ID
 func SaveExportFileWithUser(app *pocketbase.PocketBase, jobId, userId, filename string, fileData []byte, recordCount int) (*core.Record, error) {
-	return saveExportFile(app, jobId, userId, filename, fileData, recordCount)
+	return SaveExportFileWithContentType(app, jobId, userId, filename, mime.TypeByExtension(filepath.Ext(filename)), fileData, recordCount)
+}
+
+// SaveExportFileWithContentType saves file data to the export_files collection with a specific user ID
+// and the content type the file is downloaded with
+func SaveExportFileWithContentType(app *pocketbase.PocketBase, jobId, userId, filename, contentType string, fileData []byte, recordCount int) (*core.Record, error) {
+	file, err := filesystem.NewFileFromBytes(fileData, filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file from data for job %s: %w", jobId, err)
+	}
+
+	return saveExportFile(app, jobId, userId, contentType, file, recordCount, time.Time{})
+}
+
+// SaveExportFileFromPath saves a local file to the export_files collection without loading it in memory;
+// the file is named after the last element of filePath. The file is streamed to the storage (local or S3),
+// and the caller removes it once saved. A zero expiresAt keeps the file EXPORT_FILE_EXPIRATION_DAYS days.
+func SaveExportFileFromPath(app *pocketbase.PocketBase, jobId, userId, filePath, contentType string, recordCount int, expiresAt time.Time) (*core.Record, error) {
+	file, err := filesystem.NewFileFromPath(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export file for job %s: %w", jobId, err)
+	}
+
+	return saveExportFile(app, jobId, userId, contentType, file, recordCount, expiresAt)
 }
 
 // saveExportFile is the internal implementation for saving export files
-func saveExportFile(app *pocketbase.PocketBase, jobId, userId, filename string, fileData []byte, recordCount int) (*core.Record, error) {
+func saveExportFile(app *pocketbase.PocketBase, jobId, userId, contentType string, file *filesystem.File, recordCount int, expiresAt time.Time) (*core.Record, error) {
 	collection, err := app.FindCollectionByNameOrId(ExportFilesCollectionName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find export_files collection for job %s: %w", jobId, err)
@@ -36,23 +67,46 @@ func saveExportFile(app *pocketbase.PocketBase, jobId, userId, filename string,
 
 	record := core.NewRecord(collection)
 
-	expirationDays := common.GetEnvInt("EXPORT_FILE_EXPIRATION_DAYS", DefaultFileExpirationDays)
-	expirationDate := time.Now().AddDate(0, 0, expirationDays)
+	if expiresAt.IsZero() {
+		expirationDays := common.GetEnvInt("EXPORT_FILE_EXPIRATION_DAYS", DefaultFileExpirationDays)
+		expiresAt = time.Now().AddDate(0, 0, expirationDays)
+	}
 
 	record.Set("job_id", jobId)
 	record.Set("user_id", userId)
+	record.Set("content_type", contentType)
 	record.Set("record_count", recordCount)
-	record.Set("expires_at", expirationDate)
+	record.Set("expires_at", expiresAt)
 
-	file, err := filesystem.NewFileFromBytes(fileData, filename)
+	record.Set("file", file)
+
+	if err := app.Save(record); err != nil {
+		return nil, fmt.Errorf("failed to save export_files record for job %s: %w", jobId, err)
+	}
+
+	return record, nil
+}
+
+// SaveImportFile saves a file uploaded for an import into collection to the import_files collection
+// (userId is empty for the uploads of superusers). The upload is kept IMPORT_FILE_EXPIRATION_DAYS days,
+// so a failed import can be retried.
+func SaveImportFile(app core.App, userId, collection, format string, file *filesystem.File) (*core.Record, error) {
+	importFiles, err := app.FindCollectionByNameOrId(ImportFilesCollectionName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create file from data for job %s: %w", jobId, err)
+		return nil, fmt.Errorf("failed to find import_files collection: %w", err)
 	}
 
+	expirationDays := common.GetEnvInt("IMPORT_FILE_EXPIRATION_DAYS", DefaultImportFileExpirationDays)
+
+	record := core.NewRecord(importFiles)
+	record.Set("user_id", userId)
+	record.Set("collection", collection)
+	record.Set("format", format)
 	record.Set("file", file)
+	record.Set("expires_at", time.Now().AddDate(0, 0, expirationDays))
 
 	if err := app.Save(record); err != nil {
-		return nil, fmt.Errorf("failed to save export_files record for job %s: %w", jobId, err)
+		return nil, fmt.Errorf("failed to save import_files record: %w", err)
 	}
 
 	return record, nil
@@ -0,0 +1,658 @@
+package jobutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/common"
+	"ims-pocketbase-baas-starter/pkg/cronutils"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	// maxLastErrorLength keeps stored error messages within the text field limit
+	maxLastErrorLength = 2000
+
+	// leaseTokenLength is the length of the random token identifying a job reservation
+	leaseTokenLength = 32
+
+	// maxErrorHistoryEntries is how many failed attempts are kept in the error history of a job
+	maxErrorHistoryEntries = 20
+)
+
+var (
+	// ErrJobNotClaimed is returned when a job could not be claimed because another worker holds
+	// an active reservation or the job is no longer pending
+	ErrJobNotClaimed = errors.New("job not claimed")
+
+	// ErrLeaseLost is returned when a worker tries to complete or fail a job whose reservation
+	// expired and was claimed by another worker in the meantime
+	ErrLeaseLost = errors.New("job lease lost")
+
+	// ErrJobTimeout is the failure of a job that exceeded its deadline
+	ErrJobTimeout = errors.New("job timed out")
+
+	// ErrJobCanceled is returned for a job interrupted by shutdown; it is released, not failed
+	ErrJobCanceled = errors.New("job canceled by shutdown")
+
+	// ErrJobCancelRequested interrupts a running job that was canceled through CancelJob
+	ErrJobCancelRequested = errors.New("job canceled by request")
+
+	// activeLeases holds the lease tokens of the jobs this process is currently running (job id -> token)
+	activeLeases sync.Map
+)
+
+// PermanentError marks a job failure that must not be retried
+type PermanentError struct {
+	Err error
+}
+
+// Error returns the wrapped error message
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// NewPermanentError wraps err so the job is moved straight to the dead-letter set
+func NewPermanentError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanentError reports whether err (or any error it wraps) is a PermanentError
+func IsPermanentError(err error) bool {
+	var permanentErr *PermanentError
+	return errors.As(err, &permanentErr)
+}
+
+// panicError is the failure of a job whose handler panicked
+type panicError struct {
+	value any
+}
+
+// Error returns the panic value as an error message
+func (e *panicError) Error() string {
+	return fmt.Sprintf("job handler panicked: %v", e.value)
+}
+
+// GetJobTimeout returns how long a job may run: options.timeout (seconds) from the payload,
+// else the handler's default timeout, else JOB_TIMEOUT_SECONDS
+func GetJobTimeout(job *JobData, handler JobHandler) time.Duration {
+	if options, ok := job.Payload["options"].(map[string]any); ok {
+		if seconds, ok := options["timeout"].(float64); ok && seconds > 0 {
+			return time.Duration(seconds * float64(time.Second))
+		}
+	}
+
+	if provider, ok := handler.(JobTimeoutProvider); ok {
+		if timeout := provider.DefaultTimeout(); timeout > 0 {
+			return timeout
+		}
+	}
+
+	seconds := common.GetEnvInt("JOB_TIMEOUT_SECONDS", DefaultJobTimeoutSeconds)
+	if seconds <= 0 {
+		seconds = DefaultJobTimeoutSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// failureReason classifies a job error for the failure_reason field
+func failureReason(err error) string {
+	var panicErr *panicError
+
+	switch {
+	case errors.Is(err, ErrJobTimeout):
+		return JobFailureTimeout
+	case errors.As(err, &panicErr):
+		return JobFailurePanic
+	case IsPermanentError(err):
+		return JobFailurePermanent
+	default:
+		return JobFailureError
+	}
+}
+
+// GetMaxAttempts returns the maximum attempts for a job record, falling back to JOB_MAX_RETRIES
+func GetMaxAttempts(record *core.Record) int {
+	if maxAttempts := record.GetInt("max_attempts"); maxAttempts > 0 {
+		return maxAttempts
+	}
+
+	if maxAttempts := common.GetEnvInt("JOB_MAX_RETRIES", DefaultMaxAttempts); maxAttempts > 0 {
+		return maxAttempts
+	}
+
+	return DefaultMaxAttempts
+}
+
+// GetShutdownGracePeriod returns how long running jobs may take to finish when the app terminates
+func GetShutdownGracePeriod() time.Duration {
+	seconds := common.GetEnvInt("JOB_SHUTDOWN_GRACE_SECONDS", DefaultShutdownGraceSeconds)
+	if seconds < 0 {
+		seconds = DefaultShutdownGraceSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetHandlerGracePeriod returns how long a job handler may keep running once its job timed out, was
+// canceled or the app is shutting down, before the job is settled without waiting for it
+func GetHandlerGracePeriod() time.Duration {
+	seconds := common.GetEnvInt("JOB_HANDLER_GRACE_SECONDS", DefaultHandlerGraceSeconds)
+	if seconds < 0 {
+		seconds = DefaultHandlerGraceSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetReservationTimeout returns how long a reservation is honoured before the job can be reclaimed
+func GetReservationTimeout() time.Duration {
+	minutes := common.GetEnvInt("JOB_RESERVATION_TIMEOUT", DefaultReservationTimeoutMinutes)
+	if minutes <= 0 {
+		minutes = DefaultReservationTimeoutMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// nextFailureStatus decides whether a failed job is retried or moved to the dead-letter set
+func nextFailureStatus(attempts, maxAttempts int, permanent bool) string {
+	if permanent || attempts >= maxAttempts {
+		return JobStatusDead
+	}
+	return JobStatusFailed
+}
+
+// truncateError converts an error into a message that fits the last_error field
+func truncateError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	message := err.Error()
+	if len(message) > maxLastErrorLength {
+		message = message[:maxLastErrorLength]
+	}
+	return message
+}
+
+// isJobReserved reports whether the record holds a reservation that has not expired yet
+func isJobReserved(record *core.Record) bool {
+	reservedAt := record.GetDateTime("reserved_at")
+	if reservedAt.IsZero() {
+		return false
+	}
+
+	return time.Since(reservedAt.Time()) < GetReservationTimeout()
+}
+
+// claimJob atomically moves a pending job into the processing state and returns the lease token
+// the caller must present to complete or fail it. The conditional UPDATE only matches when the job
+// is still pending and its reservation is empty or stale, so when several workers or server
+// instances race for the same job exactly one of them wins; the others get ErrJobNotClaimed.
+func claimJob(app core.App, record *core.Record) (string, error) {
+	if isJobReserved(record) {
+		return "", fmt.Errorf("%w: job %s is already reserved", ErrJobNotClaimed, record.Id)
+	}
+
+	now := types.NowDateTime()
+	expired := now.Add(-GetReservationTimeout())
+	token := security.RandomString(leaseTokenLength)
+
+	result, err := app.NonconcurrentDB().Update(
+		QueuesCollection,
+		dbx.Params{
+			"status":           JobStatusProcessing,
+			"reserved_at":      now.String(),
+			"lease_token":      token,
+			"started_at":       now.String(),
+			"progress":         0,
+			"progress_message": "",
+			"updated":          now.String(),
+		},
+		dbx.NewExp(
+			"[[id]] = {:id} AND [[status]] NOT IN ({:completed}, {:dead}, {:canceled}) AND ([[reserved_at]] = '' OR [[reserved_at]] < {:expired})",
+			dbx.Params{
+				"id":        record.Id,
+				"completed": JobStatusCompleted,
+				"dead":      JobStatusDead,
+				"canceled":  JobStatusCanceled,
+				"expired":   expired.String(),
+			},
+		),
+	).Execute()
+	if err != nil {
+		return "", fmt.Errorf("failed to reserve job %s: %w", record.Id, err)
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return "", fmt.Errorf("failed to reserve job %s: %w", record.Id, err)
+	}
+	if claimed == 0 {
+		return "", fmt.Errorf("%w: job %s is reserved by another worker or no longer pending", ErrJobNotClaimed, record.Id)
+	}
+
+	// keep the in-memory record in sync with the row we just claimed
+	record.Set("status", JobStatusProcessing)
+	record.Set("reserved_at", now)
+	record.Set("lease_token", token)
+	record.Set("started_at", now)
+	record.Set("progress", 0)
+	record.Set("progress_message", "")
+	record.Set("updated", now)
+
+	return token, nil
+}
+
+// releaseJob hands a claimed job back to the queue without counting an attempt,
+// provided the lease token still matches. It reports whether the job was released.
+func releaseJob(app core.App, jobId string, leaseToken string) (bool, error) {
+	now := types.NowDateTime()
+
+	result, err := app.NonconcurrentDB().Update(
+		QueuesCollection,
+		dbx.Params{
+			"status":      JobStatusQueued,
+			"reserved_at": "",
+			"lease_token": "",
+			"updated":     now.String(),
+		},
+		dbx.NewExp("[[id]] = {:id} AND [[lease_token]] = {:token}", dbx.Params{"id": jobId, "token": leaseToken}),
+	).Execute()
+	if err != nil {
+		return false, fmt.Errorf("failed to release job %s: %w", jobId, err)
+	}
+
+	released, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to release job %s: %w", jobId, err)
+	}
+
+	return released > 0, nil
+}
+
+// ReleaseActiveJobs releases the reservation of every job this process is still running, so other
+// server instances can pick them up right away instead of waiting for the reservation timeout.
+// It is meant to be called on shutdown, once the grace period for running jobs has expired.
+func ReleaseActiveJobs(app core.App) (int, error) {
+	released := 0
+	var errs []error
+
+	activeLeases.Range(func(key, value any) bool {
+		jobId, leaseToken := key.(string), value.(string)
+
+		ok, err := releaseJob(app, jobId, leaseToken)
+		if err != nil {
+			errs = append(errs, err)
+			return true
+		}
+
+		activeLeases.CompareAndDelete(jobId, leaseToken)
+		if ok {
+			released++
+			log.Warn("Released reservation of unfinished job", "job_id", jobId)
+		}
+		return true
+	})
+
+	return released, errors.Join(errs...)
+}
+
+// saveWithLease saves the job record only if the lease token stored in the database still
+// matches, i.e. the reservation has not expired and been claimed by another worker meanwhile
+func saveWithLease(app core.App, record *core.Record, leaseToken string) error {
+	return app.RunInTransaction(func(txApp core.App) error {
+		current, err := txApp.FindRecordById(QueuesCollection, record.Id)
+		if err != nil {
+			return err
+		}
+
+		if leaseToken == "" || current.GetString("lease_token") != leaseToken {
+			return ErrLeaseLost
+		}
+
+		return txApp.Save(record)
+	})
+}
+
+// completeJob marks a job as completed so it is kept until the retention window expires.
+// The next job of its chain is queued in the same transaction, so the batch of the job
+// never looks finished in between.
+func completeJob(app core.App, record *core.Record, leaseToken string) error {
+	record.Set("status", JobStatusCompleted)
+	record.Set("reserved_at", "")
+	record.Set("lease_token", "")
+	record.Set("progress", 100)
+	record.Set("completed_at", types.NowDateTime())
+
+	err := app.RunInTransaction(func(txApp core.App) error {
+		if err := saveWithLease(txApp, record, leaseToken); err != nil {
+			return err
+		}
+		return enqueueNextInChain(txApp, record)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete job %s: %w", record.Id, err)
+	}
+
+	return nil
+}
+
+// failJob records a failed attempt and moves the job to failed (retryable) or dead.
+// Retryable jobs are pushed back with exponential backoff via available_at.
+func failJob(app core.App, record *core.Record, leaseToken string, jobErr error) error {
+	attempts := record.GetInt("attempts") + 1
+	maxAttempts := GetMaxAttempts(record)
+	status := nextFailureStatus(attempts, maxAttempts, IsPermanentError(jobErr))
+	lastError := truncateError(jobErr)
+	reason := failureReason(jobErr)
+
+	record.Set("attempts", attempts)
+	record.Set("status", status)
+	record.Set("last_error", lastError)
+	record.Set("failure_reason", reason)
+	record.Set("error_history", appendErrorHistory(GetErrorHistory(record), JobErrorEntry{
+		Attempt:       attempts,
+		Error:         lastError,
+		FailureReason: reason,
+		FailedAt:      time.Now().UTC(),
+	}))
+	record.Set("reserved_at", "")
+	record.Set("lease_token", "")
+	record.Set("result", nil)
+
+	var retryDelay time.Duration
+	if status == JobStatusFailed {
+		retryDelay = GetBackoffConfig().Delay(attempts)
+		record.Set("available_at", types.NowDateTime().Add(retryDelay))
+	}
+
+	if err := saveWithLease(app, record, leaseToken); err != nil {
+		return fmt.Errorf("failed to update failed job %s: %w", record.Id, err)
+	}
+
+	if status == JobStatusDead {
+		log.Error("Job moved to dead-letter set", "job_id", record.Id, "job_name", record.GetString("name"), "attempts", attempts, "max_attempts", maxAttempts, "error", jobErr)
+	} else {
+		log.Warn("Job failed and will be retried", "job_id", record.Id, "job_name", record.GetString("name"), "attempts", attempts, "max_attempts", maxAttempts, "retry_in", retryDelay.String(), "error", jobErr)
+	}
+
+	return nil
+}
+
+// GetErrorHistory returns the failed attempts recorded on a job, oldest first
+func GetErrorHistory(record *core.Record) []JobErrorEntry {
+	history := []JobErrorEntry{}
+	if err := record.UnmarshalJSONField("error_history", &history); err != nil {
+		return []JobErrorEntry{}
+	}
+	return history
+}
+
+// appendErrorHistory adds a failed attempt to the history, dropping the oldest entries
+// once it holds more than maxErrorHistoryEntries
+func appendErrorHistory(history []JobErrorEntry, entry JobErrorEntry) []JobErrorEntry {
+	history = append(history, entry)
+	if len(history) > maxErrorHistoryEntries {
+		history = history[len(history)-maxErrorHistoryEntries:]
+	}
+	return history
+}
+
+// runJob executes the complete lifecycle of a single queue record: claim, dispatch to
+// the registered handler within the job deadline, then mark the job as completed, failed or dead.
+// Cancelling ctx (app shutdown) releases the job instead of failing it. Jobs canceled through
+// CancelJob, or whose lease was taken over meanwhile, are interrupted and left as they are.
+// An interrupted job is only failed or released once its handler returned: a handler still running
+// after the grace period keeps the job leased, and the job is settled in the background once it returns.
+// logAttrs are appended to every log line (e.g. the worker id).
+func runJob(ctx context.Context, app *pocketbase.PocketBase, registry *JobRegistry, record *core.Record, logAttrs ...any) error {
+	if record == nil || record.Id == "" || record.Collection().Name != QueuesCollection {
+		return fmt.Errorf("invalid job record")
+	}
+
+	leaseToken, err := claimJob(app, record)
+	if err != nil {
+		return err
+	}
+
+	activeLeases.Store(record.Id, leaseToken)
+	// the claim bypasses the record hooks, so owners are notified of the new state here
+	PublishJobStatus(app, record)
+
+	runCtx, cancelRun := context.WithCancelCause(ctx)
+	reporter := trackRunningJob(app, record, leaseToken, cancelRun)
+
+	// the run is cleaned up once settled, which is after runJob returned for an overrunning handler
+	cleanups := []func(){
+		func() { activeLeases.CompareAndDelete(record.Id, leaseToken) },
+		func() { cancelRun(nil) },
+		reporter.close,
+	}
+	cleanup := func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+	}
+	detached := false
+	defer func() {
+		if !detached {
+			cleanup()
+		}
+	}()
+
+	jobData, err := ParseJobDataFromRecord(record)
+	if err != nil {
+		return abortJob(app, record, leaseToken, NewPermanentError(fmt.Errorf("failed to parse job data: %w", err)), logAttrs)
+	}
+
+	if err := ValidateJobPayload(jobData.Payload); err != nil {
+		return abortJob(app, record, leaseToken, NewPermanentError(fmt.Errorf("invalid job payload: %w", err)), logAttrs)
+	}
+
+	handler, err := registry.GetHandler(jobData.Type)
+	if err != nil {
+		return abortJob(app, record, leaseToken, NewPermanentError(fmt.Errorf("no handler found for job type '%s': %w", jobData.Type, err)), logAttrs)
+	}
+
+	stopRenewal := renewLease(app, record.Id, leaseToken, func() { cancelRun(ErrLeaseLost) })
+	cleanups = append(cleanups, stopRenewal)
+
+	timeout := GetJobTimeout(jobData, handler)
+	jobCtx, cancel := context.WithTimeout(runCtx, timeout)
+	cleanups = append(cleanups, cancel)
+
+	execCtx := cronutils.NewCronExecutionContext(app, record.Id)
+	execCtx.LogStart(fmt.Sprintf("Processing %s job: %s", jobData.Type, jobData.Name))
+
+	jobErr := executeHandler(jobCtx, execCtx, handler, jobData)
+
+	var overrun *handlerOverrunError
+	if errors.As(jobErr, &overrun) {
+		// failing or releasing the job now would let another worker run it next to this handler:
+		// the lease keeps being renewed until the handler returns
+		log.Error("Job handler still running after its grace period, keeping the job leased until it returns",
+			append([]any{"job_id", record.Id, "grace_period", GetHandlerGracePeriod().String(), "error", overrun.err}, logAttrs...)...)
+
+		detached = true
+		go func() {
+			defer cleanup()
+			settleRun(ctx, runCtx, jobCtx, app, record, leaseToken, execCtx, jobData, timeout, reporter, <-overrun.done, logAttrs)
+		}()
+		return jobErr
+	}
+
+	return settleRun(ctx, runCtx, jobCtx, app, record, leaseToken, execCtx, jobData, timeout, reporter, jobErr, logAttrs)
+}
+
+// settleRun marks a job whose handler returned jobErr as completed, failed or dead, or releases it
+// when the app is shutting down. Jobs interrupted by a cancellation or a lost lease are left as they are.
+func settleRun(ctx, runCtx, jobCtx context.Context, app *pocketbase.PocketBase, record *core.Record, leaseToken string, execCtx *cronutils.CronExecutionContext, jobData *JobData, timeout time.Duration, reporter *jobReporter, jobErr error, logAttrs []any) error {
+	// the record is completed or failed below, handlers must not report to it anymore
+	reporter.close()
+
+	if jobErr != nil && jobCtx.Err() != nil {
+		if ctx.Err() != nil {
+			// the app is shutting down: hand the job back instead of counting a failed attempt
+			if _, err := releaseJob(app, record.Id, leaseToken); err != nil {
+				log.Error("Failed to release job canceled by shutdown", append([]any{"job_id", record.Id, "error", err}, logAttrs...)...)
+			}
+			log.Warn("Job canceled by shutdown and released", append([]any{"job_id", record.Id}, logAttrs...)...)
+			return fmt.Errorf("%w: job %s", ErrJobCanceled, record.Id)
+		}
+
+		if cause := context.Cause(runCtx); cause != nil {
+			// canceled by request or reclaimed by another worker: the record is no longer ours to update
+			log.Warn("Job interrupted", append([]any{"job_id", record.Id, "reason", cause}, logAttrs...)...)
+			return fmt.Errorf("%w: job %s", cause, record.Id)
+		}
+
+		jobErr = fmt.Errorf("%w after %s", ErrJobTimeout, timeout)
+	}
+
+	if jobErr != nil {
+		execCtx.LogError(jobErr, "Job processing failed")
+		return abortJob(app, record, leaseToken, jobErr, logAttrs)
+	}
+
+	execCtx.LogEnd("Job processed successfully")
+
+	if err := completeJob(app, record, leaseToken); err != nil {
+		if errors.Is(err, ErrLeaseLost) {
+			log.Warn("Job finished after its lease was lost, result discarded", append([]any{"job_id", record.Id}, logAttrs...)...)
+			return err
+		}
+		log.Error("Failed to mark job as completed", append([]any{"job_id", record.Id, "error", err}, logAttrs...)...)
+		return err
+	}
+
+	log.Info("Job completed", append([]any{"job_id", record.Id, "job_name", jobData.Name, "job_type", jobData.Type}, logAttrs...)...)
+	settleFinishedJob(app, record)
+	return nil
+}
+
+// handlerOverrunError is returned by executeHandler for a handler still running after the grace period
+// that followed the end of its context. done receives the error of the handler once it returns.
+type handlerOverrunError struct {
+	err  error
+	done <-chan error
+}
+
+func (e *handlerOverrunError) Error() string {
+	return fmt.Sprintf("job handler still running after its grace period: %v", e.err)
+}
+
+func (e *handlerOverrunError) Unwrap() error {
+	return e.err
+}
+
+// executeHandler runs the job handler and returns its error. Once ctx is done the handler gets
+// JOB_HANDLER_GRACE_SECONDS to return (handlers that do not implement ContextJobHandler cannot be
+// interrupted and keep running until they finish); a handler still running after it is reported with
+// a *handlerOverrunError, so the job is not run again while the handler runs.
+func executeHandler(ctx context.Context, execCtx *cronutils.CronExecutionContext, handler JobHandler, job *JobData) error {
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err := &panicError{value: r}
+				execCtx.LogError(err, "Job handler panic recovered")
+				done <- err
+			}
+		}()
+
+		if contextHandler, ok := handler.(ContextJobHandler); ok {
+			done <- contextHandler.HandleContext(ctx, execCtx, job)
+			return
+		}
+		done <- handler.Handle(execCtx, job)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	grace := time.NewTimer(GetHandlerGracePeriod())
+	defer grace.Stop()
+
+	select {
+	case err := <-done:
+		// a nil error means the handler finished its work after all
+		return err
+	case <-grace.C:
+		return &handlerOverrunError{err: ctx.Err(), done: done}
+	}
+}
+
+// renewLease keeps extending the reservation of a running job, so jobs running longer than the
+// reservation timeout are not reclaimed by another worker. onLost is called once the lease no
+// longer matches (e.g. the job was canceled on another instance). The returned func stops the renewal.
+func renewLease(app core.App, jobId string, leaseToken string, onLost func()) func() {
+	stop := make(chan struct{})
+	ticker := time.NewTicker(GetReservationTimeout() / 2)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				result, err := app.NonconcurrentDB().Update(
+					QueuesCollection,
+					dbx.Params{"reserved_at": types.NowDateTime().String()},
+					dbx.NewExp("[[id]] = {:id} AND [[lease_token]] = {:token}", dbx.Params{"id": jobId, "token": leaseToken}),
+				).Execute()
+				if err != nil {
+					log.Warn("Failed to renew job lease", "job_id", jobId, "error", err)
+					continue
+				}
+				if renewed, err := result.RowsAffected(); err == nil && renewed == 0 {
+					log.Warn("Job lease lost, interrupting job", "job_id", jobId)
+					if onLost != nil {
+						onLost()
+					}
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(stop) }) }
+}
+
+// abortJob stores the failure on the record, settles the batch of a dead job and returns the original job error
+func abortJob(app core.App, record *core.Record, leaseToken string, jobErr error, logAttrs []any) error {
+	if err := failJob(app, record, leaseToken, jobErr); err != nil {
+		if errors.Is(err, ErrLeaseLost) {
+			log.Warn("Job failed after its lease was lost, failure discarded", append([]any{"job_id", record.Id, "error", jobErr}, logAttrs...)...)
+			return jobErr
+		}
+		log.Error("Failed to mark job as failed", append([]any{"job_id", record.Id, "error", err}, logAttrs...)...)
+		return jobErr
+	}
+
+	if record.GetString("status") == JobStatusDead {
+		settleFinishedJob(app, record)
+	}
+	return jobErr
+}
@@ -0,0 +1,363 @@
+package jobutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/cronutils"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func newTestQueueRecord() *core.Record {
+	collection := core.NewBaseCollection(QueuesCollection)
+	collection.Fields.Add(
+		&core.TextField{Name: "name"},
+		&core.TextField{Name: "description"},
+		&core.TextField{Name: "queue"},
+		&core.NumberField{Name: "priority"},
+		&core.TextField{Name: "idempotency_key"},
+		&core.TextField{Name: "user_id"},
+		&core.TextField{Name: "batch_id"},
+		&core.TextField{Name: "parent_id"},
+		&core.JSONField{Name: "chain"},
+		&core.JSONField{Name: "payload"},
+		&core.NumberField{Name: "attempts"},
+		&core.NumberField{Name: "max_attempts"},
+		&core.DateField{Name: "reserved_at"},
+		&core.TextField{Name: "lease_token"},
+		&core.SelectField{Name: "status", MaxSelect: 1, Values: []string{
+			JobStatusQueued, JobStatusProcessing, JobStatusCompleted, JobStatusFailed, JobStatusDead, JobStatusCanceled,
+		}},
+		&core.TextField{Name: "last_error"},
+		&core.TextField{Name: "failure_reason"},
+		&core.JSONField{Name: "error_history"},
+		&core.NumberField{Name: "progress"},
+		&core.TextField{Name: "progress_message"},
+		&core.JSONField{Name: "result"},
+		&core.DateField{Name: "started_at"},
+		&core.DateField{Name: "completed_at"},
+	)
+	return core.NewRecord(collection)
+}
+
+func TestNextFailureStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		attempts    int
+		maxAttempts int
+		permanent   bool
+		expected    string
+	}{
+		{name: "first failure is retried", attempts: 1, maxAttempts: 3, expected: JobStatusFailed},
+		{name: "last allowed attempt goes dead", attempts: 3, maxAttempts: 3, expected: JobStatusDead},
+		{name: "attempts over the limit go dead", attempts: 5, maxAttempts: 3, expected: JobStatusDead},
+		{name: "permanent errors go dead immediately", attempts: 1, maxAttempts: 3, permanent: true, expected: JobStatusDead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextFailureStatus(tt.attempts, tt.maxAttempts, tt.permanent); got != tt.expected {
+				t.Errorf("expected status %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPermanentError(t *testing.T) {
+	if NewPermanentError(nil) != nil {
+		t.Error("NewPermanentError(nil) should return nil")
+	}
+
+	baseErr := errors.New("invalid payload")
+	permanentErr := NewPermanentError(baseErr)
+
+	if !IsPermanentError(permanentErr) {
+		t.Error("expected error to be permanent")
+	}
+
+	if !IsPermanentError(fmt.Errorf("wrapped: %w", permanentErr)) {
+		t.Error("expected wrapped permanent error to be detected")
+	}
+
+	if !errors.Is(permanentErr, baseErr) {
+		t.Error("permanent error should unwrap to the original error")
+	}
+
+	if IsPermanentError(baseErr) {
+		t.Error("plain errors should not be permanent")
+	}
+}
+
+func TestTruncateError(t *testing.T) {
+	if truncateError(nil) != "" {
+		t.Error("nil error should produce an empty message")
+	}
+
+	long := errors.New(strings.Repeat("x", maxLastErrorLength+100))
+	if got := truncateError(long); len(got) != maxLastErrorLength {
+		t.Errorf("expected message of length %d, got %d", maxLastErrorLength, len(got))
+	}
+}
+
+func TestGetMaxAttempts(t *testing.T) {
+	record := newTestQueueRecord()
+
+	os.Unsetenv("JOB_MAX_RETRIES")
+	if got := GetMaxAttempts(record); got != DefaultMaxAttempts {
+		t.Errorf("expected default max attempts %d, got %d", DefaultMaxAttempts, got)
+	}
+
+	os.Setenv("JOB_MAX_RETRIES", "7")
+	defer os.Unsetenv("JOB_MAX_RETRIES")
+	if got := GetMaxAttempts(record); got != 7 {
+		t.Errorf("expected env max attempts 7, got %d", got)
+	}
+
+	record.Set("max_attempts", 2)
+	if got := GetMaxAttempts(record); got != 2 {
+		t.Errorf("expected record max attempts 2, got %d", got)
+	}
+}
+
+func TestParseJobDataFromRecordLifecycleFields(t *testing.T) {
+	record := newTestQueueRecord()
+	record.Id = "job123456789012"
+	record.Set("name", "Test job")
+	record.Set("payload", `{"type":"test_job"}`)
+	record.Set("status", JobStatusFailed)
+	record.Set("attempts", 2)
+	record.Set("max_attempts", 4)
+	record.Set("last_error", "smtp unavailable")
+	record.Set("reserved_at", "2025-01-01 10:00:00.000Z")
+
+	job, err := ParseJobDataFromRecord(record)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if job.Status != JobStatusFailed || job.Attempts != 2 || job.MaxAttempts != 4 || job.LastError != "smtp unavailable" {
+		t.Errorf("lifecycle fields not parsed correctly: %+v", job)
+	}
+
+	if job.ReservedAt == nil || job.ReservedAt.Hour() != 10 {
+		t.Errorf("expected reserved_at to be parsed, got %v", job.ReservedAt)
+	}
+}
+
+func TestClaimJobAlreadyReserved(t *testing.T) {
+	record := newTestQueueRecord()
+	record.Id = "job123456789012"
+	record.Set("status", JobStatusProcessing)
+	record.Set("reserved_at", types.NowDateTime())
+	record.Set("lease_token", "other-worker-token")
+
+	// an active reservation is rejected before the database is touched
+	token, err := claimJob(nil, record)
+	if !errors.Is(err, ErrJobNotClaimed) {
+		t.Fatalf("expected ErrJobNotClaimed, got %v", err)
+	}
+	if token != "" {
+		t.Errorf("expected no lease token, got %q", token)
+	}
+	if record.GetString("lease_token") != "other-worker-token" {
+		t.Errorf("expected lease token of the other worker to be kept, got %q", record.GetString("lease_token"))
+	}
+}
+
+func TestGetShutdownGracePeriod(t *testing.T) {
+	os.Unsetenv("JOB_SHUTDOWN_GRACE_SECONDS")
+	if got := GetShutdownGracePeriod(); got != DefaultShutdownGraceSeconds*time.Second {
+		t.Errorf("expected default grace period, got %s", got)
+	}
+
+	os.Setenv("JOB_SHUTDOWN_GRACE_SECONDS", "0")
+	defer os.Unsetenv("JOB_SHUTDOWN_GRACE_SECONDS")
+	if got := GetShutdownGracePeriod(); got != 0 {
+		t.Errorf("expected a zero grace period to be allowed, got %s", got)
+	}
+}
+
+func TestGetHandlerGracePeriod(t *testing.T) {
+	t.Setenv("JOB_HANDLER_GRACE_SECONDS", "")
+	if got := GetHandlerGracePeriod(); got != DefaultHandlerGraceSeconds*time.Second {
+		t.Errorf("expected default grace period, got %s", got)
+	}
+
+	t.Setenv("JOB_HANDLER_GRACE_SECONDS", "-1")
+	if got := GetHandlerGracePeriod(); got != DefaultHandlerGraceSeconds*time.Second {
+		t.Errorf("expected the default for a negative grace period, got %s", got)
+	}
+
+	t.Setenv("JOB_HANDLER_GRACE_SECONDS", "0")
+	if got := GetHandlerGracePeriod(); got != 0 {
+		t.Errorf("expected a zero grace period to be allowed, got %s", got)
+	}
+}
+
+func TestReleaseActiveJobsWithoutActiveJobs(t *testing.T) {
+	// nothing is running, so the database is never touched
+	released, err := ReleaseActiveJobs(nil)
+	if err != nil || released != 0 {
+		t.Errorf("expected no released jobs, got %d (%v)", released, err)
+	}
+}
+
+type MockContextJobHandler struct {
+	MockJobHandler
+	timeout time.Duration
+}
+
+func (m *MockContextJobHandler) HandleContext(ctx context.Context, execCtx *cronutils.CronExecutionContext, job *JobData) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (m *MockContextJobHandler) DefaultTimeout() time.Duration {
+	return m.timeout
+}
+
+// MockSlowJobHandler ignores the job deadline and returns err after delay
+type MockSlowJobHandler struct {
+	MockJobHandler
+	delay time.Duration
+}
+
+func (m *MockSlowJobHandler) Handle(ctx *cronutils.CronExecutionContext, job *JobData) error {
+	time.Sleep(m.delay)
+	return m.err
+}
+
+type MockPanickingJobHandler struct {
+	MockJobHandler
+}
+
+func (m *MockPanickingJobHandler) Handle(ctx *cronutils.CronExecutionContext, job *JobData) error {
+	panic("boom")
+}
+
+func TestGetJobTimeout(t *testing.T) {
+	os.Unsetenv("JOB_TIMEOUT_SECONDS")
+
+	tests := []struct {
+		name     string
+		payload  map[string]any
+		handler  JobHandler
+		expected time.Duration
+	}{
+		{
+			name:     "payload timeout wins",
+			payload:  map[string]any{"options": map[string]any{"timeout": float64(5)}},
+			handler:  &MockContextJobHandler{timeout: time.Minute},
+			expected: 5 * time.Second,
+		},
+		{
+			name:     "handler default",
+			payload:  map[string]any{"options": map[string]any{"timeout": float64(0)}},
+			handler:  &MockContextJobHandler{timeout: time.Minute},
+			expected: time.Minute,
+		},
+		{
+			name:     "global default",
+			payload:  map[string]any{},
+			handler:  &MockJobHandler{},
+			expected: DefaultJobTimeoutSeconds * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetJobTimeout(&JobData{Payload: tt.payload}, tt.handler); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFailureReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "plain error", err: errors.New("smtp unavailable"), expected: JobFailureError},
+		{name: "timeout", err: fmt.Errorf("%w after 5s", ErrJobTimeout), expected: JobFailureTimeout},
+		{name: "panic", err: &panicError{value: "boom"}, expected: JobFailurePanic},
+		{name: "permanent", err: NewPermanentError(errors.New("invalid payload")), expected: JobFailurePermanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := failureReason(tt.err); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExecuteHandler(t *testing.T) {
+	app := pocketbase.New()
+	execCtx := cronutils.NewCronExecutionContext(app, "test-job")
+	job := &JobData{ID: "job123456789012", Type: "test_job"}
+
+	t.Run("returns handler error", func(t *testing.T) {
+		handlerErr := errors.New("handler failed")
+		err := executeHandler(context.Background(), execCtx, &MockJobHandler{err: handlerErr}, job)
+		if !errors.Is(err, handlerErr) {
+			t.Errorf("expected handler error, got %v", err)
+		}
+	})
+
+	t.Run("deadline interrupts context handler", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := executeHandler(ctx, execCtx, &MockContextJobHandler{}, job)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	})
+
+	t.Run("waits for a handler returning within the grace period", func(t *testing.T) {
+		t.Setenv("JOB_HANDLER_GRACE_SECONDS", "5")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		// finishes its work after the deadline, before the grace period ends
+		err := executeHandler(ctx, execCtx, &MockSlowJobHandler{delay: 50 * time.Millisecond}, job)
+		if err != nil {
+			t.Errorf("expected the result of the handler, got %v", err)
+		}
+	})
+
+	t.Run("reports a handler overrunning the grace period", func(t *testing.T) {
+		t.Setenv("JOB_HANDLER_GRACE_SECONDS", "0")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		handlerErr := errors.New("handler failed late")
+		err := executeHandler(ctx, execCtx, &MockSlowJobHandler{MockJobHandler: MockJobHandler{err: handlerErr}, delay: 100 * time.Millisecond}, job)
+
+		var overrun *handlerOverrunError
+		if !errors.As(err, &overrun) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected an overrun after the deadline, got %v", err)
+		}
+		if lateErr := <-overrun.done; !errors.Is(lateErr, handlerErr) {
+			t.Errorf("expected the handler error once it returned, got %v", lateErr)
+		}
+	})
+
+	t.Run("recovers panics", func(t *testing.T) {
+		err := executeHandler(context.Background(), execCtx, &MockPanickingJobHandler{}, job)
+		if failureReason(err) != JobFailurePanic {
+			t.Errorf("expected panic failure, got %v", err)
+		}
+	})
+}
@@ -6,6 +6,8 @@ import (
 )
 
 // ParseUserExportJobPayload helper function to parse user export job payload
+//
+// Deprecated: user exports are data processing jobs, use ParseDataProcessingJobPayload.
 func ParseUserExportJobPayload(job *JobData) (*UserExportJobPayload, error) {
 	if job == nil {
 		return nil, fmt.Errorf("job data cannot be nil")
@@ -1,11 +1,12 @@
 package jobutils
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"ims-pocketbase-baas-starter/pkg/common"
-	"ims-pocketbase-baas-starter/pkg/cronutils"
 	log "ims-pocketbase-baas-starter/pkg/logger"
+	"sync"
 	"time"
 
 	"github.com/pocketbase/pocketbase"
@@ -99,22 +100,38 @@ func ParseJobDataFromRecord(record *core.Record) (*JobData, error) {
 
 	// Parse reserved_at timestamp
 	var reservedAt *time.Time
-	if reservedAtStr := record.GetString("reserved_at"); reservedAtStr != "" {
-		if parsed, err := time.Parse(time.RFC3339, reservedAtStr); err == nil {
-			reservedAt = &parsed
-		}
+	if reservedAtDate := record.GetDateTime("reserved_at"); !reservedAtDate.IsZero() {
+		parsed := reservedAtDate.Time()
+		reservedAt = &parsed
+	}
+
+	// Parse available_at timestamp
+	var availableAt *time.Time
+	if availableAtDate := record.GetDateTime("available_at"); !availableAtDate.IsZero() {
+		parsed := availableAtDate.Time()
+		availableAt = &parsed
 	}
 
 	return &JobData{
-		ID:          record.Id,
-		Name:        record.GetString("name"),
-		Description: record.GetString("description"),
-		Type:        jobType,
-		Payload:     payload,
-		Attempts:    int(record.GetFloat("attempts")),
-		ReservedAt:  reservedAt,
-		CreatedAt:   record.GetDateTime("created").Time(),
-		UpdatedAt:   record.GetDateTime("updated").Time(),
+		ID:            record.Id,
+		Name:          record.GetString("name"),
+		Description:   record.GetString("description"),
+		OwnerID:       record.GetString("user_id"),
+		BatchID:       record.GetString("batch_id"),
+		ParentID:      record.GetString("parent_id"),
+		Queue:         record.GetString("queue"),
+		Priority:      record.GetInt("priority"),
+		Type:          jobType,
+		Payload:       payload,
+		Status:        record.GetString("status"),
+		Attempts:      record.GetInt("attempts"),
+		MaxAttempts:   GetMaxAttempts(record),
+		LastError:     record.GetString("last_error"),
+		FailureReason: record.GetString("failure_reason"),
+		ReservedAt:    reservedAt,
+		AvailableAt:   availableAt,
+		CreatedAt:     record.GetDateTime("created").Time(),
+		UpdatedAt:     record.GetDateTime("updated").Time(),
 	}, nil
 }
 
@@ -149,18 +166,40 @@ func ValidateJobPayload(payload map[string]any) error {
 	return nil
 }
 
-// NewJobProcessor creates a new job processor with initialized registry and worker pool
+// NewJobProcessor creates a new job processor with an initialized registry and a worker pool per queue
 func NewJobProcessor(app *pocketbase.PocketBase) *JobProcessor {
+	return NewJobProcessorWithQueues(app, GetQueueConfigs())
+}
+
+// NewJobProcessorWithQueues creates a job processor with a dedicated worker pool for every configured queue
+func NewJobProcessorWithQueues(app *pocketbase.PocketBase, queueConfigs QueueConfigs) *JobProcessor {
 	if app == nil {
 		panic("NewJobProcessor: app cannot be nil")
 	}
 
+	// the default queue always exists, it also runs jobs placed on unconfigured queues
+	configs := QueueConfigs{DefaultQueueName: DefaultQueueConfigs()[DefaultQueueName]}
+	for name, config := range queueConfigs {
+		configs[name] = config
+	}
+	queueConfigs = configs
+
 	registry := NewJobRegistry()
+	SetDefaultRegistry(registry)
+
+	queuePools := make(map[string]*WorkerPool, len(queueConfigs))
+	for _, name := range queueConfigs.Names() {
+		queuePools[name] = NewWorkerPool(app, registry, queueConfigs[name].Workers)
+		log.Info("Queue configured", "queue", name, "workers", queueConfigs[name].Workers, "poll_interval", queueConfigs[name].PollInterval.String())
+	}
 
 	return &JobProcessor{
-		app:        app,
-		registry:   registry,
-		workerPool: NewWorkerPool(app, registry, common.GetEnvInt("JOB_MAX_WORKERS", 5)),
+		app:          app,
+		registry:     registry,
+		workerPool:   queuePools[DefaultQueueName],
+		queuePools:   queuePools,
+		queueConfigs: queueConfigs,
+		lastPolled:   make(map[string]time.Time, len(queueConfigs)),
 	}
 }
 
@@ -176,96 +215,98 @@ func (p *JobProcessor) RegisterHandler(handler JobHandler) error {
 
 // ProcessJob processes a single job with complete lifecycle management
 func (p *JobProcessor) ProcessJob(record *core.Record) error {
-	if record == nil || record.Id == "" || record.Collection().Name != QueuesCollection {
-		return fmt.Errorf("invalid job record")
-	}
+	return runJob(context.Background(), p.app, p.registry, record)
+}
 
-	originalReservedAt := record.GetString("reserved_at")
-	if originalReservedAt != "" && p.isJobReserved(record) {
-		return fmt.Errorf("job %s is already reserved", record.Id)
+// ProcessJobsConcurrently processes multiple jobs concurrently using the default queue's worker pool
+func (p *JobProcessor) ProcessJobsConcurrently(records []*core.Record, maxWorkers int) []error {
+	if len(records) == 0 {
+		return nil
 	}
 
-	now := time.Now()
-	record.Set("reserved_at", now.Format(time.RFC3339))
-
-	if err := p.app.Save(record); err != nil {
-		record.Set("reserved_at", originalReservedAt)
-		return fmt.Errorf("failed to reserve job %s: %w", record.Id, err)
+	// Use the persistent worker pool for better performance
+	if p.workerPool != nil {
+		return p.workerPool.ProcessJobs(records)
 	}
 
-	jobData, err := ParseJobDataFromRecord(record)
-	if err != nil {
-		failErr := p.failJob(record, fmt.Errorf("failed to parse job data: %w", err))
-		if failErr != nil {
-			log.Error("Failed to mark job as failed", "job_id", record.Id, "error", failErr)
-		}
-		return err
-	}
+	// Fallback to sequential processing
+	return p.ProcessJobs(records)
+}
 
-	if err := ValidateJobPayload(jobData.Payload); err != nil {
-		failErr := p.failJob(record, fmt.Errorf("invalid job payload: %w", err))
-		if failErr != nil {
-			log.Error("Failed to mark job as failed", "job_id", record.Id, "error", failErr)
-		}
-		return err
-	}
+// GetQueueConfigs returns the processing settings of every configured queue
+func (p *JobProcessor) GetQueueConfigs() QueueConfigs {
+	return p.queueConfigs
+}
 
-	handler, err := p.registry.GetHandler(jobData.Type)
-	if err != nil {
-		failErr := p.failJob(record, fmt.Errorf("no handler found for job type '%s': %w", jobData.Type, err))
-		if failErr != nil {
-			log.Error("Failed to mark job as failed", "job_id", record.Id, "error", failErr)
+// DueQueues returns the queues whose poll interval has elapsed at the given time and marks them as polled
+func (p *JobProcessor) DueQueues(now time.Time) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	due := []string{}
+	for _, name := range p.queueConfigs.Names() {
+		lastPolled, polled := p.lastPolled[name]
+		// allow a little slack so a queue polled every minute is not skipped due to cron jitter
+		if polled && now.Sub(lastPolled) < p.queueConfigs[name].PollInterval-time.Second {
+			continue
 		}
-		return err
+		p.lastPolled[name] = now
+		due = append(due, name)
 	}
 
-	ctx := cronutils.NewCronExecutionContext(p.app, record.Id)
-	var jobErr error
-
-	func() {
-		defer func() {
-			if r := recover(); r != nil {
-				jobErr = fmt.Errorf("job handler panicked: %v", r)
-				ctx.LogError(jobErr, "Job handler panic recovered")
-			}
-		}()
-
-		ctx.LogStart(fmt.Sprintf("Processing %s job: %s", jobData.Type, jobData.Name))
-		jobErr = handler.Handle(ctx, jobData)
-	}()
+	return due
+}
 
-	if jobErr != nil {
-		ctx.LogError(jobErr, "Job processing failed")
-		failErr := p.failJob(record, jobErr)
-		if failErr != nil {
-			ctx.LogError(failErr, "Failed to mark job as failed")
-		}
-		return jobErr
+// ProcessQueue fetches up to the queue's batch size of ready jobs and runs them on the queue's
+// dedicated worker pool. It returns the number of fetched jobs and one result per job.
+func (p *JobProcessor) ProcessQueue(queue string) (int, []error, error) {
+	config, exists := p.queueConfigs[queue]
+	if !exists {
+		return 0, nil, fmt.Errorf("queue '%s' is not configured", queue)
 	}
 
-	ctx.LogEnd("Job processed successfully")
+	records, err := FindPendingQueueJobs(p.app, queue, p.queueConfigs.Names(), config.BatchSize)
+	if err != nil {
+		return 0, nil, err
+	}
 
-	if err := p.app.Delete(record); err != nil {
-		return fmt.Errorf("failed to delete completed job %s: %w", record.Id, err)
+	if len(records) == 0 {
+		return 0, nil, nil
 	}
 
-	log.Info("Job completed and removed from queue", "job_id", record.Id, "job_name", record.GetString("name"))
-	return nil
+	return len(records), p.queuePools[queue].ProcessJobs(records), nil
 }
 
-// ProcessJobsConcurrently processes multiple jobs concurrently using the persistent worker pool
-func (p *JobProcessor) ProcessJobsConcurrently(records []*core.Record, maxWorkers int) []error {
-	if len(records) == 0 {
-		return nil
+// Shutdown stops every queue's worker pool from accepting new jobs and waits for running jobs
+// until ctx is done. Jobs that did not finish in time get their reservation released so
+// another server instance can pick them up right away.
+func (p *JobProcessor) Shutdown(ctx context.Context) error {
+	var wg sync.WaitGroup
+	errs := make([]error, 0, len(p.queuePools))
+	var errsMu sync.Mutex
+
+	for name, pool := range p.queuePools {
+		wg.Add(1)
+		go func(name string, pool *WorkerPool) {
+			defer wg.Done()
+			if err := pool.Shutdown(ctx); err != nil {
+				errsMu.Lock()
+				errs = append(errs, fmt.Errorf("queue %s: %w", name, err))
+				errsMu.Unlock()
+			}
+		}(name, pool)
 	}
+	wg.Wait()
 
-	// Use the persistent worker pool for better performance
-	if p.workerPool != nil {
-		return p.workerPool.ProcessJobs(records)
+	released, err := ReleaseActiveJobs(p.app)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if released > 0 {
+		log.Warn("Released reservations of jobs interrupted by shutdown", "jobs", released)
 	}
 
-	// Fallback to sequential processing
-	return p.ProcessJobs(records)
+	return errors.Join(errs...)
 }
 
 // ProcessJobs processes multiple jobs sequentially
@@ -276,31 +317,3 @@ func (p *JobProcessor) ProcessJobs(records []*core.Record) []error {
 	}
 	return errors
 }
-
-func (p *JobProcessor) isJobReserved(record *core.Record) bool {
-	reservedAtStr := record.GetString("reserved_at")
-	if reservedAtStr == "" {
-		return false
-	}
-
-	reservedAt, err := time.Parse(time.RFC3339, reservedAtStr)
-	if err != nil {
-		return false
-	}
-
-	return time.Since(reservedAt) < 5*time.Minute
-}
-
-func (p *JobProcessor) failJob(record *core.Record, jobErr error) error {
-	currentAttempts := int(record.GetFloat("attempts"))
-	record.Set("attempts", currentAttempts+1)
-	record.Set("reserved_at", "")
-
-	if err := p.app.Save(record); err != nil {
-		log.Error("Failed to update failed job record", "job_id", record.Id, "error", err)
-		return fmt.Errorf("failed to update failed job %s: %w", record.Id, err)
-	}
-
-	log.Error("Job failed", "job_id", record.Id, "job_name", record.GetString("name"), "attempts", currentAttempts+1, "error", jobErr)
-	return jobErr
-}
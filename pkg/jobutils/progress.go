@@ -0,0 +1,154 @@
+package jobutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// maxProgressMessageLength keeps progress messages within the text field limit
+const maxProgressMessageLength = 500
+
+var (
+	// ErrJobNotRunning is returned when progress or a result is reported for a job
+	// this process is not running (anymore), e.g. after the job deadline passed
+	ErrJobNotRunning = errors.New("job is not running")
+
+	// runningJobs holds the progress reporters of the jobs this process is currently running (job id -> *jobReporter)
+	runningJobs sync.Map
+)
+
+// jobReporter stores the progress and result a handler reports for the job it runs
+type jobReporter struct {
+	app        core.App
+	record     *core.Record
+	leaseToken string
+	cancel     context.CancelCauseFunc // interrupts the job, may be nil
+	closed     bool
+	mu         sync.Mutex
+}
+
+// trackRunningJob registers the reporter handlers of a claimed job report to.
+// cancel is used to interrupt the job when it is canceled through CancelJob.
+func trackRunningJob(app core.App, record *core.Record, leaseToken string, cancel context.CancelCauseFunc) *jobReporter {
+	reporter := &jobReporter{app: app, record: record, leaseToken: leaseToken, cancel: cancel}
+	runningJobs.Store(record.Id, reporter)
+	return reporter
+}
+
+// close stops accepting reports, so the job record can be completed or failed safely.
+// Handlers that keep running past the job deadline get ErrJobNotRunning from then on.
+func (r *jobReporter) close() {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	runningJobs.CompareAndDelete(r.record.Id, r)
+}
+
+// ReportProgress stores how far a running job has got. percent is clamped to 0-100.
+// Progress is written right away, so the job status API shows it while the job runs.
+func ReportProgress(jobId string, percent int, message string) error {
+	reporter, err := getJobReporter(jobId)
+	if err != nil {
+		return err
+	}
+
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+
+	if reporter.closed {
+		return fmt.Errorf("%w: job %s", ErrJobNotRunning, jobId)
+	}
+
+	percent = clampProgress(percent)
+	if len(message) > maxProgressMessageLength {
+		message = message[:maxProgressMessageLength]
+	}
+
+	// only the progress columns are written, the reservation is managed by the worker
+	result, err := reporter.app.NonconcurrentDB().Update(
+		QueuesCollection,
+		dbx.Params{
+			"progress":         percent,
+			"progress_message": message,
+			"updated":          types.NowDateTime().String(),
+		},
+		dbx.NewExp("[[id]] = {:id} AND [[lease_token]] = {:token}", dbx.Params{"id": jobId, "token": reporter.leaseToken}),
+	).Execute()
+	if err != nil {
+		return fmt.Errorf("failed to report progress of job %s: %w", jobId, err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to report progress of job %s: %w", jobId, err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("%w: job %s", ErrLeaseLost, jobId)
+	}
+
+	// keep the in-memory record in sync so completing the job does not overwrite the progress
+	reporter.record.Set("progress", percent)
+	reporter.record.Set("progress_message", message)
+	PublishJobStatus(reporter.app, reporter.record)
+
+	return nil
+}
+
+// SetJobResult stores the typed result of a running job, e.g. a FileExportResult, EmailResult
+// or DataProcessingResult. The result is saved together with the job once it completes and
+// discarded when the attempt fails.
+func SetJobResult(jobId string, result any) error {
+	reporter, err := getJobReporter(jobId)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result of job %s: %w", jobId, err)
+	}
+
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+
+	if reporter.closed {
+		return fmt.Errorf("%w: job %s", ErrJobNotRunning, jobId)
+	}
+
+	reporter.record.Set("result", types.JSONRaw(data))
+	return nil
+}
+
+// interruptRunningJob cancels the context of a job this process is running and reports
+// whether the job was found
+func interruptRunningJob(jobId string, cause error) bool {
+	reporter, err := getJobReporter(jobId)
+	if err != nil || reporter.cancel == nil {
+		return false
+	}
+
+	reporter.cancel(cause)
+	return true
+}
+
+// getJobReporter returns the reporter of a job this process is running
+func getJobReporter(jobId string) (*jobReporter, error) {
+	value, exists := runningJobs.Load(jobId)
+	if !exists {
+		return nil, fmt.Errorf("%w: job %s", ErrJobNotRunning, jobId)
+	}
+	return value.(*jobReporter), nil
+}
+
+// clampProgress keeps a progress percentage within 0-100
+func clampProgress(percent int) int {
+	return max(0, min(percent, 100))
+}
@@ -0,0 +1,79 @@
+package jobutils
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+)
+
+func TestClampProgress(t *testing.T) {
+	tests := []struct {
+		percent  int
+		expected int
+	}{
+		{percent: -10, expected: 0},
+		{percent: 0, expected: 0},
+		{percent: 42, expected: 42},
+		{percent: 100, expected: 100},
+		{percent: 150, expected: 100},
+	}
+
+	for _, tt := range tests {
+		if got := clampProgress(tt.percent); got != tt.expected {
+			t.Errorf("clampProgress(%d) = %d, expected %d", tt.percent, got, tt.expected)
+		}
+	}
+}
+
+func TestReportProgressJobNotRunning(t *testing.T) {
+	if err := ReportProgress("unknown-job", 50, "halfway"); !errors.Is(err, ErrJobNotRunning) {
+		t.Errorf("expected ErrJobNotRunning, got %v", err)
+	}
+
+	if err := SetJobResult("unknown-job", &DataProcessingResult{}); !errors.Is(err, ErrJobNotRunning) {
+		t.Errorf("expected ErrJobNotRunning, got %v", err)
+	}
+}
+
+func TestSetJobResult(t *testing.T) {
+	record := newTestQueueRecord()
+	record.Id = "job-with-result"
+
+	reporter := trackRunningJob(pocketbase.New(), record, "token", nil)
+	defer reporter.close()
+
+	result := &DataProcessingResult{
+		BaseJobResultData: BaseJobResultData{Message: "done", Timestamp: time.Now()},
+		ProcessedRecords:  12,
+	}
+	if err := SetJobResult(record.Id, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var stored DataProcessingResult
+	if err := json.Unmarshal([]byte(record.GetString("result")), &stored); err != nil {
+		t.Fatalf("failed to decode stored result: %v", err)
+	}
+	if stored.ProcessedRecords != 12 || stored.Message != "done" {
+		t.Errorf("unexpected stored result %+v", stored)
+	}
+}
+
+func TestSetJobResultAfterClose(t *testing.T) {
+	record := newTestQueueRecord()
+	record.Id = "closed-job"
+
+	reporter := trackRunningJob(pocketbase.New(), record, "token", nil)
+	reporter.close()
+
+	if err := SetJobResult(record.Id, &EmailResult{}); !errors.Is(err, ErrJobNotRunning) {
+		t.Errorf("expected ErrJobNotRunning after close, got %v", err)
+	}
+
+	if _, exists := runningJobs.Load(record.Id); exists {
+		t.Error("expected closed job to be untracked")
+	}
+}
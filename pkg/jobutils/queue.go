@@ -0,0 +1,231 @@
+package jobutils
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/common"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// GetCompletedJobRetention returns how long completed jobs are kept before being pruned
+func GetCompletedJobRetention() time.Duration {
+	hours := common.GetEnvInt("JOB_COMPLETED_RETENTION_HOURS", DefaultCompletedJobRetentionHours)
+	if hours <= 0 {
+		hours = DefaultCompletedJobRetentionHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// pendingJobsFilter matches jobs that are ready to be processed: queued, failed (retryable)
+// or processing with an expired reservation, and whose available_at time has been reached
+const pendingJobsFilter = "status != {:completed} && status != {:dead} && status != {:canceled} && (reserved_at = '' || reserved_at < {:expired}) && (available_at = '' || available_at <= {:now})"
+
+// pendingJobsSort picks up higher priority jobs first, then the oldest ones
+const pendingJobsSort = "-priority,created"
+
+// FindPendingJobs returns ready jobs across all queues, highest priority then oldest first
+func FindPendingJobs(app core.App, limit int) ([]*core.Record, error) {
+	return findPendingJobs(app, "", dbx.Params{}, limit)
+}
+
+// FindPendingQueueJobs returns ready jobs of a named queue, highest priority then oldest first.
+// The default queue also picks up jobs placed on queues that have no config of their own
+// (configured lists every configured queue name), so such jobs are never stranded.
+func FindPendingQueueJobs(app core.App, queue string, configured []string, limit int) ([]*core.Record, error) {
+	if queue != DefaultQueueName {
+		return findPendingJobs(app, "queue = {:queue}", dbx.Params{"queue": queue}, limit)
+	}
+
+	conditions := []string{}
+	params := dbx.Params{}
+	for i, name := range configured {
+		if name == DefaultQueueName {
+			continue
+		}
+		key := fmt.Sprintf("queue%d", i)
+		conditions = append(conditions, fmt.Sprintf("queue != {:%s}", key))
+		params[key] = name
+	}
+
+	return findPendingJobs(app, strings.Join(conditions, " && "), params, limit)
+}
+
+// findPendingJobs returns ready jobs matching the extra filter
+func findPendingJobs(app core.App, extraFilter string, params dbx.Params, limit int) ([]*core.Record, error) {
+	now := types.NowDateTime()
+
+	filter := pendingJobsFilter
+	if extraFilter != "" {
+		filter += " && " + extraFilter
+	}
+
+	params["completed"] = JobStatusCompleted
+	params["dead"] = JobStatusDead
+	params["canceled"] = JobStatusCanceled
+	params["expired"] = now.Add(-GetReservationTimeout()).String()
+	params["now"] = now.String()
+
+	records, err := app.FindRecordsByFilter(QueuesCollection, filter, pendingJobsSort, limit, 0, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending jobs: %w", err)
+	}
+
+	return records, nil
+}
+
+// ScheduleJobAt sets the earliest time a (not yet saved) job record may run
+func ScheduleJobAt(record *core.Record, runAt time.Time) {
+	if runAt.IsZero() {
+		record.Set("available_at", "")
+		return
+	}
+
+	availableAt, _ := types.ParseDateTime(runAt)
+	record.Set("available_at", availableAt)
+}
+
+// ScheduleJobIn delays a (not yet saved) job record by the given duration from now
+func ScheduleJobIn(record *core.Record, delay time.Duration) {
+	if delay <= 0 {
+		record.Set("available_at", "")
+		return
+	}
+
+	record.Set("available_at", types.NowDateTime().Add(delay))
+}
+
+// FindJobsByStatus returns jobs in the given lifecycle status, most recently updated first
+func FindJobsByStatus(app core.App, status string, limit, offset int) ([]*core.Record, error) {
+	records, err := app.FindRecordsByFilter(
+		QueuesCollection,
+		"status = {:status}",
+		"-updated",
+		limit,
+		offset,
+		dbx.Params{"status": status},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s jobs: %w", status, err)
+	}
+
+	return records, nil
+}
+
+// FindDeadJobs returns jobs in the dead-letter set
+func FindDeadJobs(app core.App, limit, offset int) ([]*core.Record, error) {
+	return FindJobsByStatus(app, JobStatusDead, limit, offset)
+}
+
+// RequeueJob moves a failed, dead or canceled job back to the queue with a fresh attempt budget.
+// The last error is kept so operators can still see why the job failed before.
+// A job whose idempotency key is held by another active job (e.g. a duplicate queued after the job
+// died) is not requeued: ErrDuplicateJob names the job holding the key.
+func RequeueJob(app core.App, jobId string) (*core.Record, error) {
+	record, err := app.FindRecordById(QueuesCollection, jobId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, jobId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch job %s: %w", jobId, err)
+	}
+
+	status := record.GetString("status")
+	if status != JobStatusFailed && status != JobStatusDead && status != JobStatusCanceled {
+		return nil, fmt.Errorf("%w: job %s cannot be requeued from status '%s'", ErrJobNotRetryable, jobId, status)
+	}
+
+	key := record.GetString("idempotency_key")
+	if holder := findKeyHolder(app, key, jobId); holder != nil {
+		return nil, fmt.Errorf("%w: job %s holds key %q of job %s", ErrDuplicateJob, holder.Id, key, jobId)
+	}
+
+	record.Set("status", JobStatusQueued)
+	record.Set("attempts", 0)
+	record.Set("reserved_at", "")
+	record.Set("lease_token", "")
+	record.Set("available_at", "")
+
+	if err := app.Save(record); err != nil {
+		// another enqueuer may have taken the key in the meantime
+		if holder := findKeyHolder(app, key, jobId); holder != nil {
+			return nil, fmt.Errorf("%w: job %s holds key %q of job %s", ErrDuplicateJob, holder.Id, key, jobId)
+		}
+		return nil, fmt.Errorf("failed to requeue job %s: %w", jobId, err)
+	}
+
+	log.Info("Job requeued", "job_id", jobId, "previous_status", status)
+	return record, nil
+}
+
+// findKeyHolder returns the queued, processing or failed job other than jobId that holds an
+// idempotency key, or nil when the key is empty or free
+func findKeyHolder(app core.App, key, jobId string) *core.Record {
+	if key == "" {
+		return nil
+	}
+
+	holder := findDuplicateJob(app, key, 0)
+	if holder == nil || holder.Id == jobId {
+		return nil
+	}
+	return holder
+}
+
+// RequeueDeadJobs requeues every job in the dead-letter set and returns how many were requeued.
+// Jobs whose idempotency key is held by another active job are left dead.
+func RequeueDeadJobs(app core.App) (int, error) {
+	records, err := FindDeadJobs(app, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+	for _, record := range records {
+		_, err := RequeueJob(app, record.Id)
+		if errors.Is(err, ErrDuplicateJob) {
+			log.Info("Dead job not requeued, its key is held by another job", "job_id", record.Id, "error", err)
+			continue
+		}
+		if err != nil {
+			return requeued, err
+		}
+		requeued++
+	}
+
+	return requeued, nil
+}
+
+// PruneCompletedJobs deletes up to batchSize completed jobs that finished before the retention window
+func PruneCompletedJobs(app core.App, retention time.Duration, batchSize int) (int, error) {
+	cutoff := types.NowDateTime().Add(-retention)
+
+	records, err := app.FindRecordsByFilter(
+		QueuesCollection,
+		"status = {:status} && completed_at < {:cutoff}",
+		"completed_at",
+		batchSize,
+		0,
+		dbx.Params{"status": JobStatusCompleted, "cutoff": cutoff.String()},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch completed jobs: %w", err)
+	}
+
+	deleted := 0
+	for _, record := range records {
+		if err := app.Delete(record); err != nil {
+			return deleted, fmt.Errorf("failed to delete completed job %s: %w", record.Id, err)
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,136 @@
+package jobutils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/common"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+)
+
+// queueNamePattern matches the pattern enforced on the queues.queue field
+var queueNamePattern = regexp.MustCompile(`^[a-z0-9_\-]+$`)
+
+// QueueConfig holds the processing settings of a named queue
+type QueueConfig struct {
+	Workers      int           // Workers in the queue's dedicated worker pool
+	PollInterval time.Duration // Minimum time between two polls of the queue
+	BatchSize    int           // Maximum jobs fetched per poll
+}
+
+// QueueConfigs maps queue names to their processing settings
+type QueueConfigs map[string]QueueConfig
+
+// DefaultQueueConfigs returns the built-in queues. Emails and exports get their own worker pools
+// so a flood of bulk exports cannot starve transactional emails.
+func DefaultQueueConfigs() QueueConfigs {
+	pollInterval := time.Duration(DefaultQueuePollIntervalSeconds) * time.Second
+
+	return QueueConfigs{
+		DefaultQueueName: {
+			Workers:      common.GetEnvInt("JOB_MAX_WORKERS", DefaultQueueWorkers),
+			PollInterval: pollInterval,
+			BatchSize:    common.GetEnvInt("JOB_BATCH_SIZE", DefaultQueueBatchSize),
+		},
+		QueueEmails: {
+			Workers:      2,
+			PollInterval: pollInterval,
+			BatchSize:    DefaultQueueBatchSize,
+		},
+		QueueExports: {
+			Workers:      1,
+			PollInterval: pollInterval,
+			BatchSize:    10,
+		},
+	}
+}
+
+// GetQueueConfigs returns the built-in queues merged with the queues defined in JOB_QUEUES.
+// An invalid JOB_QUEUES value is ignored in favour of the built-in queues.
+func GetQueueConfigs() QueueConfigs {
+	configs, err := ParseQueueConfigs(common.GetEnv("JOB_QUEUES", ""), DefaultQueueConfigs())
+	if err != nil {
+		log.Warn("Invalid JOB_QUEUES value, using built-in queues", "error", err)
+		return DefaultQueueConfigs()
+	}
+	return configs
+}
+
+// ParseQueueConfigs parses a queue spec of the form "name:workers[:poll_interval[:batch_size]]",
+// comma separated (e.g. "emails:4:10s,exports:1:5m:5"), on top of the given base configs.
+// Omitted values keep the base value of the queue, or fall back to the queue defaults.
+func ParseQueueConfigs(spec string, base QueueConfigs) (QueueConfigs, error) {
+	configs := make(QueueConfigs, len(base))
+	for name, config := range base {
+		configs[name] = config
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 4 {
+			return nil, fmt.Errorf("invalid queue config %q: expected name:workers[:poll_interval[:batch_size]]", entry)
+		}
+
+		name := strings.TrimSpace(parts[0])
+		if !queueNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid queue name %q", name)
+		}
+
+		config, exists := configs[name]
+		if !exists {
+			config = QueueConfig{
+				PollInterval: time.Duration(DefaultQueuePollIntervalSeconds) * time.Second,
+				BatchSize:    DefaultQueueBatchSize,
+			}
+		}
+
+		workers, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || workers <= 0 {
+			return nil, fmt.Errorf("invalid worker count for queue %q: %s", name, parts[1])
+		}
+		config.Workers = workers
+
+		if len(parts) > 2 {
+			pollInterval, err := time.ParseDuration(strings.TrimSpace(parts[2]))
+			if err != nil || pollInterval <= 0 {
+				return nil, fmt.Errorf("invalid poll interval for queue %q: %s", name, parts[2])
+			}
+			config.PollInterval = pollInterval
+		}
+
+		if len(parts) > 3 {
+			batchSize, err := strconv.Atoi(strings.TrimSpace(parts[3]))
+			if err != nil || batchSize <= 0 {
+				return nil, fmt.Errorf("invalid batch size for queue %q: %s", name, parts[3])
+			}
+			config.BatchSize = batchSize
+		}
+
+		configs[name] = config
+	}
+
+	if _, exists := configs[DefaultQueueName]; !exists {
+		return nil, fmt.Errorf("queue config must include the %q queue", DefaultQueueName)
+	}
+
+	return configs, nil
+}
+
+// Names returns the configured queue names in alphabetical order
+func (c QueueConfigs) Names() []string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
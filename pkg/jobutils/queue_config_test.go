@@ -0,0 +1,124 @@
+package jobutils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+)
+
+func TestParseQueueConfigs(t *testing.T) {
+	base := QueueConfigs{
+		DefaultQueueName: {Workers: 5, PollInterval: time.Minute, BatchSize: 50},
+		QueueEmails:      {Workers: 2, PollInterval: time.Minute, BatchSize: 50},
+	}
+
+	tests := []struct {
+		name        string
+		spec        string
+		expected    QueueConfigs
+		expectError bool
+	}{
+		{name: "empty spec keeps base", spec: "", expected: base},
+		{
+			name: "override workers only",
+			spec: "emails:4",
+			expected: QueueConfigs{
+				DefaultQueueName: {Workers: 5, PollInterval: time.Minute, BatchSize: 50},
+				QueueEmails:      {Workers: 4, PollInterval: time.Minute, BatchSize: 50},
+			},
+		},
+		{
+			name: "add queue with all settings",
+			spec: "emails:3:10s, reports:1:5m:5",
+			expected: QueueConfigs{
+				DefaultQueueName: {Workers: 5, PollInterval: time.Minute, BatchSize: 50},
+				QueueEmails:      {Workers: 3, PollInterval: 10 * time.Second, BatchSize: 50},
+				"reports":        {Workers: 1, PollInterval: 5 * time.Minute, BatchSize: 5},
+			},
+		},
+		{name: "missing workers", spec: "emails", expectError: true},
+		{name: "invalid workers", spec: "emails:0", expectError: true},
+		{name: "invalid poll interval", spec: "emails:2:soon", expectError: true},
+		{name: "invalid batch size", spec: "emails:2:10s:-1", expectError: true},
+		{name: "invalid queue name", spec: "Emails!:2", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configs, err := ParseQueueConfigs(tt.spec, base)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error for spec %q", tt.spec)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(configs, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, configs)
+			}
+		})
+	}
+
+	if base[QueueEmails].Workers != 2 {
+		t.Error("ParseQueueConfigs must not modify the base configs")
+	}
+}
+
+func TestParseQueueConfigsRequiresDefaultQueue(t *testing.T) {
+	if _, err := ParseQueueConfigs("emails:2", QueueConfigs{}); err == nil {
+		t.Error("expected error when the default queue is missing")
+	}
+}
+
+func TestQueueConfigsNames(t *testing.T) {
+	configs := QueueConfigs{QueueExports: {}, DefaultQueueName: {}, QueueEmails: {}}
+
+	expected := []string{DefaultQueueName, QueueEmails, QueueExports}
+	if names := configs.Names(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestJobProcessor_DueQueues(t *testing.T) {
+	app := pocketbase.New()
+	processor := NewJobProcessorWithQueues(app, QueueConfigs{
+		QueueEmails:  {Workers: 1, PollInterval: time.Minute, BatchSize: 10},
+		QueueExports: {Workers: 1, PollInterval: 5 * time.Minute, BatchSize: 10},
+	})
+
+	if _, exists := processor.GetQueueConfigs()[DefaultQueueName]; !exists {
+		t.Fatal("expected the default queue to be configured")
+	}
+
+	now := time.Now()
+
+	due := processor.DueQueues(now)
+	if !reflect.DeepEqual(due, []string{DefaultQueueName, QueueEmails, QueueExports}) {
+		t.Errorf("expected every queue to be due on the first poll, got %v", due)
+	}
+
+	due = processor.DueQueues(now.Add(time.Minute))
+	if !reflect.DeepEqual(due, []string{DefaultQueueName, QueueEmails}) {
+		t.Errorf("expected only one minute queues to be due, got %v", due)
+	}
+
+	due = processor.DueQueues(now.Add(5 * time.Minute))
+	if !reflect.DeepEqual(due, []string{DefaultQueueName, QueueEmails, QueueExports}) {
+		t.Errorf("expected every queue to be due after five minutes, got %v", due)
+	}
+}
+
+func TestJobProcessor_ProcessQueueUnknown(t *testing.T) {
+	processor := NewJobProcessorWithQueues(pocketbase.New(), QueueConfigs{})
+
+	if _, _, err := processor.ProcessQueue("unknown"); err == nil {
+		t.Error("expected error for an unconfigured queue")
+	}
+}
@@ -0,0 +1,73 @@
+package jobutils
+
+import (
+	"encoding/json"
+	"strings"
+
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/routine"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+)
+
+const (
+	// JobsRealtimeTopic is the realtime topic delivering status updates of every job of the
+	// authenticated user. Subscribe to "jobs/{id}" to follow a single job.
+	JobsRealtimeTopic = "jobs"
+
+	// realtimeClientsChunkSize is how many realtime clients are checked per chunk
+	realtimeClientsChunkSize = 300
+)
+
+// IsJobsRealtimeTopic reports whether a realtime subscription is a job status topic
+func IsJobsRealtimeTopic(subscription string) bool {
+	topic, _, _ := strings.Cut(subscription, "?")
+	return topic == JobsRealtimeTopic || strings.HasPrefix(topic, JobsRealtimeTopic+"/")
+}
+
+// PublishJobStatus pushes the current status of a job to the realtime clients of its owner
+// subscribed to the "jobs" or "jobs/{id}" topic. Jobs without an owner are not published, and
+// clients authenticated as any other user never receive the update.
+func PublishJobStatus(app core.App, record *core.Record) {
+	ownerId := record.GetString("user_id")
+	if ownerId == "" {
+		return
+	}
+
+	chunks := app.SubscriptionsBroker().ChunkedClients(realtimeClientsChunkSize)
+	if len(chunks) == 0 {
+		return // no subscribers
+	}
+
+	data, err := json.Marshal(JobStatusFromRecord(record))
+	if err != nil {
+		log.Error("Failed to encode job status for realtime clients", "job_id", record.Id, "error", err)
+		return
+	}
+
+	// "?" matches the topic with or without subscription options
+	prefixes := []string{JobsRealtimeTopic + "?", JobsRealtimeTopic + "/" + record.Id + "?"}
+
+	for _, chunk := range chunks {
+		for _, client := range chunk {
+			if !isJobOwnerClient(client, ownerId) {
+				continue
+			}
+
+			for subscription := range client.Subscriptions(prefixes...) {
+				message := subscriptions.Message{Name: subscription, Data: data}
+				routine.FireAndForget(func() {
+					client.Send(message)
+				})
+			}
+		}
+	}
+}
+
+// isJobOwnerClient reports whether a realtime client is authenticated as the owner of a job
+func isJobOwnerClient(client subscriptions.Client, ownerId string) bool {
+	auth, _ := client.Get(apis.RealtimeClientAuthKey).(*core.Record)
+	return auth != nil && auth.Id == ownerId && auth.Collection().Name == "users"
+}
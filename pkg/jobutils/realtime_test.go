@@ -0,0 +1,81 @@
+package jobutils
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+)
+
+func TestIsJobsRealtimeTopic(t *testing.T) {
+	tests := []struct {
+		subscription string
+		expected     bool
+	}{
+		{subscription: "jobs", expected: true},
+		{subscription: "jobs/abc123", expected: true},
+		{subscription: "jobs?options=%7B%7D", expected: true},
+		{subscription: "jobs/abc123?options=%7B%7D", expected: true},
+		{subscription: "jobsfeed", expected: false},
+		{subscription: "queues/*", expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsJobsRealtimeTopic(tt.subscription); got != tt.expected {
+			t.Errorf("IsJobsRealtimeTopic(%q) = %v, expected %v", tt.subscription, got, tt.expected)
+		}
+	}
+}
+
+// newTestRealtimeClient registers a realtime client authenticated as the given user
+func newTestRealtimeClient(app *pocketbase.PocketBase, userId string, topics ...string) *subscriptions.DefaultClient {
+	user := core.NewRecord(core.NewAuthCollection("users"))
+	user.Id = userId
+
+	client := subscriptions.NewDefaultClient()
+	client.Set(apis.RealtimeClientAuthKey, user)
+	client.Subscribe(topics...)
+	app.SubscriptionsBroker().Register(client)
+	return client
+}
+
+func TestPublishJobStatusScopedToOwner(t *testing.T) {
+	app := pocketbase.New()
+
+	record := newTestQueueRecord()
+	record.Id = "job-owned"
+	record.Set("user_id", "owner")
+	record.Set("status", JobStatusProcessing)
+	record.Set("progress", 40)
+
+	owner := newTestRealtimeClient(app, "owner", JobsRealtimeTopic)
+	ownerJob := newTestRealtimeClient(app, "owner", JobsRealtimeTopic+"/job-owned")
+	stranger := newTestRealtimeClient(app, "stranger", JobsRealtimeTopic, JobsRealtimeTopic+"/job-owned")
+
+	PublishJobStatus(app, record)
+
+	for _, client := range []*subscriptions.DefaultClient{owner, ownerJob} {
+		select {
+		case message := <-client.Channel():
+			var status JobStatus
+			if err := json.Unmarshal(message.Data, &status); err != nil {
+				t.Fatalf("failed to decode job status message: %v", err)
+			}
+			if status.JobID != "job-owned" || status.Progress.Percent != 40 {
+				t.Errorf("unexpected job status message %+v", status)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("expected owner client subscribed to %v to receive the job status", client.Subscriptions())
+		}
+	}
+
+	select {
+	case message := <-stranger.Channel():
+		t.Errorf("other users must not receive the job status, got %s", message.Name)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
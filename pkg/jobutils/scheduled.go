@@ -0,0 +1,378 @@
+package jobutils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"ims-pocketbase-baas-starter/pkg/cronutils"
+	log "ims-pocketbase-baas-starter/pkg/logger"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ScheduledJobsCollection is the collection recurring jobs are defined in
+const ScheduledJobsCollection = "scheduled_jobs"
+
+const (
+	// scheduleRefreshInterval is how often the scheduler reloads the schedules on its own, so
+	// changes saved through another instance are picked up without a record hook firing here
+	scheduleRefreshInterval = 5 * time.Minute
+
+	// scheduledRunUniqueWindow keeps instances that run the scheduler at the same time from
+	// queuing the same run twice
+	scheduledRunUniqueWindow = time.Hour
+)
+
+var (
+	// ErrInvalidCronExpr is returned for a schedule with an invalid cron expression
+	ErrInvalidCronExpr = errors.New("invalid cron expression")
+
+	// ErrInvalidTimezone is returned for a schedule with an unknown timezone
+	ErrInvalidTimezone = errors.New("invalid timezone")
+
+	// ErrNoNextRun is returned when a schedule has no run within the next years
+	ErrNoNextRun = cronutils.ErrNoNextRun
+)
+
+// ScheduledJob is a recurring job defined in the scheduled_jobs collection
+type ScheduledJob struct {
+	ID          string         // Schedule ID in the scheduled_jobs collection
+	Name        string         // Schedule name, also used as the name of the queued jobs
+	Description string         // Description of the queued jobs
+	CronExpr    string         // 5-field cron expression (e.g. "0 2 * * MON-FRI" or "@daily"), see cronutils.Schedule
+	Timezone    string         // IANA timezone the expression is evaluated in (defaults to UTC, a TZ= prefix wins)
+	JobType     string         // Type of the queued jobs
+	Payload     map[string]any // Payload template (data and options), string values may use {{.Date}} etc.
+	Queue       string         // Queue the jobs are placed on (defaults to DefaultQueueName)
+	Enabled     bool           // Disabled schedules are kept but not run
+
+	schedule *cronutils.Schedule
+}
+
+// ScheduledPayloadData is what the string values of a payload template are rendered with
+type ScheduledPayloadData struct {
+	ScheduleID   string    // ID of the schedule
+	ScheduleName string    // Name of the schedule
+	ScheduledAt  time.Time // Time the run was due, in the schedule timezone
+	Date         string    // Day the run was due (YYYY-MM-DD), in the schedule timezone
+}
+
+// ParseScheduledJob reads and validates a scheduled_jobs record
+func ParseScheduledJob(record *core.Record) (*ScheduledJob, error) {
+	job := &ScheduledJob{
+		ID:          record.Id,
+		Name:        record.GetString("name"),
+		Description: record.GetString("description"),
+		CronExpr:    strings.TrimSpace(record.GetString("cron_expr")),
+		Timezone:    record.GetString("timezone"),
+		JobType:     record.GetString("job_type"),
+		Queue:       record.GetString("queue"),
+		Enabled:     record.GetBool("enabled"),
+		Payload:     map[string]any{},
+	}
+
+	if job.JobType == "" {
+		return nil, fmt.Errorf("job type is required")
+	}
+
+	if raw := record.GetString("payload"); raw != "" && raw != "null" {
+		if err := record.UnmarshalJSONField("payload", &job.Payload); err != nil {
+			return nil, fmt.Errorf("payload template must be a JSON object: %w", err)
+		}
+	}
+
+	if err := job.parseSchedule(); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// parseSchedule validates the cron expression and timezone of the schedule
+func (j *ScheduledJob) parseSchedule() error {
+	location := time.UTC
+	if j.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(j.Timezone); err != nil {
+			return fmt.Errorf("%w %q: %v", ErrInvalidTimezone, j.Timezone, err)
+		}
+	}
+
+	schedule, err := cronutils.ParseSchedule(j.CronExpr, location)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCronExpr, err)
+	}
+
+	// the scheduler runs once a minute
+	if schedule.HasSeconds() {
+		return fmt.Errorf("%w: %v", ErrInvalidCronExpr, cronutils.ErrSecondsNotSupported)
+	}
+
+	j.schedule = schedule
+	return nil
+}
+
+// IsDue reports whether the schedule runs in the minute of t
+func (j *ScheduledJob) IsDue(t time.Time) bool {
+	return j.schedule.IsDue(t)
+}
+
+// NextRun returns the first minute after t the schedule runs in
+func (j *ScheduledJob) NextRun(after time.Time) (time.Time, error) {
+	return j.schedule.Next(after)
+}
+
+// BuildPayload renders the payload template for the run due at scheduledAt
+func (j *ScheduledJob) BuildPayload(scheduledAt time.Time) (map[string]any, error) {
+	local := scheduledAt.In(j.schedule.Location)
+	data := ScheduledPayloadData{
+		ScheduleID:   j.ID,
+		ScheduleName: j.Name,
+		ScheduledAt:  local,
+		Date:         local.Format(time.DateOnly),
+	}
+
+	rendered, err := renderPayloadTemplate(j.Payload, data)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, ok := rendered.(map[string]any)
+	if !ok {
+		payload = map[string]any{}
+	}
+	payload["type"] = j.JobType
+
+	return payload, nil
+}
+
+// renderPayloadTemplate renders the template placeholders of every string in a payload value
+func renderPayloadTemplate(value any, data ScheduledPayloadData) (any, error) {
+	switch v := value.(type) {
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+
+		tmpl, err := template.New("payload").Option("missingkey=error").Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload template %q: %w", v, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render payload template %q: %w", v, err)
+		}
+		return buf.String(), nil
+	case map[string]any:
+		rendered := make(map[string]any, len(v))
+		for key, item := range v {
+			r, err := renderPayloadTemplate(item, data)
+			if err != nil {
+				return nil, err
+			}
+			rendered[key] = r
+		}
+		return rendered, nil
+	case []any:
+		rendered := make([]any, len(v))
+		for i, item := range v {
+			r, err := renderPayloadTemplate(item, data)
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = r
+		}
+		return rendered, nil
+	default:
+		return v, nil
+	}
+}
+
+// ValidateScheduledJob checks that a schedule can be run: its expression, timezone and a payload
+// rendered for the next run accepted by the handler of its job type. It returns the next run
+// (zero for disabled schedules).
+func ValidateScheduledJob(job *ScheduledJob, now time.Time) (time.Time, error) {
+	next, err := job.NextRun(now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidCronExpr, err)
+	}
+
+	payload, err := job.BuildPayload(next)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err := validateEnqueuePayload(payload, &EnqueueOptions{Name: job.Name}); err != nil {
+		return time.Time{}, err
+	}
+
+	if !job.Enabled {
+		return time.Time{}, nil
+	}
+	return next, nil
+}
+
+// Scheduler queues the jobs of the scheduled_jobs collection when they are due
+type Scheduler struct {
+	app      core.App
+	jobs     []*ScheduledJob
+	loadedAt time.Time
+	mu       sync.Mutex
+}
+
+// NewScheduler creates a scheduler for the scheduled_jobs collection of app
+func NewScheduler(app core.App) *Scheduler {
+	return &Scheduler{app: app}
+}
+
+// Reload reads the enabled schedules again, e.g. after a scheduled_jobs record changed.
+// Invalid schedules are logged and skipped.
+func (s *Scheduler) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.reload()
+}
+
+// reload reads the enabled schedules, s.mu must be held
+func (s *Scheduler) reload() error {
+	records, err := s.app.FindAllRecords(ScheduledJobsCollection, dbx.HashExp{"enabled": true})
+	if err != nil {
+		return fmt.Errorf("failed to load scheduled jobs: %w", err)
+	}
+
+	jobs := make([]*ScheduledJob, 0, len(records))
+	for _, record := range records {
+		job, err := ParseScheduledJob(record)
+		if err != nil {
+			log.Error("Skipping invalid scheduled job", "schedule_id", record.Id, "name", record.GetString("name"), "error", err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	s.jobs = jobs
+	s.loadedAt = time.Now()
+
+	log.Debug("Scheduled jobs loaded", "total", len(jobs))
+	return nil
+}
+
+// Jobs returns the enabled schedules the scheduler runs
+func (s *Scheduler) Jobs() []*ScheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*ScheduledJob(nil), s.jobs...)
+}
+
+// RunDue queues a job for every schedule due in the minute of now and returns how many were queued
+func (s *Scheduler) RunDue(now time.Time) (int, error) {
+	s.mu.Lock()
+	if s.loadedAt.IsZero() || time.Since(s.loadedAt) >= scheduleRefreshInterval {
+		if err := s.reload(); err != nil {
+			s.mu.Unlock()
+			return 0, err
+		}
+	}
+	jobs := append([]*ScheduledJob(nil), s.jobs...)
+	s.mu.Unlock()
+
+	scheduledAt := now.Truncate(time.Minute)
+
+	queued := 0
+	var errs []error
+	for _, job := range jobs {
+		if !job.IsDue(scheduledAt) {
+			continue
+		}
+
+		if err := s.run(job, scheduledAt); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		queued++
+	}
+
+	return queued, errors.Join(errs...)
+}
+
+// run queues the job of a schedule for the run due at scheduledAt and records the run
+func (s *Scheduler) run(job *ScheduledJob, scheduledAt time.Time) error {
+	handle, runErr := s.enqueue(job, scheduledAt)
+
+	next, err := job.NextRun(scheduledAt)
+	if err != nil {
+		log.Warn("Scheduled job has no next run", "schedule_id", job.ID, "name", job.Name, "error", err)
+	}
+
+	lastJobId, lastError := "", ""
+	if handle != nil {
+		lastJobId = handle.ID
+	}
+	if runErr != nil {
+		lastError = truncateError(runErr)
+	}
+
+	// written straight to the database so the record hooks do not reload the scheduler every run
+	_, err = s.app.NonconcurrentDB().Update(
+		ScheduledJobsCollection,
+		dbx.Params{
+			"last_run_at": dateTimeString(scheduledAt),
+			"next_run_at": dateTimeString(next),
+			"last_job_id": lastJobId,
+			"last_error":  lastError,
+			"updated":     types.NowDateTime().String(),
+		},
+		dbx.HashExp{"id": job.ID},
+	).Execute()
+	if err != nil {
+		log.Error("Failed to record scheduled job run", "schedule_id", job.ID, "name", job.Name, "error", err)
+	}
+
+	if runErr != nil {
+		log.Error("Failed to queue scheduled job", "schedule_id", job.ID, "name", job.Name, "error", runErr)
+		return fmt.Errorf("scheduled job %s: %w", job.Name, runErr)
+	}
+
+	log.Info("Scheduled job queued", "schedule_id", job.ID, "name", job.Name, "job_id", lastJobId, "duplicate", handle.Existing, "next_run_at", next)
+	return nil
+}
+
+// enqueue queues the job of a schedule. Each run has its own uniqueness key, so instances that
+// run the scheduler at the same time queue it once.
+func (s *Scheduler) enqueue(job *ScheduledJob, scheduledAt time.Time) (*JobHandle, error) {
+	payload, err := job.BuildPayload(scheduledAt)
+	if err != nil {
+		return nil, err
+	}
+
+	description := job.Description
+	if description == "" {
+		description = fmt.Sprintf("Scheduled run of %s (%s)", job.Name, job.CronExpr)
+	}
+
+	return Enqueue(s.app, payload,
+		WithName(job.Name),
+		WithDescription(description),
+		WithQueue(job.Queue),
+		WithUniqueKey(fmt.Sprintf("scheduled:%s:%d", job.ID, scheduledAt.Unix()), scheduledRunUniqueWindow),
+	)
+}
+
+// dateTimeString formats a time for a date column (empty for the zero time)
+func dateTimeString(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	value, _ := types.ParseDateTime(t)
+	return value.String()
+}
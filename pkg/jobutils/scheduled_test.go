@@ -0,0 +1,193 @@
+package jobutils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func newTestScheduledJobRecord(cronExpr string, timezone string) *core.Record {
+	collection := core.NewBaseCollection(ScheduledJobsCollection)
+	collection.Fields.Add(
+		&core.TextField{Name: "name"},
+		&core.TextField{Name: "description"},
+		&core.TextField{Name: "cron_expr"},
+		&core.TextField{Name: "timezone"},
+		&core.TextField{Name: "job_type"},
+		&core.JSONField{Name: "payload"},
+		&core.TextField{Name: "queue"},
+		&core.BoolField{Name: "enabled"},
+	)
+
+	record := core.NewRecord(collection)
+	record.Id = "schedule-1"
+	record.Set("name", "Nightly report")
+	record.Set("cron_expr", cronExpr)
+	record.Set("timezone", timezone)
+	record.Set("job_type", JobTypeDataProcessing)
+	record.Set("enabled", true)
+	return record
+}
+
+func TestParseScheduledJob(t *testing.T) {
+	tests := []struct {
+		name        string
+		cronExpr    string
+		timezone    string
+		jobType     string
+		expectedErr error
+	}{
+		{name: "valid in UTC", cronExpr: "0 2 * * *", jobType: JobTypeDataProcessing},
+		{name: "valid with timezone", cronExpr: "*/15 9-17 * * 1-5", timezone: "Europe/Paris", jobType: JobTypeDataProcessing},
+		{name: "invalid expression", cronExpr: "0 25 * * *", jobType: JobTypeDataProcessing, expectedErr: ErrInvalidCronExpr},
+		{name: "six fields are not supported by the scheduler", cronExpr: "0 0 2 * * *", jobType: JobTypeDataProcessing, expectedErr: ErrInvalidCronExpr},
+		{name: "unknown timezone", cronExpr: "0 2 * * *", timezone: "Mars/Olympus", jobType: JobTypeDataProcessing, expectedErr: ErrInvalidTimezone},
+		{name: "missing job type", cronExpr: "0 2 * * *"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := newTestScheduledJobRecord(tt.cronExpr, tt.timezone)
+			record.Set("job_type", tt.jobType)
+
+			job, err := ParseScheduledJob(record)
+
+			if tt.jobType == "" {
+				if err == nil {
+					t.Error("expected an error for a missing job type")
+				}
+				return
+			}
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if job.Name != "Nightly report" || job.JobType != tt.jobType || !job.Enabled {
+				t.Errorf("unexpected scheduled job: %+v", job)
+			}
+		})
+	}
+}
+
+func TestScheduledJobNextRun(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		cronExpr string
+		timezone string
+		after    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "later the same day",
+			cronExpr: "0 2 * * *",
+			after:    time.Date(2025, 1, 10, 1, 30, 0, 0, time.UTC),
+			expected: time.Date(2025, 1, 10, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "next day once today's run passed",
+			cronExpr: "0 2 * * *",
+			after:    time.Date(2025, 1, 10, 2, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 1, 11, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekdays only",
+			cronExpr: "30 8 * * 1-5",
+			after:    time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC), // Friday
+			expected: time.Date(2025, 1, 13, 8, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "evaluated in the schedule timezone",
+			cronExpr: "0 2 * * *",
+			timezone: "America/New_York",
+			after:    time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 1, 10, 2, 0, 0, 0, newYork),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := ParseScheduledJob(newTestScheduledJobRecord(tt.cronExpr, tt.timezone))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			next, err := job.NextRun(tt.after)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !next.Equal(tt.expected) {
+				t.Errorf("expected next run %v, got %v", tt.expected, next)
+			}
+			if !job.IsDue(next) {
+				t.Errorf("expected the schedule to be due at its next run %v", next)
+			}
+		})
+	}
+}
+
+func TestScheduledJobNextRunNeverMatches(t *testing.T) {
+	job, err := ParseScheduledJob(newTestScheduledJobRecord("0 0 31 2 *", ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := job.NextRun(time.Now()); !errors.Is(err, ErrNoNextRun) {
+		t.Errorf("expected ErrNoNextRun, got %v", err)
+	}
+}
+
+func TestScheduledJobBuildPayload(t *testing.T) {
+	record := newTestScheduledJobRecord("0 2 * * *", "")
+	record.Set("payload", map[string]any{
+		"data": map[string]any{
+			"operation": "export",
+			"target":    "report-{{.Date}}.csv",
+			"tags":      []any{"{{.ScheduleName}}", "nightly"},
+		},
+		"options": map[string]any{"timeout": 600},
+	})
+
+	job, err := ParseScheduledJob(record)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	payload, err := job.BuildPayload(time.Date(2025, 3, 1, 2, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if payload["type"] != JobTypeDataProcessing {
+		t.Errorf("expected the job type to be set, got %v", payload["type"])
+	}
+
+	data := payload["data"].(map[string]any)
+	if data["target"] != "report-2025-03-01.csv" {
+		t.Errorf("expected the date to be rendered, got %v", data["target"])
+	}
+	if tags := data["tags"].([]any); tags[0] != "Nightly report" || tags[1] != "nightly" {
+		t.Errorf("expected list values to be rendered, got %v", tags)
+	}
+
+	// the template itself is left untouched for the next runs
+	if job.Payload["data"].(map[string]any)["target"] != "report-{{.Date}}.csv" {
+		t.Error("expected the payload template not to be modified")
+	}
+
+	job.Payload = map[string]any{"data": map[string]any{"target": "{{.Unknown}}"}}
+	if _, err := job.BuildPayload(time.Now()); err == nil {
+		t.Error("expected an error for an unknown placeholder")
+	}
+}
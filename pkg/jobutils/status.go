@@ -0,0 +1,226 @@
+package jobutils
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"mime"
+	"path/filepath"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// ErrJobNotFound is returned when neither the job nor an export file it produced exists
+var ErrJobNotFound = errors.New("job not found")
+
+// JobProgress is the progress last reported by the handler of a job
+type JobProgress struct {
+	Percent int    `json:"percent"`
+	Message string `json:"message"`
+}
+
+// JobStatus is the state of a job as reported by the job status API
+type JobStatus struct {
+	JobID         string          `json:"job_id"`
+	Name          string          `json:"name"`
+	Type          string          `json:"type"`
+	Queue         string          `json:"queue"`
+	OwnerID       string          `json:"user_id"`
+	Status        string          `json:"status"`
+	Progress      JobProgress     `json:"progress"`
+	Attempts      int             `json:"attempts"`
+	MaxAttempts   int             `json:"max_attempts"`
+	LastError     string          `json:"last_error"`
+	FailureReason string          `json:"failure_reason"`
+	CreatedAt     *time.Time      `json:"created_at"`
+	UpdatedAt     *time.Time      `json:"updated_at"`
+	StartedAt     *time.Time      `json:"started_at"`
+	CompletedAt   *time.Time      `json:"completed_at"`
+	AvailableAt   *time.Time      `json:"available_at"`
+	Result        json.RawMessage `json:"result"`
+}
+
+// ToMap returns the status as response data
+func (s *JobStatus) ToMap() map[string]any {
+	var result any
+	if len(s.Result) > 0 {
+		result = s.Result
+	}
+
+	return map[string]any{
+		"job_id":         s.JobID,
+		"name":           s.Name,
+		"type":           s.Type,
+		"queue":          s.Queue,
+		"user_id":        s.OwnerID,
+		"status":         s.Status,
+		"progress":       s.Progress,
+		"attempts":       s.Attempts,
+		"max_attempts":   s.MaxAttempts,
+		"last_error":     s.LastError,
+		"failure_reason": s.FailureReason,
+		"created_at":     s.CreatedAt,
+		"updated_at":     s.UpdatedAt,
+		"started_at":     s.StartedAt,
+		"completed_at":   s.CompletedAt,
+		"available_at":   s.AvailableAt,
+		"result":         result,
+	}
+}
+
+// GetJobStatus returns the status of a job. Completed jobs are pruned after the retention
+// window, but their export files outlive them, so a pruned export job is still reported as
+// completed with its file as the result. Any other unknown job returns ErrJobNotFound.
+func GetJobStatus(app core.App, jobId string) (*JobStatus, error) {
+	record, err := app.FindRecordById(QueuesCollection, jobId)
+	if err == nil {
+		return JobStatusFromRecord(record), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	exportRecord, err := app.FindFirstRecordByFilter(
+		ExportFilesCollectionName,
+		"job_id = {:job_id}",
+		dbx.Params{"job_id": jobId},
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return exportJobStatus(jobId, exportRecord)
+}
+
+// JobStatusFromRecord builds the status of a job from its queue record
+func JobStatusFromRecord(record *core.Record) *JobStatus {
+	status := record.GetString("status")
+	if status == "" {
+		// records created before the lifecycle fields existed
+		status = JobStatusQueued
+		if record.GetString("reserved_at") != "" {
+			status = JobStatusProcessing
+		}
+	}
+
+	var result json.RawMessage
+	if raw := record.GetString("result"); raw != "" && raw != "null" {
+		result = json.RawMessage(raw)
+	}
+
+	payload := map[string]any{}
+	_ = record.UnmarshalJSONField("payload", &payload)
+	jobType, _ := payload["type"].(string)
+
+	return &JobStatus{
+		JobID:   record.Id,
+		Name:    record.GetString("name"),
+		Type:    jobType,
+		Queue:   record.GetString("queue"),
+		OwnerID: record.GetString("user_id"),
+		Status:  status,
+		Progress: JobProgress{
+			Percent: record.GetInt("progress"),
+			Message: record.GetString("progress_message"),
+		},
+		Attempts:      record.GetInt("attempts"),
+		MaxAttempts:   GetMaxAttempts(record),
+		LastError:     record.GetString("last_error"),
+		FailureReason: record.GetString("failure_reason"),
+		CreatedAt:     optionalTime(record.GetDateTime("created")),
+		UpdatedAt:     optionalTime(record.GetDateTime("updated")),
+		StartedAt:     optionalTime(record.GetDateTime("started_at")),
+		CompletedAt:   optionalTime(record.GetDateTime("completed_at")),
+		AvailableAt:   optionalTime(record.GetDateTime("available_at")),
+		Result:        result,
+	}
+}
+
+// JobDetails is the full state of a job as reported by the job administration API
+type JobDetails struct {
+	*JobStatus
+	Description    string          `json:"description"`
+	Priority       int             `json:"priority"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	BatchID        string          `json:"batch_id"`
+	ParentID       string          `json:"parent_id"`
+	ReservedAt     *time.Time      `json:"reserved_at"`
+	Payload        map[string]any  `json:"payload"`
+	ErrorHistory   []JobErrorEntry `json:"error_history"`
+}
+
+// ToMap returns the details as response data
+func (d *JobDetails) ToMap() map[string]any {
+	data := d.JobStatus.ToMap()
+	data["description"] = d.Description
+	data["priority"] = d.Priority
+	data["idempotency_key"] = d.IdempotencyKey
+	data["batch_id"] = d.BatchID
+	data["parent_id"] = d.ParentID
+	data["reserved_at"] = d.ReservedAt
+	data["payload"] = d.Payload
+	data["error_history"] = d.ErrorHistory
+	return data
+}
+
+// JobDetailsFromRecord builds the details of a job from its queue record
+func JobDetailsFromRecord(record *core.Record) *JobDetails {
+	payload := map[string]any{}
+	_ = record.UnmarshalJSONField("payload", &payload)
+
+	return &JobDetails{
+		JobStatus:      JobStatusFromRecord(record),
+		Description:    record.GetString("description"),
+		Priority:       record.GetInt("priority"),
+		IdempotencyKey: record.GetString("idempotency_key"),
+		BatchID:        record.GetString("batch_id"),
+		ParentID:       record.GetString("parent_id"),
+		ReservedAt:     optionalTime(record.GetDateTime("reserved_at")),
+		Payload:        payload,
+		ErrorHistory:   GetErrorHistory(record),
+	}
+}
+
+// exportJobStatus reports a pruned export job as completed, with its export file as the result
+func exportJobStatus(jobId string, exportRecord *core.Record) (*JobStatus, error) {
+	created := exportRecord.GetDateTime("created")
+	fileName := exportRecord.GetString("file")
+
+	result, err := json.Marshal(&FileExportResult{
+		BaseJobResultData: BaseJobResultData{
+			Message:   "Export file available",
+			Timestamp: created.Time(),
+		},
+		ExportRecordId: exportRecord.Id,
+		FileName:       fileName,
+		RecordCount:    exportRecord.GetInt("record_count"),
+		ContentType:    mime.TypeByExtension(filepath.Ext(fileName)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &JobStatus{
+		JobID:       jobId,
+		OwnerID:     exportRecord.GetString("user_id"),
+		Status:      JobStatusCompleted,
+		Progress:    JobProgress{Percent: 100},
+		CompletedAt: optionalTime(created),
+		Result:      result,
+	}, nil
+}
+
+// optionalTime converts a date field into a time, nil when the field is empty
+func optionalTime(value types.DateTime) *time.Time {
+	if value.IsZero() {
+		return nil
+	}
+	t := value.Time()
+	return &t
+}
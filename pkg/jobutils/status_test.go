@@ -0,0 +1,84 @@
+package jobutils
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestJobStatusFromRecord(t *testing.T) {
+	startedAt := types.NowDateTime()
+
+	record := newTestQueueRecord()
+	record.Id = "status-job"
+	record.Set("name", "Export users")
+	record.Set("status", JobStatusProcessing)
+	record.Set("attempts", 1)
+	record.Set("max_attempts", 5)
+	record.Set("last_error", "connection reset")
+	record.Set("failure_reason", JobFailureError)
+	record.Set("progress", 40)
+	record.Set("progress_message", "Converting users")
+	record.Set("started_at", startedAt)
+
+	status := JobStatusFromRecord(record)
+
+	if status.JobID != "status-job" || status.Name != "Export users" || status.Status != JobStatusProcessing {
+		t.Errorf("unexpected status identity %+v", status)
+	}
+	if status.Progress != (JobProgress{Percent: 40, Message: "Converting users"}) {
+		t.Errorf("unexpected progress %+v", status.Progress)
+	}
+	if status.Attempts != 1 || status.MaxAttempts != 5 {
+		t.Errorf("unexpected attempts %d/%d", status.Attempts, status.MaxAttempts)
+	}
+	if status.LastError != "connection reset" || status.FailureReason != JobFailureError {
+		t.Errorf("unexpected failure %q (%s)", status.LastError, status.FailureReason)
+	}
+	if status.StartedAt == nil || !status.StartedAt.Equal(startedAt.Time()) {
+		t.Errorf("expected started_at %v, got %v", startedAt.Time(), status.StartedAt)
+	}
+	if status.CompletedAt != nil {
+		t.Errorf("expected no completed_at, got %v", status.CompletedAt)
+	}
+	if status.Result != nil {
+		t.Errorf("expected no result, got %s", status.Result)
+	}
+}
+
+func TestJobStatusFromRecordWithResult(t *testing.T) {
+	record := newTestQueueRecord()
+	record.Set("status", JobStatusCompleted)
+	record.Set("progress", 100)
+	record.Set("result", types.JSONRaw(`{"message":"sent","timestamp":"2025-01-01T00:00:00Z","recipients":["a@example.com"]}`))
+
+	status := JobStatusFromRecord(record)
+
+	var result EmailResult
+	if err := json.Unmarshal(status.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if len(result.Recipients) != 1 || result.Recipients[0] != "a@example.com" {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	data := status.ToMap()
+	if data["status"] != JobStatusCompleted || data["result"] == nil {
+		t.Errorf("unexpected response data %v", data)
+	}
+}
+
+func TestJobStatusFromLegacyRecord(t *testing.T) {
+	record := newTestQueueRecord()
+
+	if status := JobStatusFromRecord(record).Status; status != JobStatusQueued {
+		t.Errorf("expected legacy record without reservation to be queued, got %s", status)
+	}
+
+	record.Set("reserved_at", types.NowDateTime().Add(-time.Minute))
+	if status := JobStatusFromRecord(record).Status; status != JobStatusProcessing {
+		t.Errorf("expected legacy reserved record to be processing, got %s", status)
+	}
+}
@@ -1,6 +1,7 @@
 package jobutils
 
 import (
+	"context"
 	"ims-pocketbase-baas-starter/pkg/cronutils"
 	"sync"
 	"time"
@@ -16,9 +17,13 @@ type JobRegistry struct {
 
 // JobProcessor coordinates job execution and queue management
 type JobProcessor struct {
-	app        *pocketbase.PocketBase
-	registry   *JobRegistry
-	workerPool *WorkerPool
+	app          *pocketbase.PocketBase
+	registry     *JobRegistry
+	workerPool   *WorkerPool            // Worker pool of the default queue
+	queuePools   map[string]*WorkerPool // Dedicated worker pool per named queue
+	queueConfigs QueueConfigs
+	lastPolled   map[string]time.Time
+	mu           sync.Mutex
 }
 
 // JobHandler defines the interface that all job handlers must implement
@@ -29,17 +34,48 @@ type JobHandler interface {
 	GetJobType() string
 }
 
+// ContextJobHandler is implemented by job handlers that honour cancellation. The context carries
+// the job deadline and is also cancelled when the app shuts down.
+type ContextJobHandler interface {
+	JobHandler
+	// HandleContext processes a job and should return early once ctx is done
+	HandleContext(ctx context.Context, execCtx *cronutils.CronExecutionContext, job *JobData) error
+}
+
+// JobTimeoutProvider can be implemented by job handlers to set the default timeout of their jobs
+// (used when the payload has no options.timeout)
+type JobTimeoutProvider interface {
+	// DefaultTimeout returns how long a job may run before it is failed with a timeout
+	DefaultTimeout() time.Duration
+}
+
+// PayloadValidator can be implemented by job handlers to reject invalid payloads at enqueue time
+type PayloadValidator interface {
+	// ValidatePayload returns an error if the job payload cannot be processed by the handler
+	ValidatePayload(job *JobData) error
+}
+
 // JobData represents standardized job data extracted from queue records
 type JobData struct {
-	ID          string         // Job ID from queues table
-	Name        string         // Job name
-	Description string         // Job description
-	Type        string         // Job type extracted from payload
-	Payload     map[string]any // Parsed JSON payload
-	Attempts    int            // Current attempt count
-	ReservedAt  *time.Time     // When job was reserved
-	CreatedAt   time.Time      // When job was created
-	UpdatedAt   time.Time      // When job was updated
+	ID            string         // Job ID from queues table
+	Name          string         // Job name
+	Description   string         // Job description
+	OwnerID       string         // ID of the user the job belongs to (empty for system jobs)
+	BatchID       string         // Batch the job belongs to (empty outside batches)
+	ParentID      string         // Job whose completion queued this chained job (empty if not chained)
+	Queue         string         // Queue the job was placed on
+	Priority      int            // Higher priority jobs are picked up first
+	Type          string         // Job type extracted from payload
+	Payload       map[string]any // Parsed JSON payload
+	Status        string         // Lifecycle status (queued, processing, completed, failed, dead)
+	Attempts      int            // Current attempt count
+	MaxAttempts   int            // Attempts allowed before the job is moved to the dead-letter set
+	LastError     string         // Error message from the most recent failed attempt
+	FailureReason string         // Reason of the most recent failure (error, timeout, panic, permanent)
+	ReservedAt    *time.Time     // When job was reserved
+	AvailableAt   *time.Time     // Earliest time the job may run (nil means immediately)
+	CreatedAt     time.Time      // When job was created
+	UpdatedAt     time.Time      // When job was updated
 }
 
 // JobResult represents the result of job execution
@@ -58,6 +94,8 @@ type BaseJobPayload struct {
 }
 
 // UserExportJobData represents the data section for user export jobs
+//
+// Deprecated: user exports are data processing jobs, use DataProcessingJobData.
 type UserExportJobData struct {
 	Format string   `json:"format"`
 	Fields []string `json:"fields"`
@@ -65,6 +103,8 @@ type UserExportJobData struct {
 }
 
 // UserExportJobOptions represents the options section for user export jobs
+//
+// Deprecated: user exports are data processing jobs, use DataProcessingJobOptions.
 type UserExportJobOptions struct {
 	FilenamePrefix string `json:"filename_prefix"`
 	StoreResult    bool   `json:"store_result"`
@@ -72,6 +112,8 @@ type UserExportJobOptions struct {
 }
 
 // UserExportJobPayload represents the complete payload for user export jobs
+//
+// Deprecated: user exports are data processing jobs, use DataProcessingJobPayload.
 type UserExportJobPayload struct {
 	Type    string               `json:"type"`
 	Data    UserExportJobData    `json:"data"`
@@ -101,14 +143,23 @@ type EmailJobPayload struct {
 
 // DataProcessingJobData represents the data section for data processing jobs
 type DataProcessingJobData struct {
-	Operation string `json:"operation"`
-	Source    string `json:"source"`
-	Target    string `json:"target"`
+	Operation string            `json:"operation"`
+	Source    string            `json:"source"`              // Collection name for exports, import_files record ID for imports
+	Target    string            `json:"target"`              // File format for exports (e.g. csv), collection name for imports
+	Filter    string            `json:"filter,omitempty"`    // PocketBase filter of the exported records
+	Sort      string            `json:"sort,omitempty"`      // PocketBase sort of the exported records (e.g. -created)
+	Fields    []string          `json:"fields,omitempty"`    // Exported fields, relation.field for a related field (all visible fields when empty)
+	Mapping   map[string]string `json:"mapping,omitempty"`   // Imported file column -> collection field (columns named after a field when empty)
+	Mode      string            `json:"mode,omitempty"`      // Import mode: insert (default) or upsert
+	KeyField  string            `json:"key_field,omitempty"` // Field matching the existing records of an upsert (id when empty)
 }
 
 // DataProcessingJobOptions represents the options section for data processing jobs
 type DataProcessingJobOptions struct {
-	Timeout int `json:"timeout,omitempty"`
+	Timeout        int    `json:"timeout,omitempty"`
+	FilenamePrefix string `json:"filename_prefix,omitempty"` // Start of the export file name (<source>_export when empty)
+	ResultExpiry   string `json:"result_expiry,omitempty"`   // How long the export file is kept (e.g. 72h; EXPORT_FILE_EXPIRATION_DAYS when empty)
+	DryRun         bool   `json:"dry_run,omitempty"`         // Validate an import and report its errors without saving the records
 }
 
 // DataProcessingJobPayload represents the complete payload for data processing jobs
@@ -149,12 +200,70 @@ type DataProcessingResult struct {
 	OutputLocation   string `json:"output_location,omitempty"`
 }
 
+// DataImportResult represents the result data for data import jobs
+type DataImportResult struct {
+	BaseJobResultData
+	Collection     string `json:"collection"`
+	DryRun         bool   `json:"dry_run"`
+	TotalRows      int    `json:"total_rows"`
+	Created        int    `json:"created"`                    // Records created (to be created in a dry run)
+	Updated        int    `json:"updated"`                    // Records updated (to be updated in a dry run)
+	Failed         int    `json:"failed"`                     // Rows left out, listed in the error report
+	ReportRecordId string `json:"report_record_id,omitempty"` // export_files record of the error report
+	ReportFileName string `json:"report_file_name,omitempty"`
+}
+
+// JobErrorEntry records one failed attempt of a job in its error history
+type JobErrorEntry struct {
+	Attempt       int       `json:"attempt"`
+	Error         string    `json:"error"`
+	FailureReason string    `json:"failure_reason"`
+	FailedAt      time.Time `json:"failed_at"`
+}
+
 // Job status constants
 const (
 	JobStatusQueued     = "queued"
 	JobStatusProcessing = "processing"
 	JobStatusCompleted  = "completed"
 	JobStatusFailed     = "failed"
+	JobStatusDead       = "dead"
+	JobStatusCanceled   = "canceled"
+)
+
+// Job failure reason constants
+const (
+	JobFailureError     = "error"     // Handler returned an error
+	JobFailureTimeout   = "timeout"   // Job exceeded its deadline
+	JobFailurePanic     = "panic"     // Handler panicked
+	JobFailurePermanent = "permanent" // Handler returned a PermanentError or the job is invalid
+)
+
+// Job lifecycle defaults
+const (
+	DefaultMaxAttempts                = 3
+	DefaultCompletedJobRetentionHours = 24
+	DefaultReservationTimeoutMinutes  = 5
+	DefaultShutdownGraceSeconds       = 30
+	DefaultHandlerGraceSeconds        = 30
+	DefaultJobTimeoutSeconds          = 30
+	DefaultBackoffBaseSeconds         = 30
+	DefaultBackoffMaxSeconds          = 3600
+	DefaultBackoffJitterPercent       = 20
+)
+
+// Queue name constants
+const (
+	DefaultQueueName = "default" // Queue used when an enqueuer does not pick one
+	QueueEmails      = "emails"  // Transactional emails (password resets, welcome emails, ...)
+	QueueExports     = "exports" // Long running data exports and imports
+)
+
+// Queue processing defaults
+const (
+	DefaultQueueWorkers             = 5
+	DefaultQueueBatchSize           = 50
+	DefaultQueuePollIntervalSeconds = 5
 )
 
 // Job type constants
@@ -172,12 +281,19 @@ const (
 )
 
 const (
-	DataProcessingFileCSV  = "csv"
-	DataProcessingFileXLSX = "xlsx"
-	DataProcessingFileJSON = "json"
-	DataProcessingFilePDF  = "pdf"
+	DataProcessingFileCSV    = "csv"
+	DataProcessingFileXLSX   = "xlsx"
+	DataProcessingFileJSON   = "json"
+	DataProcessingFileNDJSON = "ndjson"
+	DataProcessingFilePDF    = "pdf"
 )
 
 const (
 	DataProcessingCollectionUsers = "users"
 )
+
+// Data import mode constants
+const (
+	DataImportModeInsert = "insert" // Every row creates a record
+	DataImportModeUpsert = "upsert" // Rows update the record with the same key field, or create one
+)
@@ -2,8 +2,8 @@ package jobutils
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"ims-pocketbase-baas-starter/pkg/cronutils"
 	log "ims-pocketbase-baas-starter/pkg/logger"
 	"sync"
 	"time"
@@ -12,6 +12,9 @@ import (
 	"github.com/pocketbase/pocketbase/core"
 )
 
+// ErrWorkerPoolShutdown is returned for jobs handed to a worker pool that is shutting down
+var ErrWorkerPoolShutdown = errors.New("worker pool is shutdown")
+
 // WorkerPool manages a pool of persistent workers for job processing
 type WorkerPool struct {
 	workers     []*Worker
@@ -24,6 +27,12 @@ type WorkerPool struct {
 	registry    *JobRegistry
 	isShutdown  bool
 	mu          sync.RWMutex
+	waiters     map[string]chan WorkerJobResult // Result channels of ProcessJobs batches, keyed by job ID
+	inFlight    map[string]struct{}             // Jobs handed to a worker that have not reported back yet
+	freed       chan struct{}                   // Signaled whenever a worker finishes a job
+	stateMu     sync.Mutex
+	ctx         context.Context    // Parent context of every job, cancelled once the shutdown grace period expires
+	cancel      context.CancelFunc // Cancels ctx
 }
 
 // Worker represents a single worker in the pool
@@ -34,6 +43,7 @@ type Worker struct {
 	quit        chan bool
 	app         *pocketbase.PocketBase
 	registry    *JobRegistry
+	ctx         context.Context
 }
 
 // WorkerJobResult represents the result of job processing
@@ -50,6 +60,7 @@ func NewWorkerPool(app *pocketbase.PocketBase, registry *JobRegistry, maxWorkers
 
 	jobQueueSize := maxWorkers * 10
 	resultQueueSize := maxWorkers * 10
+	ctx, cancel := context.WithCancel(context.Background())
 
 	pool := &WorkerPool{
 		workers:     make([]*Worker, 0, maxWorkers),
@@ -60,6 +71,11 @@ func NewWorkerPool(app *pocketbase.PocketBase, registry *JobRegistry, maxWorkers
 		app:         app,
 		registry:    registry,
 		isShutdown:  false,
+		waiters:     make(map[string]chan WorkerJobResult),
+		inFlight:    make(map[string]struct{}),
+		freed:       make(chan struct{}, 1),
+		ctx:         ctx,
+		cancel:      cancel,
 	}
 
 	for i := 0; i < maxWorkers; i++ {
@@ -67,19 +83,109 @@ func NewWorkerPool(app *pocketbase.PocketBase, registry *JobRegistry, maxWorkers
 			id:          i,
 			jobQueue:    pool.jobQueue,
 			resultQueue: pool.resultQueue,
-			quit:        make(chan bool),
+			quit:        pool.quit,
 			app:         app,
 			registry:    registry,
+			ctx:         ctx,
 		}
 		pool.workers = append(pool.workers, worker)
 		pool.wg.Add(1)
 		go worker.start(&pool.wg)
 	}
 
+	go pool.routeResults()
+
 	log.Info("Worker pool started", "workers", maxWorkers, "job_queue_size", jobQueueSize)
 	return pool
 }
 
+// routeResults hands worker results to the ProcessJobs batch waiting for them (if any)
+// and frees the capacity taken by the finished job
+func (wp *WorkerPool) routeResults() {
+	for result := range wp.resultQueue {
+		wp.stateMu.Lock()
+		delete(wp.inFlight, result.JobID)
+		waiter, exists := wp.waiters[result.JobID]
+		delete(wp.waiters, result.JobID)
+		wp.stateMu.Unlock()
+
+		if exists {
+			waiter <- result
+		} else if result.Error != nil {
+			log.Debug("Dispatched job finished with error", "job_id", result.JobID, "error", result.Error)
+		}
+
+		select {
+		case wp.freed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Available returns how many workers are not busy with a job
+func (wp *WorkerPool) Available() int {
+	wp.stateMu.Lock()
+	defer wp.stateMu.Unlock()
+
+	if available := wp.maxWorkers - len(wp.inFlight); available > 0 {
+		return available
+	}
+	return 0
+}
+
+// Freed is signaled whenever a worker finishes a job and capacity becomes available
+func (wp *WorkerPool) Freed() <-chan struct{} {
+	return wp.freed
+}
+
+// Submit hands a job to the pool without waiting for its result. It returns false when the
+// pool is shut down, every worker is busy or the job is already in flight.
+func (wp *WorkerPool) Submit(job *core.Record) bool {
+	wp.mu.RLock()
+	defer wp.mu.RUnlock()
+
+	if wp.isShutdown || !wp.track(job.Id, nil) {
+		return false
+	}
+
+	select {
+	case wp.jobQueue <- job:
+		return true
+	default:
+		wp.untrack(job.Id)
+		return false
+	}
+}
+
+// track marks a job as in flight unless it already is or every worker is busy.
+// A waiter channel receives the job result once a worker finishes it.
+func (wp *WorkerPool) track(jobId string, waiter chan WorkerJobResult) bool {
+	wp.stateMu.Lock()
+	defer wp.stateMu.Unlock()
+
+	if _, exists := wp.inFlight[jobId]; exists {
+		return false
+	}
+	if waiter == nil && len(wp.inFlight) >= wp.maxWorkers {
+		return false
+	}
+
+	wp.inFlight[jobId] = struct{}{}
+	if waiter != nil {
+		wp.waiters[jobId] = waiter
+	}
+	return true
+}
+
+// untrack forgets a job that could not be handed to a worker
+func (wp *WorkerPool) untrack(jobId string) {
+	wp.stateMu.Lock()
+	defer wp.stateMu.Unlock()
+
+	delete(wp.inFlight, jobId)
+	delete(wp.waiters, jobId)
+}
+
 // IsShutdown returns whether the worker pool has been shut down
 func (wp *WorkerPool) IsShutdown() bool {
 	wp.mu.RLock()
@@ -91,10 +197,9 @@ func (wp *WorkerPool) IsShutdown() bool {
 func (wp *WorkerPool) ProcessJobs(jobs []*core.Record) []error {
 	// Check if pool is shutdown
 	if wp.IsShutdown() {
-		err := fmt.Errorf("worker pool is shutdown")
 		results := make([]error, len(jobs))
 		for i := range results {
-			results[i] = err
+			results[i] = ErrWorkerPoolShutdown
 		}
 		return results
 	}
@@ -109,13 +214,29 @@ func (wp *WorkerPool) ProcessJobs(jobs []*core.Record) []error {
 	}
 
 	// Send jobs to workers
+	batchResults := make(chan WorkerJobResult, len(jobs))
 	sendErrors := make([]error, len(jobs))
 	jobsSent := 0
 	for i, job := range jobs {
+		if !wp.track(job.Id, batchResults) {
+			sendErrors[i] = fmt.Errorf("%w: job %s is already being processed by this pool", ErrJobNotClaimed, job.Id)
+			continue
+		}
+
+		if wp.IsShutdown() {
+			wp.untrack(job.Id)
+			sendErrors[i] = ErrWorkerPoolShutdown
+			continue
+		}
+
 		select {
 		case wp.jobQueue <- job:
 			jobsSent++
+		case <-wp.quit:
+			wp.untrack(job.Id)
+			sendErrors[i] = ErrWorkerPoolShutdown
 		case <-time.After(30 * time.Second):
+			wp.untrack(job.Id)
 			err := fmt.Errorf("job queue timeout for job %s", job.Id)
 			log.Error("Job queue timeout", "job_id", job.Id)
 			sendErrors[i] = err
@@ -134,7 +255,7 @@ func (wp *WorkerPool) ProcessJobs(jobs []*core.Record) []error {
 
 	for i := 0; i < jobsSent; i++ {
 		select {
-		case result := <-wp.resultQueue:
+		case result := <-batchResults:
 			if jobIndex, exists := jobIndexMap[result.JobID]; exists {
 				results[jobIndex] = result.Error
 			} else {
@@ -185,7 +306,9 @@ func (wp *WorkerPool) ProcessJobsConcurrently(jobs []*core.Record, maxWorkers in
 	return wp.ProcessJobs(jobs)
 }
 
-// Shutdown gracefully shuts down the worker pool
+// Shutdown gracefully shuts down the worker pool: it stops accepting new jobs, drops the jobs
+// that were handed to the pool but not started yet, and waits for running jobs to finish until
+// ctx is done. Jobs still running at that point are canceled; see also ReleaseActiveJobs.
 func (wp *WorkerPool) Shutdown(ctx context.Context) error {
 	wp.mu.Lock()
 	if wp.isShutdown {
@@ -194,32 +317,57 @@ func (wp *WorkerPool) Shutdown(ctx context.Context) error {
 		return nil
 	}
 	wp.isShutdown = true
+	close(wp.quit)
 	wp.mu.Unlock()
 
 	log.Info("Shutting down worker pool")
-	close(wp.jobQueue)
 
 	done := make(chan struct{})
 	go func() {
 		wp.wg.Wait()
+		wp.dropPendingJobs()
+		// every worker has exited, no more results can be sent
+		close(wp.resultQueue)
 		close(done)
 	}()
 
 	select {
 	case <-done:
+		wp.cancel()
 		log.Info("Worker pool shutdown completed")
 		return nil
 	case <-ctx.Done():
-		close(wp.quit)
-		log.Warn("Worker pool force shutdown due to timeout")
+		// cancel the running jobs, context-aware handlers return early and release their job
+		wp.cancel()
+		log.Warn("Worker pool shutdown grace period expired, running jobs canceled")
 		return ctx.Err()
 	}
 }
 
+// dropPendingJobs discards the jobs that were handed to the pool but never picked up by a worker.
+// They were not claimed yet, so they stay pending for the next poll or another server instance.
+func (wp *WorkerPool) dropPendingJobs() {
+	for {
+		select {
+		case job := <-wp.jobQueue:
+			wp.resultQueue <- WorkerJobResult{JobID: job.Id, Error: ErrWorkerPoolShutdown}
+		default:
+			return
+		}
+	}
+}
+
 func (w *Worker) start(wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	for {
+		// stop before taking another job once the pool is shutting down
+		select {
+		case <-w.quit:
+			return
+		default:
+		}
+
 		select {
 		case job, ok := <-w.jobQueue:
 			if !ok {
@@ -241,101 +389,9 @@ func (w *Worker) start(wg *sync.WaitGroup) {
 }
 
 func (w *Worker) processJob(record *core.Record) error {
-	if record == nil || record.Id == "" || record.Collection().Name != QueuesCollection {
-		return fmt.Errorf("invalid job record")
+	ctx := w.ctx
+	if ctx == nil {
+		ctx = context.Background()
 	}
-
-	originalReservedAt := record.GetString("reserved_at")
-	if originalReservedAt != "" && w.isJobReserved(record) {
-		return fmt.Errorf("job %s is already reserved", record.Id)
-	}
-
-	now := time.Now()
-	record.Set("reserved_at", now.Format(time.RFC3339))
-
-	if err := w.app.Save(record); err != nil {
-		record.Set("reserved_at", originalReservedAt)
-		return fmt.Errorf("failed to reserve job %s: %w", record.Id, err)
-	}
-
-	jobData, err := ParseJobDataFromRecord(record)
-	if err != nil {
-		failErr := w.failJob(record, fmt.Errorf("failed to parse job data: %w", err))
-		if failErr != nil {
-			log.Error("Failed to mark job as failed", "job_id", record.Id, "worker_id", w.id, "error", failErr)
-		}
-		return err
-	}
-
-	handler, err := w.registry.GetHandler(jobData.Type)
-	if err != nil {
-		failErr := w.failJob(record, fmt.Errorf("no handler for job type '%s': %w", jobData.Type, err))
-		if failErr != nil {
-			log.Error("Failed to mark job as failed", "job_id", record.Id, "worker_id", w.id, "error", failErr)
-		}
-		return err
-	}
-
-	// Execute job with panic recovery
-	var jobErr error
-	func() {
-		defer func() {
-			if r := recover(); r != nil {
-				jobErr = fmt.Errorf("job handler panicked: %v", r)
-				log.Error("Job handler panic", "job_id", record.Id, "worker_id", w.id, "panic", r)
-			}
-		}()
-
-		ctx := cronutils.NewCronExecutionContext(w.app, record.Id)
-		ctx.LogStart(fmt.Sprintf("Processing %s job: %s", jobData.Type, jobData.Name))
-		jobErr = handler.Handle(ctx, jobData)
-
-		if jobErr == nil {
-			ctx.LogEnd("Job processed successfully")
-		}
-	}()
-
-	if jobErr != nil {
-		log.Error("Job failed", "job_id", record.Id, "worker_id", w.id, "job_type", jobData.Type, "error", jobErr)
-		failErr := w.failJob(record, jobErr)
-		if failErr != nil {
-			log.Error("Failed to mark job as failed", "job_id", record.Id, "worker_id", w.id, "error", failErr)
-		}
-		return jobErr
-	}
-
-	if err := w.app.Delete(record); err != nil {
-		log.Error("Failed to complete job", "job_id", record.Id, "worker_id", w.id, "error", err)
-		return err
-	}
-
-	log.Info("Job completed successfully", "job_id", record.Id, "worker_id", w.id, "job_type", jobData.Type)
-	return nil
-}
-
-func (w *Worker) isJobReserved(record *core.Record) bool {
-	reservedAtStr := record.GetString("reserved_at")
-	if reservedAtStr == "" {
-		return false
-	}
-
-	reservedAt, err := time.Parse(time.RFC3339, reservedAtStr)
-	if err != nil {
-		return false
-	}
-
-	return time.Since(reservedAt) < 5*time.Minute
-}
-
-func (w *Worker) failJob(record *core.Record, jobErr error) error {
-	currentAttempts := int(record.GetFloat("attempts"))
-	record.Set("attempts", currentAttempts+1)
-	record.Set("reserved_at", "")
-
-	if err := w.app.Save(record); err != nil {
-		log.Error("Failed to update failed job", "job_id", record.Id, "error", err)
-		return fmt.Errorf("failed to update failed job: %w", err)
-	}
-
-	return jobErr
+	return runJob(ctx, w.app, w.registry, record, "worker_id", w.id)
 }
@@ -2,6 +2,7 @@ package jobutils
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -234,3 +235,56 @@ func TestWorker(t *testing.T) {
 	close(resultQueue)
 	close(quit)
 }
+
+func TestWorkerPool_Submit(t *testing.T) {
+	app := pocketbase.New()
+	pool := NewWorkerPool(app, NewJobRegistry(), 2)
+
+	if pool.Available() != 2 {
+		t.Fatalf("expected 2 available workers, got %d", pool.Available())
+	}
+
+	// a record outside the queues collection is rejected by the worker without touching the database
+	record := core.NewRecord(core.NewBaseCollection("not_queues"))
+	record.Id = "job123456789012"
+
+	if !pool.Submit(record) {
+		t.Fatal("expected job to be submitted")
+	}
+
+	select {
+	case <-pool.Freed():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the pool to signal the finished job")
+	}
+
+	if pool.Available() != 2 {
+		t.Errorf("expected capacity to be freed after the job finished, got %d", pool.Available())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = pool.Shutdown(ctx)
+
+	if pool.Submit(record) {
+		t.Error("expected submit to fail after shutdown")
+	}
+}
+
+func TestWorkerPool_ProcessJobsAfterShutdown(t *testing.T) {
+	pool := NewWorkerPool(pocketbase.New(), NewJobRegistry(), 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := pool.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+
+	record := core.NewRecord(core.NewBaseCollection(QueuesCollection))
+	record.Id = "job123456789012"
+
+	results := pool.ProcessJobs([]*core.Record{record})
+	if len(results) != 1 || !errors.Is(results[0], ErrWorkerPoolShutdown) {
+		t.Errorf("expected ErrWorkerPoolShutdown, got %v", results)
+	}
+}
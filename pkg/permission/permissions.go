@@ -20,6 +20,19 @@ const (
 	RoleViewAll = "role.view.all"
 	RoleUpdate  = "role.update"
 	RoleDelete  = "role.delete"
+
+	// Job permissions
+	JobViewAll = "job.view.all"
+	JobRetry   = "job.retry"
+	JobCancel  = "job.cancel"
+	JobPurge   = "job.purge"
+
+	// Cron permissions
+	CronView   = "cron.view"
+	CronManage = "cron.manage"
+
+	// Data permissions
+	DataImport = "data.import"
 )
 
 // PermissionDefinition represents a permission with its metadata
@@ -46,5 +59,12 @@ func GetAllPermissions() []PermissionDefinition {
 		{Slug: RoleViewAll, Name: "View All Roles", Description: "Can view all roles"},
 		{Slug: RoleUpdate, Name: "Update Role", Description: "Can update role information"},
 		{Slug: RoleDelete, Name: "Delete Role", Description: "Can delete roles"},
+		{Slug: JobViewAll, Name: "View All Jobs", Description: "Can view the status and download the files of all jobs"},
+		{Slug: JobRetry, Name: "Retry Job", Description: "Can retry failed, dead and canceled jobs"},
+		{Slug: JobCancel, Name: "Cancel Job", Description: "Can cancel queued and running jobs"},
+		{Slug: JobPurge, Name: "Purge Jobs", Description: "Can delete completed, dead and canceled jobs"},
+		{Slug: CronView, Name: "View Crons", Description: "Can view the run history of the crons"},
+		{Slug: CronManage, Name: "Manage Crons", Description: "Can trigger, pause and resume crons"},
+		{Slug: DataImport, Name: "Import Data", Description: "Can import files into collections"},
 	}
 }
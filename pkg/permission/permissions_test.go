@@ -23,6 +23,13 @@ func TestPermissionConstants(t *testing.T) {
 		{"RoleViewAll constant", RoleViewAll, "role.view.all"},
 		{"RoleUpdate constant", RoleUpdate, "role.update"},
 		{"RoleDelete constant", RoleDelete, "role.delete"},
+		{"JobViewAll constant", JobViewAll, "job.view.all"},
+		{"JobRetry constant", JobRetry, "job.retry"},
+		{"JobCancel constant", JobCancel, "job.cancel"},
+		{"JobPurge constant", JobPurge, "job.purge"},
+		{"CronView constant", CronView, "cron.view"},
+		{"CronManage constant", CronManage, "cron.manage"},
+		{"DataImport constant", DataImport, "data.import"},
 	}
 
 	for _, tt := range tests {
@@ -37,7 +44,7 @@ func TestPermissionConstants(t *testing.T) {
 func TestGetAllPermissions(t *testing.T) {
 	permissions := GetAllPermissions()
 
-	expectedCount := 14 // Updated to include CacheClear permission
+	expectedCount := 21 // Updated to include the data import permission
 	if len(permissions) != expectedCount {
 		t.Errorf("Expected %d permissions, got %d", expectedCount, len(permissions))
 	}
@@ -72,6 +79,13 @@ func TestGetAllPermissions(t *testing.T) {
 		RoleViewAll:          {"View All Roles", "Can view all roles"},
 		RoleUpdate:           {"Update Role", "Can update role information"},
 		RoleDelete:           {"Delete Role", "Can delete roles"},
+		JobViewAll:           {"View All Jobs", "Can view the status and download the files of all jobs"},
+		JobRetry:             {"Retry Job", "Can retry failed, dead and canceled jobs"},
+		JobCancel:            {"Cancel Job", "Can cancel queued and running jobs"},
+		JobPurge:             {"Purge Jobs", "Can delete completed, dead and canceled jobs"},
+		CronView:             {"View Crons", "Can view the run history of the crons"},
+		CronManage:           {"Manage Crons", "Can trigger, pause and resume crons"},
+		DataImport:           {"Import Data", "Can import files into collections"},
 	}
 
 	returnedPerms := make(map[string]PermissionDefinition)
@@ -91,6 +91,16 @@ func ValidationError(e *core.RequestEvent, message string, validationErrors map[
 
 // File serves a file download from PocketBase filesystem
 func File(e *core.RequestEvent, fileName, basePath string) error {
+	return FileWithContentType(e, fileName, basePath, "")
+}
+
+// FileWithContentType serves a file download from PocketBase filesystem with a content type
+// (application/octet-stream when empty)
+func FileWithContentType(e *core.RequestEvent, fileName, basePath, contentType string) error {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
 	filesystem, err := e.App.NewFilesystem()
 	if err != nil {
 		return InternalServerError(e, "Failed to access filesystem", nil)
@@ -105,7 +115,7 @@ func File(e *core.RequestEvent, fileName, basePath string) error {
 	defer fileReader.Close()
 
 	e.Response.Header().Set("Content-Disposition", "attachment; filename=\""+fileName+"\"")
-	e.Response.Header().Set("Content-Type", "application/octet-stream")
+	e.Response.Header().Set("Content-Type", contentType)
 
 	_, err = io.Copy(e.Response, fileReader)
 	if err != nil {